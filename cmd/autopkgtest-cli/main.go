@@ -1,14 +1,25 @@
 package main
 
 import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
 	autopkgtestclient "github.com/canonical/autopkgtest-automation/internal/autopkgtest-client"
 	"github.com/canonical/autopkgtest-automation/internal/scraper"
 	triggerlinkgenerator "github.com/canonical/autopkgtest-automation/internal/trigger-link-generator"
@@ -16,80 +27,282 @@ import (
 
 const (
 	version = "0.1.0"
+
+	// exitUsage is the exit code for command-line usage errors
+	exitUsage = 2
+
+	// Exit codes of the trigger command, so that scripts can tell why it
+	// failed without parsing stderr; other errors exit with 1. They start
+	// at 3 since 2 is already taken by usage errors.
+	exitAuthRequired   = 3 // Authentication required
+	exitInvalidRequest = 4 // Test request rejected as invalid
+	exitTimeout        = 5 // -wait timed out before a test completed
+	exitTestFailure    = 6 // A test waited for ended in another status than pass or neutral
+
+	// defaultIndexPackage is tested on every supported suite and
+	// architecture, so its results matrix doubles as an index of them
+	defaultIndexPackage = "dpkg"
+
+	// defaultServerURL is the autopkgtest server used unless
+	// $AUTOPKGTEST_URL names another one
+	defaultServerURL = "https://autopkgtest.ubuntu.com"
 )
 
+// checkFormats lists the output formats supported by check -format
+var checkFormats = []string{"text", "table", "html", "json", "csv", "tsv", "markdown"}
+
+// triggerFormats lists the output formats supported by trigger -format
+var triggerFormats = []string{"text", "json"}
+
+// generateFormats lists the output formats supported by
+// generate-trigger-link -format
+var generateFormats = []string{"text", "yaml"}
+
+// colorModes lists the values accepted by -color
+var colorModes = []string{"auto", "always", "never"}
+
 func main() {
 	// Define subcommands
 	checkCmd := flag.NewFlagSet("check", flag.ExitOnError)
 	generateLinkCmd := flag.NewFlagSet("generate-trigger-link", flag.ExitOnError)
 	triggerCmd := flag.NewFlagSet("trigger", flag.ExitOnError)
+	fetchLogsCmd := flag.NewFlagSet("fetch-logs", flag.ExitOnError)
+	refreshIndexCmd := flag.NewFlagSet("refresh-index", flag.ExitOnError)
+	diffCmd := flag.NewFlagSet("diff", flag.ExitOnError)
+	queuesCmd := flag.NewFlagSet("queues", flag.ExitOnError)
 	versionCmd := flag.NewFlagSet("version", flag.ExitOnError)
 
 	// Check command flags
-	checkPackage := checkCmd.String("package", "", "Package name to check (required)")
+	checkPackage := checkCmd.String("package", "", "Package name to check (required unless -packages, -package-file or -from is given)")
+	checkPackages := checkCmd.String("packages", "", "Comma-separated package names to check together, with one combined report (alternative to -package)")
+	checkPackageFile := checkCmd.String("package-file", "", "File listing packages to check together, one per line (alternative to -package)")
 	checkVerbose := checkCmd.Bool("verbose", false, "Show all test results, not just errors")
 	checkRelease := checkCmd.String("release", "", "Filter by specific release (optional, e.g., noble, jammy)")
 	checkArch := checkCmd.String("arch", "", "Filter by specific architecture (optional, e.g., amd64, arm64)")
+	checkStatus := checkCmd.String("status", "", "Only show results with these comma-separated statuses (optional, e.g., regression, fail,regression)")
+	checkVersion := checkCmd.String("version", "", "Only show results triggered by this version of the package (optional, e.g., 25.09.0-3)")
+	checkFormat := checkCmd.String("format", "text", "Output format: "+strings.Join(checkFormats, ", "))
+	checkArchOrder := checkCmd.String("arch-order", "", "Comma-separated architecture order for table and html columns and markdown rows (optional, e.g., amd64,arm64)")
+	checkWatchUntilPass := checkCmd.Bool("watch-until-pass", false, "Re-check until the selected release/arch passes (requires -release and -arch)")
+	checkTimeout := checkCmd.Duration("timeout", 2*time.Hour, "Maximum time to wait with -watch-until-pass")
+	checkPollInterval := checkCmd.Duration("poll-interval", 5*time.Minute, "How often to re-check with -watch-until-pass")
+	checkCollapse := checkCmd.Bool("collapse", false, "Group errors with identical status and trigger")
+	checkFailingReleases := checkCmd.Bool("failing-releases", false, "Only print the names of releases with failures")
+	checkFailOn := checkCmd.String("fail-on", "", "Comma-separated statuses treated as errors (optional, default: all but pass and neutral)")
+	checkStrict := checkCmd.Bool("strict", false, "Exit with an error if the scraper reports any warnings")
+	checkRetryTmpfail := checkCmd.Bool("retry-tmpfail", false, "While tmpfail is the only error status, re-check (see -retry-count and -retry-delay) before reporting")
+	checkRetryCount := checkCmd.Int("retry-count", 3, "With -retry-tmpfail, how many times to re-check")
+	checkRetryDelay := checkCmd.Duration("retry-delay", 10*time.Minute, "With -retry-tmpfail, how long to wait before each re-check")
+	checkSave := checkCmd.String("save", "", "Also save the results to this file as JSON, for offline review with -from (optional)")
+	checkFrom := checkCmd.String("from", "", "Read the results from a file written by -save instead of fetching them (optional)")
+	checkColor := checkCmd.String("color", "auto", "Color statuses in text output: "+strings.Join(colorModes, ", ")+" (auto: only on a terminal, unless NO_COLOR is set)")
 
 	// Generate-trigger-link command flags
-	genPackage := generateLinkCmd.String("package", "", "Package name to generate trigger link for (required)")
+	genPackage := generateLinkCmd.String("package", "", "Package name to generate trigger link for (required unless -packages is given)")
+	genPackages := generateLinkCmd.String("packages", "", "Comma-separated package names sharing the other options (alternative to -package)")
 	genVersion := generateLinkCmd.String("version", "", "Package version (optional)")
 	genArch := generateLinkCmd.String("arch", "", "Comma-separated list of architectures (optional, e.g., amd64,arm64)")
-	genSuite := generateLinkCmd.String("suite", "", "Ubuntu suite/release (required, e.g., noble, mantic, jammy)")
+	genArchAll := generateLinkCmd.Bool("arch-all", false, "Generate one URL per architecture supported on the suite (ignored if -arch is given)")
+	genDiscoverArch := generateLinkCmd.Bool("discover-arch", false, "Generate one URL per architecture the package is currently tested on in the suite (instead of -arch)")
+	genSuite := generateLinkCmd.String("suite", "", "Ubuntu suite/release (required, e.g., noble, jammy, questing)")
 	genTrigger := generateLinkCmd.String("trigger", "", "Custom trigger string (optional, overrides package/version)")
-	genPPA := generateLinkCmd.String("ppa", "", "PPA to test against (optional, format: user/ppa-name)")
+	genAllowAnyTrigger := generateLinkCmd.Bool("allow-any-trigger", false, "Accept -trigger values not of the form package/version")
+	genPPA := generateLinkCmd.String("ppa", "", "Comma-separated PPAs to test against (optional, format: user/ppa-name)")
+	genReadableBy := generateLinkCmd.String("readable-by", "", "Comma-separated Launchpad users allowed to see private PPA results (optional)")
 	genAllProposed := generateLinkCmd.Bool("all-proposed", false, "Install all packages from proposed pocket")
+	genValidateOnly := generateLinkCmd.Bool("validate-only", false, "Validate inputs without generating URLs")
+	genFormat := generateLinkCmd.String("format", "text", "Output format: "+strings.Join(generateFormats, ", "))
+	genOutput := generateLinkCmd.String("output", "", "Write the URLs to this file, one per line, instead of stdout (optional)")
 
 	// Trigger command flags (will use authentication)
 	triggerPackage := triggerCmd.String("package", "", "Package name to trigger test for (required)")
 	triggerVersion := triggerCmd.String("version", "", "Package version (optional)")
 	triggerArch := triggerCmd.String("arch", "", "Comma-separated list of architectures (optional, e.g., amd64,arm64)")
-	triggerSuite := triggerCmd.String("suite", "", "Ubuntu suite/release (required, e.g., noble, mantic, jammy)")
+	triggerArchAll := triggerCmd.Bool("arch-all", false, "Trigger every architecture supported on the suite (ignored if -arch is given)")
+	triggerSuite := triggerCmd.String("suite", "", "Ubuntu suite/release (required, e.g., noble, jammy, questing)")
 	triggerTrigger := triggerCmd.String("trigger", "", "Custom trigger string (optional, overrides package/version)")
-	triggerPPA := triggerCmd.String("ppa", "", "PPA to test against (optional, format: user/ppa-name)")
+	triggerAllowAnyTrigger := triggerCmd.Bool("allow-any-trigger", false, "Accept -trigger values not of the form package/version")
+	triggerPPA := triggerCmd.String("ppa", "", "Comma-separated PPAs to test against (optional, format: user/ppa-name)")
+	triggerReadableBy := triggerCmd.String("readable-by", "", "Comma-separated Launchpad users allowed to see private PPA results (optional)")
 	triggerAllProposed := triggerCmd.Bool("all-proposed", false, "Install all packages from proposed pocket")
-	triggerCredentials := triggerCmd.String("credentials", "", "Path to cookie file with Launchpad session (optional)")
+	triggerCredentials := triggerCmd.String("credentials", "", "Path to cookie file with Launchpad session (optional, defaults to $AUTOPKGTEST_COOKIES)")
+	triggerSessionFile := triggerCmd.String("session-file", "", "Load the session from this file if it exists and -credentials is not given, and save renewed session cookies to it after each request (optional)")
 	triggerWait := triggerCmd.Bool("wait", false, "Wait for test completion")
-	triggerTimeout := triggerCmd.Duration("timeout", 2*time.Hour, "Maximum time to wait for test completion")
+	triggerTimeout := triggerCmd.Duration("timeout", 2*time.Hour, "Maximum time to wait for all tests to complete (must be positive)")
 	triggerPollInterval := triggerCmd.Duration("poll-interval", 60*time.Second, "How often to check test status")
+	triggerLogTail := triggerCmd.Int("log-tail", 0, "With -wait, print the last N lines of the log of failed tests (optional)")
+	triggerRetryTmpfail := triggerCmd.Int("retry-tmpfail", 0, "With -wait, re-trigger tests that end in tmpfail up to N times (optional)")
+	triggerSkipRunning := triggerCmd.Bool("skip-running", false, "Skip (and with -wait, monitor) architectures that already have a test running")
+	triggerBatch := triggerCmd.String("batch", "", "File of requests to trigger, one \"<package> <release> [<arch>] [<trigger>...]\" per line (optional)")
+	triggerQuiet := triggerCmd.Bool("quiet", false, "Only print uuid=<uuid> arch=<arch> lines on stdout")
+	triggerFormat := triggerCmd.String("format", "text", "Output format: "+strings.Join(triggerFormats, ", "))
+	triggerEmitScript := triggerCmd.String("emit-script", "", "Write a shell script with the equivalent curl requests to this file (optional)")
+	triggerWebhook := triggerCmd.String("webhook", "", "With -wait, POST a JSON notification to this URL when each test completes (optional)")
+	triggerColor := triggerCmd.String("color", "auto", "Color statuses in text output: "+strings.Join(colorModes, ", ")+" (auto: only on a terminal, unless NO_COLOR is set)")
+
+	// Fetch-logs command flags
+	fetchLogsPackage := fetchLogsCmd.String("package", "", "Package name to download failure logs for (required)")
+	fetchLogsOutput := fetchLogsCmd.String("o", "logs", "Directory to write logs to")
+	fetchLogsRelease := fetchLogsCmd.String("release", "", "Filter by specific release (optional, e.g., noble, jammy)")
+	fetchLogsArch := fetchLogsCmd.String("arch", "", "Filter by specific architecture (optional, e.g., amd64, arm64)")
+
+	// Refresh-index command flags
+	refreshIndexPackage := refreshIndexCmd.String("package", defaultIndexPackage, "Package whose results matrix lists the current suites and architectures")
+
+	// Diff command flags
+	diffPackageA := diffCmd.String("package-a", "", "First package to compare (required)")
+	diffPackageB := diffCmd.String("package-b", "", "Second package to compare (required)")
+	diffRelease := diffCmd.String("release", "", "Filter by specific release (optional, e.g., noble, jammy)")
+	diffArch := diffCmd.String("arch", "", "Filter by specific architecture (optional, e.g., amd64, arm64)")
+
+	// Queues command flags
+	queuesRelease := queuesCmd.String("release", "", "Only show the queues of this release (optional, e.g., noble)")
 
 	// Parse command line
 	if len(os.Args) < 2 {
-		printUsage()
-		os.Exit(1)
+		printUsage(os.Stderr)
+		os.Exit(exitUsage)
 	}
 
+	loadIndexCache()
+
 	switch os.Args[1] {
 	case "check":
 		checkCmd.Parse(os.Args[2:])
-		if *checkPackage == "" {
-			fmt.Println("Error: -package flag is required")
-			checkCmd.PrintDefaults()
-			os.Exit(1)
+		multiPackage := *checkPackages != "" || *checkPackageFile != ""
+		if *checkPackage == "" && !multiPackage && *checkFrom == "" {
+			usageError(checkCmd, "-package flag is required")
+		}
+		if (*checkPackage != "" && multiPackage) || (*checkPackages != "" && *checkPackageFile != "") {
+			usageError(checkCmd, "only one of -package, -packages and -package-file can be used")
+		}
+		if multiPackage {
+			switch {
+			case *checkFormat != "text":
+				usageError(checkCmd, "-format "+*checkFormat+" cannot be used with -packages or -package-file")
+			case *checkVersion != "":
+				usageError(checkCmd, "-version cannot be used with -packages or -package-file")
+			case *checkWatchUntilPass, *checkFailingReleases, *checkCollapse, *checkVerbose:
+				usageError(checkCmd, "-watch-until-pass, -failing-releases, -collapse and -verbose cannot be used with -packages or -package-file")
+			case *checkSave != "", *checkFrom != "":
+				usageError(checkCmd, "-save and -from cannot be used with -packages or -package-file")
+			}
+		}
+		if (*checkSave != "" || *checkFrom != "") && (*checkWatchUntilPass || *checkFailingReleases) {
+			usageError(checkCmd, "-save and -from cannot be used with -watch-until-pass or -failing-releases")
+		}
+		if !*checkRetryTmpfail {
+			checkCmd.Visit(func(f *flag.Flag) {
+				if f.Name == "retry-count" || f.Name == "retry-delay" {
+					usageError(checkCmd, "-"+f.Name+" requires -retry-tmpfail")
+				}
+			})
+		}
+		if *checkRetryTmpfail {
+			if multiPackage || *checkFrom != "" || *checkWatchUntilPass || *checkFailingReleases {
+				usageError(checkCmd, "-retry-tmpfail cannot be used with -packages, -package-file, -from, -watch-until-pass or -failing-releases")
+			}
+			if *checkRetryCount < 0 {
+				usageError(checkCmd, "-retry-count cannot be negative")
+			}
+		}
+		if *checkWatchUntilPass {
+			if *checkRelease == "" || *checkArch == "" {
+				usageError(checkCmd, "-watch-until-pass requires -release and -arch")
+			}
+			handleWatchUntilPass(*checkPackage, *checkRelease, *checkArch, *checkTimeout, *checkPollInterval)
+			return
+		}
+
+		if !slices.Contains(checkFormats, *checkFormat) {
+			usageError(checkCmd, fmt.Sprintf("unknown -format %q (valid: %s)", *checkFormat, strings.Join(checkFormats, ", ")))
+		}
+		if !slices.Contains(colorModes, *checkColor) {
+			usageError(checkCmd, fmt.Sprintf("unknown -color %q (valid: %s)", *checkColor, strings.Join(colorModes, ", ")))
+		}
+		color := useColor(*checkColor, os.Stdout)
+
+		var failOn []string
+		if *checkFailOn != "" {
+			failOn = strings.Split(*checkFailOn, ",")
+			for i := range failOn {
+				failOn[i] = strings.TrimSpace(failOn[i])
+				if autopkgtest.ParseStatus(failOn[i]) == autopkgtest.StatusUnknown {
+					usageError(checkCmd, fmt.Sprintf("unknown -fail-on status %q (valid: %s)", failOn[i], joinStatuses(autopkgtest.KnownStatuses)))
+				}
+			}
+		}
+
+		var archOrder []string
+		if *checkArchOrder != "" {
+			archOrder = strings.Split(*checkArchOrder, ",")
+			for i := range archOrder {
+				archOrder[i] = strings.TrimSpace(archOrder[i])
+			}
 		}
-		handleCheck(*checkPackage, *checkVerbose, *checkRelease, *checkArch)
+
+		if *checkPackages != "" {
+			packages := splitPackages(*checkPackages)
+			if len(packages) == 0 {
+				usageError(checkCmd, "-packages lists no package")
+			}
+			handleCheckPackages(packages, *checkStrict, color, *checkRelease, *checkArch, *checkStatus, failOn)
+			return
+		}
+		if *checkPackageFile != "" {
+			handleCheckPackages(readPackageFile(*checkPackageFile), *checkStrict, color, *checkRelease, *checkArch, *checkStatus, failOn)
+			return
+		}
+
+		handleCheck(*checkPackage, checkOptions{
+			Release:         *checkRelease,
+			Arch:            *checkArch,
+			Status:          *checkStatus,
+			Version:         *checkVersion,
+			Format:          *checkFormat,
+			Save:            *checkSave,
+			From:            *checkFrom,
+			Verbose:         *checkVerbose,
+			Collapse:        *checkCollapse,
+			FailingReleases: *checkFailingReleases,
+			Strict:          *checkStrict,
+			Color:           color,
+			RetryTmpfail:    *checkRetryTmpfail,
+			RetryCount:      *checkRetryCount,
+			RetryDelay:      *checkRetryDelay,
+			ArchOrder:       archOrder,
+			FailOn:          failOn,
+		})
 
 	case "generate-trigger-link":
 		generateLinkCmd.Parse(os.Args[2:])
-		if *genPackage == "" {
-			fmt.Println("Error: -package flag is required")
-			generateLinkCmd.PrintDefaults()
-			os.Exit(1)
+		if *genPackage == "" && *genPackages == "" {
+			usageError(generateLinkCmd, "-package flag is required")
+		}
+		if *genPackage != "" && *genPackages != "" {
+			usageError(generateLinkCmd, "-package and -packages cannot be used together")
 		}
 		if *genSuite == "" {
-			fmt.Println("Error: -suite flag is required")
-			generateLinkCmd.PrintDefaults()
-			os.Exit(1)
+			usageError(generateLinkCmd, "-suite flag is required")
 		}
-
-		var archs []string
-		if *genArch != "" {
-			archs = strings.Split(*genArch, ",")
-			for i := range archs {
-				archs[i] = strings.TrimSpace(archs[i])
-			}
+		if !slices.Contains(generateFormats, *genFormat) {
+			usageError(generateLinkCmd, fmt.Sprintf("unknown -format %q (valid: %s)", *genFormat, strings.Join(generateFormats, ", ")))
+		}
+		if *genPackages != "" && *genFormat != "text" {
+			usageError(generateLinkCmd, "-format "+*genFormat+" cannot be used with -packages")
+		}
+		if *genOutput != "" && *genFormat != "text" {
+			usageError(generateLinkCmd, "-output cannot be used with -format "+*genFormat)
+		}
+		if *genDiscoverArch && *genArch != "" {
+			usageError(generateLinkCmd, "-discover-arch and -arch cannot be used together")
+		}
+		if *genDiscoverArch && *genArchAll {
+			usageError(generateLinkCmd, "-discover-arch and -arch-all cannot be used together")
 		}
 
+		archs := parseArchs(*genArch, *genArchAll, *genSuite)
+
 		// Parse comma-separated triggers into a slice
 		var triggers []string
 		if *genTrigger != "" {
@@ -99,29 +312,73 @@ func main() {
 			}
 		}
 
-		handleGenerateTriggerLink(*genPackage, *genVersion, *genSuite, triggers, *genPPA, *genAllProposed, archs)
+		// Parse comma-separated PPAs into a slice
+		var ppas []string
+		if *genPPA != "" {
+			ppas = strings.Split(*genPPA, ",")
+			for i := range ppas {
+				ppas[i] = strings.TrimSpace(ppas[i])
+			}
+		}
+
+		// Parse comma-separated readable-by users into a slice
+		var readableBy []string
+		if *genReadableBy != "" {
+			readableBy = strings.Split(*genReadableBy, ",")
+			for i := range readableBy {
+				readableBy[i] = strings.TrimSpace(readableBy[i])
+			}
+		}
+
+		if *genPackages != "" {
+			packages := splitPackages(*genPackages)
+			if len(packages) == 0 {
+				usageError(generateLinkCmd, "-packages lists no package")
+			}
+			handleGeneratePackagesTriggerLinks(packages, *genVersion, *genSuite, triggers, ppas, readableBy, *genAllProposed, *genAllowAnyTrigger, *genDiscoverArch, *genValidateOnly, *genOutput, archs)
+			return
+		}
+
+		handleGenerateTriggerLink(*genPackage, *genVersion, *genSuite, triggers, ppas, readableBy, *genAllProposed, *genAllowAnyTrigger, *genDiscoverArch, *genValidateOnly, *genFormat, *genOutput, archs)
 
 	case "trigger":
 		triggerCmd.Parse(os.Args[2:])
-		if *triggerPackage == "" {
-			fmt.Println("Error: -package flag is required")
-			triggerCmd.PrintDefaults()
-			os.Exit(1)
+		if !slices.Contains(triggerFormats, *triggerFormat) {
+			usageError(triggerCmd, fmt.Sprintf("unknown -format %q (valid: %s)", *triggerFormat, strings.Join(triggerFormats, ", ")))
 		}
-		if *triggerSuite == "" {
-			fmt.Println("Error: -suite flag is required")
-			triggerCmd.PrintDefaults()
-			os.Exit(1)
+		if !slices.Contains(colorModes, *triggerColor) {
+			usageError(triggerCmd, fmt.Sprintf("unknown -color %q (valid: %s)", *triggerColor, strings.Join(colorModes, ", ")))
 		}
-
-		var archs []string
-		if *triggerArch != "" {
-			archs = strings.Split(*triggerArch, ",")
-			for i := range archs {
-				archs[i] = strings.TrimSpace(archs[i])
+		if *triggerFormat != "text" && (*triggerQuiet || *triggerBatch != "") {
+			usageError(triggerCmd, "-format "+*triggerFormat+" cannot be combined with -quiet or -batch")
+		}
+		if *triggerWebhook != "" && !*triggerWait {
+			usageError(triggerCmd, "-webhook requires -wait")
+		}
+		if *triggerRetryTmpfail != 0 && !*triggerWait {
+			usageError(triggerCmd, "-retry-tmpfail requires -wait")
+		}
+		if *triggerWait && *triggerTimeout <= 0 {
+			usageError(triggerCmd, "-timeout must be positive")
+		}
+		if *triggerBatch != "" {
+			if *triggerPackage != "" || *triggerSuite != "" || *triggerArch != "" || *triggerArchAll || *triggerVersion != "" {
+				usageError(triggerCmd, "-batch cannot be combined with -package, -suite, -arch, -arch-all or -version")
+			}
+			if *triggerWait || *triggerEmitScript != "" {
+				usageError(triggerCmd, "-batch cannot be combined with -wait or -emit-script")
+			}
+		} else {
+			if *triggerPackage == "" {
+				usageError(triggerCmd, "-package flag is required")
+			}
+			if *triggerSuite == "" {
+				usageError(triggerCmd, "-suite flag is required")
 			}
 		}
 
+		archs := parseArchs(*triggerArch, *triggerArchAll, *triggerSuite)
+
 		// Parse comma-separated triggers into a slice
 		var triggers []string
 		if *triggerTrigger != "" {
@@ -131,112 +388,483 @@ func main() {
 			}
 		}
 
-		handleTrigger(*triggerPackage, *triggerVersion, *triggerSuite, triggers, *triggerPPA, *triggerAllProposed, *triggerCredentials, *triggerWait, *triggerTimeout, *triggerPollInterval, archs)
+		// Parse comma-separated PPAs into a slice
+		var ppas []string
+		if *triggerPPA != "" {
+			ppas = strings.Split(*triggerPPA, ",")
+			for i := range ppas {
+				ppas[i] = strings.TrimSpace(ppas[i])
+			}
+		}
+
+		// Parse comma-separated readable-by users into a slice
+		var readableBy []string
+		if *triggerReadableBy != "" {
+			readableBy = strings.Split(*triggerReadableBy, ",")
+			for i := range readableBy {
+				readableBy[i] = strings.TrimSpace(readableBy[i])
+			}
+		}
+
+		opts := triggerOptions{
+			Triggers:        triggers,
+			PPAs:            ppas,
+			ReadableBy:      readableBy,
+			AllProposed:     *triggerAllProposed,
+			AllowAnyTrigger: *triggerAllowAnyTrigger,
+			Credentials:     *triggerCredentials,
+			SessionFile:     *triggerSessionFile,
+			SkipRunning:     *triggerSkipRunning,
+			Quiet:           *triggerQuiet,
+			Version:         *triggerVersion,
+			Archs:           archs,
+			Wait:            *triggerWait,
+			Timeout:         *triggerTimeout,
+			PollInterval:    *triggerPollInterval,
+			LogTail:         *triggerLogTail,
+			RetryTmpfail:    *triggerRetryTmpfail,
+			Color:           useColor(*triggerColor, os.Stdout),
+			Format:          *triggerFormat,
+			EmitScript:      *triggerEmitScript,
+			Webhook:         *triggerWebhook,
+		}
+		if *triggerBatch != "" {
+			handleTriggerBatch(*triggerBatch, opts)
+			return
+		}
+
+		handleTrigger(*triggerPackage, *triggerSuite, opts)
+
+	case "fetch-logs":
+		fetchLogsCmd.Parse(os.Args[2:])
+		if *fetchLogsPackage == "" {
+			usageError(fetchLogsCmd, "-package flag is required")
+		}
+		handleFetchLogs(*fetchLogsPackage, *fetchLogsOutput, *fetchLogsRelease, *fetchLogsArch)
+
+	case "diff":
+		diffCmd.Parse(os.Args[2:])
+		if *diffPackageA == "" || *diffPackageB == "" {
+			usageError(diffCmd, "-package-a and -package-b flags are required")
+		}
+		handleDiff(*diffPackageA, *diffPackageB, *diffRelease, *diffArch)
+
+	case "queues":
+		queuesCmd.Parse(os.Args[2:])
+		handleQueues(*queuesRelease)
+
+	case "refresh-index":
+		refreshIndexCmd.Parse(os.Args[2:])
+		handleRefreshIndex(*refreshIndexPackage)
 
 	case "version":
 		versionCmd.Parse(os.Args[2:])
 		fmt.Printf("autopkgtest-cli version %s\n", version)
 
 	case "help", "-h", "--help":
-		printUsage()
+		printUsage(os.Stdout)
 
 	default:
-		fmt.Printf("Unknown command: %s\n", os.Args[1])
-		printUsage()
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", os.Args[1])
+		printUsage(os.Stderr)
+		os.Exit(exitUsage)
+	}
+}
+
+// usageError reports a command-line usage problem on stderr, followed by the
+// subcommand's flag defaults, and exits with exitUsage
+func usageError(fs *flag.FlagSet, msg string) {
+	fmt.Fprintf(os.Stderr, "Error: %s\n", msg)
+	fs.PrintDefaults()
+	os.Exit(exitUsage)
+}
+
+// serverURL returns the base URL of the autopkgtest server:
+// $AUTOPKGTEST_URL if set, e.g. a staging deployment, otherwise
+// defaultServerURL
+func serverURL() string {
+	if u := strings.TrimRight(strings.TrimSpace(os.Getenv("AUTOPKGTEST_URL")), "/"); u != "" {
+		return u
+	}
+	return defaultServerURL
+}
+
+// serverHost returns the host of serverURL, which session cookies are sent
+// to, and whether the server is reached over HTTPS
+func serverHost() (host string, secure bool) {
+	u, err := url.Parse(serverURL())
+	if err != nil || u.Hostname() == "" {
+		u, _ = url.Parse(defaultServerURL)
 	}
+	return u.Hostname(), u.Scheme == "https"
 }
 
-func printUsage() {
-	fmt.Print("autopkgtest-cli - Autopkgtest automation tool\n\n" +
+// newScraper creates a scraper for serverURL
+func newScraper(opts ...scraper.Option) *scraper.Scraper {
+	s := scraper.NewScraper(opts...)
+	s.BaseURL = serverURL()
+	return s
+}
+
+// newGenerator creates a trigger link generator for serverURL
+func newGenerator() *triggerlinkgenerator.Generator {
+	gen := triggerlinkgenerator.NewGenerator()
+	gen.BaseURL = serverURL() + "/request.cgi"
+	return gen
+}
+
+// isTestFailure reports whether a test that ended with status failed for
+// the trigger exit code: anything but pass or neutral, including a tmpfail
+// left once -retry-tmpfail gave up, a regression or an unknown status
+func isTestFailure(status autopkgtest.Status) bool {
+	return status != autopkgtest.StatusPass && status != autopkgtest.StatusNeutral
+}
+
+// joinStatuses lists statuses for a usage message
+func joinStatuses(statuses []autopkgtest.Status) string {
+	names := make([]string, len(statuses))
+	for i, status := range statuses {
+		names[i] = string(status)
+	}
+	return strings.Join(names, ", ")
+}
+
+// printUsage writes the help text to w: stdout when help was requested,
+// stderr when shown because of a usage error
+func printUsage(w io.Writer) {
+	usage := "autopkgtest-cli - Autopkgtest automation tool\n\n" +
 		"Usage:\n" +
 		"\tautopkgtest-cli <command> [flags]\n\n" +
 		"Commands:\n" +
 		"\tcheck\t\t\tCheck autopkgtest results for a package\n" +
 		"\tgenerate-trigger-link\tGenerate autopkgtest trigger URL(s)\n" +
 		"\ttrigger\t\t\tTrigger autopkgtest with authentication\n" +
+		"\tfetch-logs\t\tDownload logs for all failing tests\n" +
+		"\tdiff\t\t\tCompare the result matrices of two packages\n" +
+		"\tqueues\t\t\tShow the number of queued requests per release/arch\n" +
+		"\trefresh-index\t\tUpdate the cached list of suites and architectures\n" +
 		"\tversion\t\t\tShow version information\n" +
 		"\thelp\t\t\tShow this help message\n\n" +
 		"Check command:\n" +
-		"\tautopkgtest-cli check -package <name> [-verbose] [-release <release>] [-arch <arch>]\n\n" +
+		"\tautopkgtest-cli check -package <name> [-verbose] [-collapse] [-failing-releases] [-fail-on <statuses>] [-strict] [-color auto|always|never] [-format " + strings.Join(checkFormats, "|") + "] [-arch-order <archs>] [-release <release>] [-arch <arch>] [-status <statuses>] [-version <version>] [-save <path>] [-retry-tmpfail [-retry-count <n>] [-retry-delay <d>]]\n" +
+		"\tautopkgtest-cli check -from <path> [options]\n" +
+		"\tautopkgtest-cli check -packages <a,b,c> | -package-file <path> [-fail-on <statuses>] [-strict] [-release <release>] [-arch <arch>] [-status <statuses>]\n" +
+		"\tautopkgtest-cli check -package <name> -watch-until-pass -release <release> -arch <arch> [-timeout <d>] [-poll-interval <d>]\n\n" +
 		"Check options:\n" +
-		"\t-package string      Package name (required)\n" +
+		"\t-package string      Package name (required unless -packages, -package-file or -from is given)\n" +
+		"\t-packages string     Packages to check together, with one combined report (comma-separated: a,b,c)\n" +
+		"\t-package-file string File listing packages to check together, one per line (# starts a comment)\n" +
 		"\t-verbose             Show all test results, not just errors\n" +
+		"\t-collapse            Group errors with identical status and trigger\n" +
+		"\t-failing-releases    Only print releases that have failures\n" +
+		"\t-fail-on string      Statuses treated as errors (e.g., fail,regression; default: all but pass and neutral)\n" +
+		"\t-strict              Fail if the scraper reports any warnings\n" +
+		"\t-retry-tmpfail       While tmpfail is the only error status, re-check before reporting\n" +
+		"\t-retry-count int     With -retry-tmpfail, how many times to re-check (default: 3)\n" +
+		"\t-retry-delay duration  With -retry-tmpfail, wait between re-checks (default: 10m)\n" +
+		"\t-save string         Also save the results to a JSON file for offline review\n" +
+		"\t-from string         Read the results from a file written by -save (no network access)\n" +
+		"\t-color string        Color statuses: auto, always or never (default: auto, only on a terminal without NO_COLOR)\n" +
 		"\t-release string      Filter by release (optional, e.g., noble, jammy)\n" +
-		"\t-arch string         Filter by architecture (optional, e.g., amd64, arm64)\n\n" +
+		"\t-arch string         Filter by architecture (optional, e.g., amd64, arm64)\n" +
+		"\t-status string       Only show results with these statuses (optional, e.g., regression or fail,regression)\n" +
+		"\t-version string      Only show results triggered by this version of the package (optional)\n" +
+		"\t-format string       Output format: text, table, html, json, csv, tsv or markdown (default: text)\n" +
+		"\t-arch-order string   Architecture order for table/html columns and markdown rows (e.g., amd64,arm64)\n" +
+		"\t-watch-until-pass    Re-check until the selected release/arch passes\n" +
+		"\t-timeout duration    Maximum time to wait with -watch-until-pass (default: 2h)\n" +
+		"\t-poll-interval duration  How often to re-check with -watch-until-pass (default: 5m)\n\n" +
 		"Generate-trigger-link command:\n" +
-		"\tautopkgtest-cli generate-trigger-link -package <name> -suite <suite> [options]\n\n" +
+		"\tautopkgtest-cli generate-trigger-link -package <name> -suite <suite> [options]\n" +
+		"\tautopkgtest-cli generate-trigger-link -packages <a,b,c> -suite <suite> [options]\n\n" +
 		"Generate-trigger-link options:\n" +
-		"\t-package string      Package name (required)\n" +
-		"\t-suite string        Ubuntu release (required, e.g., noble, mantic, jammy)\n" +
+		"\t-package string      Package name (required unless -packages is given)\n" +
+		"\t-packages string     Packages sharing the other options (comma-separated: a,b,c)\n" +
+		"\t-suite string        Ubuntu release (required, e.g., noble, jammy, questing)\n" +
 		"\t-arch string         Architectures (optional, comma-separated: amd64,arm64)\n" +
+		"\t-arch-all            One URL per architecture supported on the suite (ignored if -arch is given)\n" +
+		"\t-discover-arch       One URL per architecture the package is tested on in the suite (instead of -arch)\n" +
 		"\t-version string      Package version (optional)\n" +
 		"\t-trigger string      Custom trigger (optional, comma-separated for multiple)\n" +
-		"\t-ppa string          PPA to test (optional, e.g., user/ppa-name)\n" +
-		"\t-all-proposed        Use all packages from proposed pocket\n\n" +
+		"\t-allow-any-trigger   Accept triggers not of the form package/version\n" +
+		"\t-ppa string          PPAs to test (optional, comma-separated: user/ppa-name,user/other)\n" +
+		"\t-readable-by string  Launchpad users allowed to see private PPA results (optional, comma-separated)\n" +
+		"\t-all-proposed        Use all packages from proposed pocket\n" +
+		"\t-validate-only       Validate inputs without generating URLs\n" +
+		"\t-format string       Output format: text or yaml (default: text; yaml requires -package)\n" +
+		"\t-output string       Write the URLs to a file, one per line (optional)\n\n" +
 		"Trigger command (with authentication - skeleton):\n" +
-		"\tautopkgtest-cli trigger -package <name> -suite <suite> [options]\n\n" +
+		"\tautopkgtest-cli trigger -package <name> -suite <suite> [options]\n" +
+		"\tautopkgtest-cli trigger -batch <file> [options]\n\n" +
 		"Trigger options:\n" +
 		"\t-package string      Package name (required)\n" +
-		"\t-suite string        Ubuntu release (required, e.g., noble, mantic, jammy)\n" +
+		"\t-suite string        Ubuntu release (required, e.g., noble, jammy, questing)\n" +
 		"\t-arch string         Architectures (optional, comma-separated: amd64,arm64)\n" +
+		"\t-arch-all            Trigger every architecture supported on the suite (ignored if -arch is given)\n" +
 		"\t-version string      Package version (optional)\n" +
 		"\t-trigger string      Custom trigger (optional, comma-separated for multiple)\n" +
-		"\t-ppa string          PPA to test (optional, e.g., user/ppa-name)\n" +
+		"\t-allow-any-trigger   Accept triggers not of the form package/version\n" +
+		"\t-ppa string          PPAs to test (optional, comma-separated: user/ppa-name,user/other)\n" +
+		"\t-readable-by string  Launchpad users allowed to see private PPA results (optional, comma-separated)\n" +
 		"\t-all-proposed        Use all packages from proposed pocket\n" +
-		"\t-credentials string  Path to cookie file (use \"-\" for stdin, or set AUTOPKGTEST_COOKIE env var)\n" +
+		"\t-credentials string  Path to cookie file (use \"-\" for stdin; defaults to $AUTOPKGTEST_COOKIES, then $AUTOPKGTEST_COOKIE)\n" +
+		"\t-session-file string Load the session from this file if it exists and -credentials is not given; save renewed session cookies to it\n" +
 		"\t-wait                Wait for test completion\n" +
-		"\t-timeout duration    Maximum time to wait (default: 2h)\n" +
-		"\t-poll-interval duration  How often to check status (default: 30s)\n\n" +
+		"\t-timeout duration    Maximum time to wait for all tests, must be positive (default: 2h)\n" +
+		"\t-poll-interval duration  How often to check status (default: 30s)\n" +
+		"\t-log-tail int        With -wait, print the last N log lines of failed tests\n" +
+		"\t-retry-tmpfail int   With -wait, re-trigger tests that end in tmpfail up to N times\n" +
+		"\t-skip-running        Skip architectures that already have a test running\n" +
+		"\t-quiet               Only print uuid=<uuid> arch=<arch> lines on stdout\n" +
+		"\t-format string       Output format: text or json (default: text)\n" +
+		"\t-batch string        Trigger the requests in a file (\"<package> <release> [<arch>] [<trigger>...]\" per line)\n" +
+		"\t-emit-script string  Write the equivalent curl requests to a shell script\n" +
+		"\t-webhook string      With -wait, POST a JSON notification to this URL as each test completes\n" +
+		"\t-color string        Color statuses: auto, always or never (default: auto, only on a terminal without NO_COLOR)\n\n" +
+		"Fetch-logs command:\n" +
+		"\tautopkgtest-cli fetch-logs -package <name> [-o <dir>] [-release <release>] [-arch <arch>]\n\n" +
+		"Fetch-logs options:\n" +
+		"\t-package string      Package name (required)\n" +
+		"\t-o string            Directory to write logs to (default: logs)\n" +
+		"\t-release string      Filter by release (optional, e.g., noble, jammy)\n" +
+		"\t-arch string         Filter by architecture (optional, e.g., amd64, arm64)\n\n" +
+		"Diff command:\n" +
+		"\tautopkgtest-cli diff -package-a <name> -package-b <name> [-release <release>] [-arch <arch>]\n\n" +
+		"Diff options:\n" +
+		"\t-package-a string    First package (required)\n" +
+		"\t-package-b string    Second package (required)\n" +
+		"\t-release string      Filter by release (optional, e.g., noble, jammy)\n" +
+		"\t-arch string         Filter by architecture (optional, e.g., amd64, arm64)\n\n" +
+		"Queues command:\n" +
+		"\tautopkgtest-cli queues [-release <release>]\n\n" +
+		"Queues options:\n" +
+		"\t-release string      Only show the queues of this release (optional, e.g., noble)\n\n" +
+		"Refresh-index command:\n" +
+		"\tautopkgtest-cli refresh-index [-package <name>]\n\n" +
+		"Refresh-index options:\n" +
+		"\t-package string      Package whose results list the current suites and architectures (default: dpkg)\n\n" +
 		"Examples:\n" +
 		"\tautopkgtest-cli check -package ovn\n" +
 		"\tautopkgtest-cli check -package ovn -verbose\n" +
 		"\tautopkgtest-cli check -package ovn -release noble -arch amd64\n" +
+		"\tautopkgtest-cli check -package ovn -status regression\n" +
+		"\tautopkgtest-cli check -package ovn -version 25.09.0-3\n" +
+		"\tautopkgtest-cli check -package ovn -failing-releases\n" +
+		"\tautopkgtest-cli check -package ovn -format table -arch-order amd64,arm64\n" +
+		"\tautopkgtest-cli check -package ovn -format html > ovn.html\n" +
+		"\tautopkgtest-cli check -package ovn -format json | jq '.errors'\n" +
+		"\tautopkgtest-cli check -package ovn -watch-until-pass -release noble -arch amd64\n" +
 		"\tautopkgtest-cli generate-trigger-link -package ovn -suite noble\n" +
 		"\tautopkgtest-cli generate-trigger-link -package ovn -suite noble -arch amd64,arm64\n" +
+		"\tautopkgtest-cli generate-trigger-link -package ovn -suite noble -arch amd64,arm64 -format yaml\n" +
+		"\tautopkgtest-cli generate-trigger-link -package ovn -suite noble -arch amd64,arm64 -output links.txt\n" +
+		"\tautopkgtest-cli generate-trigger-link -packages ovn,openvswitch -suite noble -arch amd64,arm64 -trigger systemd/259-1ubuntu3\n" +
 		"\tautopkgtest-cli generate-trigger-link -package myapp -suite noble -trigger systemd/259-1ubuntu3,dhcpcd/1:10.3.0-7\n" +
+		"\tautopkgtest-cli fetch-logs -package ovn -o ./logs/\n" +
 		"\tautopkgtest-cli trigger -package ovn -suite noble -credentials ~/.autopkgtest-cookies\n" +
+		"\tautopkgtest-cli trigger -package ovn -suite noble -credentials ~/.autopkgtest-cookies -session-file ~/.autopkgtest-session.json\n" +
 		"\tautopkgtest-cli trigger -package ovn -suite noble --wait --timeout 1h\n" +
-		"\tautopkgtest-cli trigger -package myapp -suite noble -trigger systemd/259-1ubuntu3,dhcpcd/1:10.3.0-7 -credentials ~/.autopkgtest-cookies\n")
+		"\tautopkgtest-cli trigger -package ovn -suite noble --wait -webhook https://hooks.example.com/autopkgtest\n" +
+		"\tautopkgtest-cli trigger -package myapp -suite noble -trigger systemd/259-1ubuntu3,dhcpcd/1:10.3.0-7 -credentials ~/.autopkgtest-cookies\n"
+	fmt.Fprint(w, usage)
 }
 
-func handleCheck(packageName string, verbose bool, release, arch string) {
-	fmt.Printf("Checking autopkgtest results for package: %s\n", packageName)
-	if release != "" || arch != "" {
-		fmt.Print("Filters: ")
-		if release != "" {
-			fmt.Printf("release=%s ", release)
+// checkOptions holds the check flags that shape how the results of one
+// package are fetched and printed
+type checkOptions struct {
+	Release         string
+	Arch            string
+	Status          string
+	Version         string
+	Format          string
+	Save            string // Path to save the fetched results to
+	From            string // Path to read saved results from instead of fetching
+	Verbose         bool
+	Collapse        bool
+	FailingReleases bool
+	Strict          bool
+	Color           bool
+	RetryTmpfail    bool
+	RetryCount      int
+	RetryDelay      time.Duration
+	ArchOrder       []string
+	FailOn          []string
+}
+
+func handleCheck(packageName string, opts checkOptions) {
+	if opts.FailingReleases {
+		handleFailingReleases(packageName, opts.Strict, opts.Release, opts.Arch, opts.Status, opts.Version, opts.FailOn)
+		return
+	}
+
+	// Saved results replace the fetch, so no network call is made
+	var saved *scraper.PackageResults
+	if opts.From != "" {
+		var err error
+		if saved, err = scraper.LoadResults(opts.From); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if packageName == "" {
+			packageName = saved.Package
+		} else if packageName != saved.Package {
+			fmt.Fprintf(os.Stderr, "Error: %s holds results for package %s, not %s\n", opts.From, saved.Package, packageName)
+			os.Exit(1)
+		}
+	}
+
+	// HTML, JSON, CSV, TSV and Markdown output must be the only thing written
+	// to stdout
+	if opts.Format == "text" || opts.Format == "table" {
+		if opts.From != "" {
+			fmt.Printf("Checking saved autopkgtest results for package: %s (from %s)\n", packageName, opts.From)
+		} else {
+			fmt.Printf("Checking autopkgtest results for package: %s\n", packageName)
 		}
-		if arch != "" {
-			fmt.Printf("arch=%s", arch)
+		if opts.Release != "" || opts.Arch != "" || opts.Status != "" || opts.Version != "" {
+			fmt.Print("Filters: ")
+			if opts.Release != "" {
+				fmt.Printf("release=%s ", opts.Release)
+			}
+			if opts.Arch != "" {
+				fmt.Printf("arch=%s ", opts.Arch)
+			}
+			if opts.Status != "" {
+				fmt.Printf("status=%s ", opts.Status)
+			}
+			if opts.Version != "" {
+				fmt.Printf("version=%s", opts.Version)
+			}
+			fmt.Println()
 		}
 		fmt.Println()
 	}
-	fmt.Println()
 
-	s := scraper.NewScraper()
-	var filter *scraper.Filter
-	if release != "" || arch != "" {
-		filter = &scraper.Filter{
-			Release:      release,
-			Architecture: arch,
+	s := newScraper(scraper.WithFailOn(opts.FailOn...))
+	filter := checkFilter(packageName, opts.Release, opts.Arch, opts.Status, opts.Version)
+	var results *scraper.PackageResults
+	if saved != nil {
+		results = s.FilterResults(saved, filter)
+	} else {
+		var err error
+		if opts.RetryTmpfail {
+			// Progress goes to stderr so that machine output stays clean
+			results, err = s.FetchRetryingTmpfail(packageName, filter, opts.RetryCount, opts.RetryDelay, func(retry int, results *scraper.PackageResults) {
+				fmt.Fprintf(os.Stderr, "Only tmpfail errors found (%d); re-checking in %s (retry %d of %d)...\n", len(results.Errors), opts.RetryDelay, retry, opts.RetryCount)
+			})
+		} else {
+			results, err = s.FetchPackageResultsFiltered(packageName, filter)
+		}
+		if err != nil {
+			exitPackageFetchError(s, packageName, err)
 		}
 	}
-	results, err := s.FetchPackageResultsFiltered(packageName, filter)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error fetching results: %v\n", err)
+	if opts.Save != "" {
+		if err := scraper.SaveResults(results, opts.Save); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	printWarnings(results)
+	if err := checkStrict(results, opts.Strict); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	if verbose {
-		fmt.Printf("Total tests found: %d\n", len(results.Tests))
+	if opts.Format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding results: %v\n", err)
+			os.Exit(1)
+		}
+		if len(results.Errors) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.Format == "csv" {
+		if err := results.WriteCSV(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(results.Errors) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.Format == "markdown" {
+		if err := results.WriteMarkdown(os.Stdout, opts.ArchOrder); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(results.Errors) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.Format == "tsv" {
+		if err := results.WriteTSV(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(results.Errors) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.Format == "html" {
+		page, err := results.RenderHTML(opts.ArchOrder)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(page)
+		if len(results.Errors) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.Format == "table" {
+		fmt.Print(results.RenderTable(opts.ArchOrder))
+		fmt.Println()
+		fmt.Printf("Found %d errors for package: %s\n", len(results.Errors), results.Package)
+		if len(results.Errors) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.Verbose {
+		fmt.Printf("Total tests found: %d\n", results.TestedCount())
 		fmt.Println()
 
 		if len(results.Tests) > 0 {
 			fmt.Println("All test results:")
 			for i, test := range results.Tests {
 				fmt.Printf("\nTest %d:\n", i+1)
-				fmt.Printf("\tStatus: %s\n", test.Status)
+				if test.Status == string(autopkgtest.StatusNoData) {
+					if test.InProgress {
+						fmt.Printf("\t%s/%s: running (first run)\n", test.Release, test.Architecture)
+					} else {
+						fmt.Printf("\t%s/%s: not tested\n", test.Release, test.Architecture)
+					}
+					continue
+				}
+				if test.InProgress {
+					fmt.Printf("\t%s/%s: running\n", test.Release, test.Architecture)
+					fmt.Printf("\tPrevious status: %s\n", colorize(test.Status, opts.Color))
+				} else {
+					fmt.Printf("\tStatus: %s\n", colorize(test.Status, opts.Color))
+				}
+				if test.Category != "" {
+					fmt.Printf("\tCategory: %s\n", test.Category)
+				}
 				if test.Release != "" {
 					fmt.Printf("\tRelease: %s\n", test.Release)
 				}
@@ -246,6 +874,9 @@ func handleCheck(packageName string, verbose bool, release, arch string) {
 				if test.Duration != "" {
 					fmt.Printf("\tDuration: %s\n", test.Duration)
 				}
+				if !test.LastRun.IsZero() {
+					fmt.Printf("\tLast run: %s\n", test.LastRun.Format("2006-01-02 15:04:05 MST"))
+				}
 				if test.Trigger != "" {
 					fmt.Printf("\tTrigger: %s\n", test.Trigger)
 				}
@@ -257,9 +888,21 @@ func handleCheck(packageName string, verbose bool, release, arch string) {
 		}
 	}
 
+	if !opts.Verbose {
+		if summary := formatStatusSummary(results.Summary(), opts.Color); summary != "" {
+			fmt.Println(summary)
+			fmt.Println()
+		}
+	}
+
 	// Always show error report
-	report := results.ReportErrors()
-	fmt.Println(report)
+	var report string
+	if opts.Collapse {
+		report = results.ReportCollapsedErrors()
+	} else {
+		report = results.ReportErrors()
+	}
+	fmt.Println(colorizeReport(report, opts.Color))
 
 	// Exit with error code if errors were found
 	if len(results.Errors) > 0 {
@@ -267,186 +910,815 @@ func handleCheck(packageName string, verbose bool, release, arch string) {
 	}
 }
 
-func handleGenerateTriggerLink(packageName, version, suite string, triggers []string, ppa string, allProposed bool, archs []string) {
-	if packageName == "" {
-		fmt.Fprintln(os.Stderr, "Error: -package is required")
-		os.Exit(1)
+// handleCheckPackages fetches the results of several packages concurrently
+// and prints one report of the errors across all of them, followed by the
+// packages that could not be fetched at all. It exits with 1 if any package
+// has errors or could not be fetched.
+func handleCheckPackages(packages []string, strict, color bool, release, arch, status string, failOn []string) {
+	fmt.Printf("Checking autopkgtest results for packages: %s\n\n", strings.Join(packages, ", "))
+
+	s := newScraper(scraper.WithFailOn(failOn...))
+	results, errs := s.FetchMultiplePackages(packages, checkFilter("", release, arch, status, ""), 0)
+
+	failed := len(errs) > 0
+	for _, pkg := range packages {
+		if errs[pkg] != nil {
+			continue
+		}
+		for _, warning := range results[pkg].Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", pkg, warning)
+		}
+		if err := checkStrict(results[pkg], strict); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", pkg, err)
+			failed = true
+		}
+		if len(results[pkg].Errors) > 0 {
+			failed = true
+		}
 	}
 
-	if suite == "" {
-		fmt.Fprintln(os.Stderr, "Error: -suite is required")
+	fmt.Print(colorizeReport(scraper.ReportErrorsMulti(results), color))
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "\nFailed to fetch results for %d of %d packages:\n", len(errs), len(packages))
+		for _, pkg := range packages {
+			if err := errs[pkg]; err != nil {
+				fmt.Fprintf(os.Stderr, "\t%s: %v\n", pkg, err)
+			}
+		}
+	}
+	if failed {
 		os.Exit(1)
 	}
+}
 
-	req := &triggerlinkgenerator.LinkRequest{
-		Package:       packageName,
-		Version:       version,
-		Suite:         suite,
-		Triggers:      triggers,
-		PPA:           ppa,
-		AllProposed:   allProposed,
-		Architectures: archs,
+// readPackageFile reads the packages listed in path, exiting on error
+func readPackageFile(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-
-	gen := triggerlinkgenerator.NewGenerator()
-	resp, err := gen.GenerateLinks(req)
+	packages, err := parsePackageFile(f)
+	f.Close()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error generating trigger links: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error reading package file %s: %v\n", path, err)
 		os.Exit(1)
 	}
-
-	if len(resp.URLs) == 0 {
-		fmt.Println("No links generated.")
-		return
+	if len(packages) == 0 {
+		fmt.Fprintf(os.Stderr, "No packages found in package file %s\n", path)
+		os.Exit(1)
 	}
+	return packages
+}
 
-	fmt.Printf("Generated autopkgtest trigger URL(s) for package: %s\n\n", packageName)
-	fmt.Println("Visit the following URL(s) in your browser:")
-	fmt.Println("(You must be logged into Launchpad with appropriate permissions)")
-	fmt.Println()
-
-	for _, link := range resp.URLs {
-		fmt.Println(link)
+// parsePackageFile parses a list of packages with one name per line. Blank
+// lines and comments starting with # are ignored, and a package listed
+// twice is only checked once.
+func parsePackageFile(r io.Reader) ([]string, error) {
+	var packages []string
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 1 {
+			return nil, fmt.Errorf("line %d: expected one package name, got %q", lineNum, strings.TrimSpace(line))
+		}
+		if !slices.Contains(packages, fields[0]) {
+			packages = append(packages, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
 	}
+	return packages, nil
 }
 
-// handleTrigger triggers autopkgtest with authentication
-func handleTrigger(packageName, version, suite string, triggers []string, ppa string, allProposed bool, credentials string, wait bool, timeout, pollInterval time.Duration, archs []string) {
-	fmt.Println("=== Autopkgtest Trigger ===")
-	fmt.Println()
-
-	if packageName == "" {
-		fmt.Fprintln(os.Stderr, "Error: -package is required")
-		os.Exit(1)
+// splitPackages parses a comma-separated -packages list. As in
+// parsePackageFile, empty entries are ignored and a package listed twice is
+// only kept once.
+func splitPackages(list string) []string {
+	var packages []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !slices.Contains(packages, name) {
+			packages = append(packages, name)
+		}
 	}
+	return packages
+}
 
-	if suite == "" {
-		fmt.Fprintln(os.Stderr, "Error: -suite is required")
-		os.Exit(1)
-	}
+// formatStatusSummary formats status counts on one line, most frequent
+// first, e.g. "pass: 10, fail: 2, regression: 1"
+func formatStatusSummary(counts map[string]int, color bool) string {
+	statuses := slices.Collect(maps.Keys(counts))
+	slices.SortFunc(statuses, func(a, b string) int {
+		if c := cmp.Compare(counts[b], counts[a]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
 
-	// Generate the trigger URLs
-	req := &triggerlinkgenerator.LinkRequest{
-		Package:       packageName,
-		Version:       version,
-		Suite:         suite,
-		Triggers:      triggers,
-		PPA:           ppa,
-		AllProposed:   allProposed,
-		Architectures: archs,
+	parts := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		parts = append(parts, fmt.Sprintf("%s: %d", colorize(status, color), counts[status]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ANSI escape sequences used to color statuses
+const (
+	ansiReset  = "\033[0m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+)
+
+// statusColors maps status names to the color they are shown in; other
+// statuses are not colored
+var statusColors = map[autopkgtest.Status]string{
+	autopkgtest.StatusPass:       ansiGreen,
+	autopkgtest.StatusFail:       ansiRed,
+	autopkgtest.StatusRegression: ansiRed,
+	autopkgtest.StatusTmpfail:    ansiYellow,
+}
+
+// useColor reports whether output to f should be colored for the -color
+// mode: always, never, or auto, which colors only when f is a terminal and
+// the NO_COLOR environment variable is not set (see https://no-color.org)
+func useColor(mode string, f *os.File) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize returns status in its color if color is set
+func colorize(status string, color bool) string {
+	return colorizeAs(status, status, color)
+}
+
+// colorizeAs returns text in the color of status if color is set
+func colorizeAs(text, status string, color bool) string {
+	code, ok := statusColors[autopkgtest.ParseStatus(status)]
+	if !color || !ok {
+		return text
+	}
+	return code + text + ansiReset
+}
+
+// colorizeReport colors the status of each "Status:" line of an error report
+func colorizeReport(report string, color bool) string {
+	if !color {
+		return report
+	}
+	lines := strings.Split(report, "\n")
+	for i, line := range lines {
+		if status, ok := strings.CutPrefix(line, "\tStatus: "); ok {
+			lines[i] = "\tStatus: " + colorize(status, color)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exitFetchError reports a failure to fetch package results and exits,
+// explaining how to fix it when the server requires authentication
+func exitFetchError(err error) {
+	fmt.Fprintf(os.Stderr, "Error fetching results: %v\n", err)
+	if errors.Is(err, scraper.ErrAuthRequired) {
+		fmt.Fprintf(os.Stderr, "Results pages do not normally require a login; check whether a proxy or gateway between you and the server requires authentication.\n")
+	}
+	os.Exit(1)
+}
+
+// exitPackageFetchError is like exitFetchError, but first checks whether
+// the package exists at all so that a typo in its name gets a clear message
+func exitPackageFetchError(s *scraper.Scraper, packageName string, err error) {
+	if exists, existsErr := s.PackageExists(packageName); existsErr == nil && !exists {
+		fmt.Fprintf(os.Stderr, "Error: no such package: %s\n", packageName)
+		os.Exit(1)
+	}
+	exitFetchError(err)
+}
+
+// printWarnings writes any scraper warnings to stderr
+func printWarnings(results *scraper.PackageResults) {
+	for _, warning := range results.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+}
+
+// checkStrict returns an error if strict is set and the scraper reported
+// any warnings, so that layout changes on the server fail CI runs
+func checkStrict(results *scraper.PackageResults, strict bool) error {
+	if strict && len(results.Warnings) > 0 {
+		return fmt.Errorf("%d warning(s) reported in strict mode", len(results.Warnings))
+	}
+	return nil
+}
+
+// handleWatchUntilPass re-scrapes the package page until the selected
+// release/arch cell(s) pass or the timeout elapses
+func handleWatchUntilPass(packageName, release, arch string, timeout, pollInterval time.Duration) {
+	fmt.Printf("Watching %s [%s/%s] until it passes (timeout: %v, poll interval: %v)...\n\n", packageName, release, arch, timeout, pollInterval)
+
+	s := newScraper()
+	filter := &scraper.Filter{
+		Release:      release,
+		Architecture: arch,
+	}
+	results, err := s.WaitForPass(packageName, filter, pollInterval, timeout)
+	if err != nil {
+		if results != nil && errors.Is(err, scraper.ErrTimeout) {
+			fmt.Fprintf(os.Stderr, "⏱ %v\n\n", err)
+			fmt.Fprintln(os.Stderr, results.ReportErrors())
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Error watching results: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, test := range results.Tests {
+		if test.Status == string(autopkgtest.StatusNoData) {
+			continue
+		}
+		fmt.Printf("✓ %s/%s: %s\n", test.Release, test.Architecture, test.Status)
+	}
+}
+
+// checkFilter builds the scraper filter for the check options, or nil if none
+// is set. A version selects the results triggered by packageName/version.
+func checkFilter(packageName, release, arch, status, version string) *scraper.Filter {
+	if release == "" && arch == "" && status == "" && version == "" {
+		return nil
+	}
+	filter := &scraper.Filter{
+		Release:      release,
+		Architecture: arch,
+		Status:       status,
+	}
+	if version != "" {
+		filter.Trigger = packageName + "/" + version
+	}
+	return filter
+}
+
+// handleFailingReleases prints only the names of releases that have at least
+// one failing test, one per line
+func handleFailingReleases(packageName string, strict bool, release, arch, status, version string, failOn []string) {
+	s := newScraper(scraper.WithFailOn(failOn...))
+	filter := checkFilter(packageName, release, arch, status, version)
+	results, err := s.FetchPackageResultsFiltered(packageName, filter)
+	if err != nil {
+		exitPackageFetchError(s, packageName, err)
+	}
+	printWarnings(results)
+	if err := checkStrict(results, strict); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	releases := results.FailingReleases()
+	for _, r := range releases {
+		fmt.Println(r)
+	}
+
+	// Exit with error code if any release is failing
+	if len(releases) > 0 {
+		os.Exit(1)
+	}
+}
+
+// handleDiff prints the release/arch cells whose status differs between two
+// packages and exits with 1 if there are any, like diff(1)
+func handleDiff(packageA, packageB, release, arch string) {
+	var filter *scraper.Filter
+	if release != "" || arch != "" {
+		filter = &scraper.Filter{
+			Release:      release,
+			Architecture: arch,
+		}
+	}
+
+	s := newScraper()
+	results, errs := s.FetchMultiplePackages([]string{packageA, packageB}, filter, 2)
+	for _, pkg := range []string{packageA, packageB} {
+		if err := errs[pkg]; err != nil {
+			exitFetchError(fmt.Errorf("%s: %w", pkg, err))
+		}
+	}
+
+	diffs := scraper.DiffResults(results[packageA], results[packageB])
+	fmt.Print(formatDiff(packageA, packageB, diffs))
+	if len(diffs) > 0 {
+		os.Exit(1)
+	}
+}
+
+// formatDiff renders diffs as a table with one status column per package;
+// cells missing from a package are shown as "-"
+func formatDiff(packageA, packageB string, diffs []scraper.ResultDiff) string {
+	if len(diffs) == 0 {
+		return fmt.Sprintf("No differences between %s and %s.\n", packageA, packageB)
+	}
+
+	orDash := func(status string) string {
+		if status == "" {
+			return "-"
+		}
+		return status
+	}
+
+	var out strings.Builder
+	tw := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "RELEASE\tARCH\t%s\t%s\n", packageA, packageB)
+	for _, d := range diffs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", d.Release, d.Architecture, orDash(d.StatusA), orDash(d.StatusB))
+	}
+	tw.Flush()
+	fmt.Fprintf(&out, "\n%d cell(s) differ\n", len(diffs))
+	return out.String()
+}
+
+// handleQueues prints the number of queued requests for each release/arch,
+// optionally only those of release
+func handleQueues(release string) {
+	client, err := autopkgtestclient.NewClient(autopkgtestclient.WithBaseURL(serverURL()))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating client: %v\n", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	sizes, err := client.GetQueueSizes()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching queues: %v\n", err)
+		if errors.Is(err, autopkgtestclient.ErrQueuesUnavailable) {
+			fmt.Fprintln(os.Stderr, "The queue page is temporarily unavailable; try again in a few minutes.")
+		}
+		os.Exit(1)
+	}
+
+	if release != "" {
+		maps.DeleteFunc(sizes, func(queue string, _ int) bool {
+			return !strings.HasPrefix(queue, release+"/")
+		})
+	}
+	fmt.Print(formatQueueSizes(sizes))
+}
+
+// formatQueueSizes renders the queue sizes returned by GetQueueSizes as a
+// table sorted by release and architecture, followed by the total
+func formatQueueSizes(sizes map[string]int) string {
+	if len(sizes) == 0 {
+		return "No queues found.\n"
+	}
+
+	queues := slices.Sorted(maps.Keys(sizes))
+	total := 0
+	var out strings.Builder
+	tw := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RELEASE\tARCH\tQUEUED")
+	for _, queue := range queues {
+		release, arch, _ := strings.Cut(queue, "/")
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", release, arch, sizes[queue])
+		total += sizes[queue]
+	}
+	tw.Flush()
+	fmt.Fprintf(&out, "\n%d request(s) queued in total\n", total)
+	return out.String()
+}
+
+// handleFetchLogs downloads the latest log of every failing test into outputDir
+func handleFetchLogs(packageName, outputDir, release, arch string) {
+	s := newScraper()
+	var filter *scraper.Filter
+	if release != "" || arch != "" {
+		filter = &scraper.Filter{
+			Release:      release,
+			Architecture: arch,
+		}
+	}
+	results, err := s.FetchPackageResultsFiltered(packageName, filter)
+	if err != nil {
+		exitPackageFetchError(s, packageName, err)
+	}
+	printWarnings(results)
+
+	if len(results.Errors) == 0 {
+		fmt.Printf("No failing tests found for package: %s\n", packageName)
+		return
+	}
+
+	fmt.Printf("Downloading logs for %d failing test(s) of %s to %s\n\n", len(results.Errors), packageName, outputDir)
+
+	downloads, err := s.DownloadFailureLogs(results, outputDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error downloading logs: %v\n", err)
+		os.Exit(1)
+	}
+
+	failed := 0
+	for _, d := range downloads {
+		switch {
+		case d.Err != nil:
+			failed++
+			fmt.Fprintf(os.Stderr, "✗ %s/%s: %v\n", d.Test.Release, d.Test.Architecture, d.Err)
+		case d.Skipped:
+			fmt.Printf("- %s/%s: already downloaded (%s)\n", d.Test.Release, d.Test.Architecture, d.Path)
+		default:
+			fmt.Printf("✓ %s/%s: %s\n", d.Test.Release, d.Test.Architecture, d.Path)
+		}
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "\n%d log(s) could not be downloaded.\n", failed)
+		os.Exit(1)
+	}
+}
+
+func handleGenerateTriggerLink(packageName, version, suite string, triggers, ppas, readableBy []string, allProposed, allowAnyTrigger, discoverArch, validateOnly bool, format, output string, archs []string) {
+	if packageName == "" {
+		fmt.Fprintln(os.Stderr, "Error: -package is required")
+		os.Exit(exitUsage)
+	}
+
+	if suite == "" {
+		fmt.Fprintln(os.Stderr, "Error: -suite is required")
+		os.Exit(exitUsage)
+	}
+
+	req := &triggerlinkgenerator.LinkRequest{
+		Package:               packageName,
+		Version:               version,
+		Suite:                 suite,
+		Triggers:              triggers,
+		PPAs:                  ppas,
+		ReadableBy:            readableBy,
+		AllProposed:           allProposed,
+		Architectures:         archs,
+		AllowAnyTrigger:       allowAnyTrigger,
+		DiscoverArchitectures: discoverArch,
+	}
+
+	gen := newGenerator()
+	if discoverArch {
+		gen.ArchSource = newScraper()
+	}
+	if validateOnly {
+		if err := gen.Validate(req); err != nil {
+			fmt.Fprintf(os.Stderr, "Validation failed:\n%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("OK: request is valid")
+		return
 	}
 
-	gen := triggerlinkgenerator.NewGenerator()
 	resp, err := gen.GenerateLinks(req)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error generating trigger links: %v\n", err)
 		os.Exit(1)
 	}
 
+	if format == "yaml" {
+		if err := resp.WriteYAML(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing YAML: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if output != "" {
+		writeLinksOutput(output, resp.URLs)
+		return
+	}
+
 	if len(resp.URLs) == 0 {
-		fmt.Println("No links to trigger.")
+		fmt.Println("No links generated.")
 		return
 	}
 
-	// Create autopkgtest client
-	var clientOpts []autopkgtestclient.ClientOption
+	fmt.Printf("Generated autopkgtest trigger URL(s) for package: %s\n\n", packageName)
+	fmt.Println("Visit the following URL(s) in your browser:")
+	fmt.Println("(You must be logged into Launchpad with appropriate permissions)")
+	fmt.Println()
 
-	// Try to load cookies from multiple sources (in priority order)
-	cookies, source, err := loadCookies(credentials)
+	for _, link := range resp.URLs {
+		fmt.Println(link)
+	}
+}
+
+// handleGeneratePackagesTriggerLinks prints trigger URLs for several packages
+// sharing the same options, grouped and labeled by package
+func handleGeneratePackagesTriggerLinks(packages []string, version, suite string, triggers, ppas, readableBy []string, allProposed, allowAnyTrigger, discoverArch, validateOnly bool, output string, archs []string) {
+	req := &triggerlinkgenerator.LinkRequest{
+		Version:               version,
+		Suite:                 suite,
+		Triggers:              triggers,
+		PPAs:                  ppas,
+		ReadableBy:            readableBy,
+		AllProposed:           allProposed,
+		Architectures:         archs,
+		AllowAnyTrigger:       allowAnyTrigger,
+		DiscoverArchitectures: discoverArch,
+	}
+
+	gen := newGenerator()
+	if discoverArch {
+		gen.ArchSource = newScraper()
+	}
+	if validateOnly {
+		failed := false
+		for _, pkg := range packages {
+			pkgReq := *req
+			pkgReq.Package = pkg
+			if err := gen.Validate(&pkgReq); err != nil {
+				fmt.Fprintf(os.Stderr, "Validation failed for %s:\n%v\n", pkg, err)
+				failed = true
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+		fmt.Println("OK: request is valid")
+		return
+	}
+
+	links, err := gen.GenerateLinksForPackages(req, packages)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Failed to load cookies: %v\n", err)
-		fmt.Fprintf(os.Stderr, "Will attempt to trigger without authentication (may fail)\n\n")
-	} else if len(cookies) > 0 {
-		fmt.Printf("Loaded session cookie from %s\n\n", source)
-		clientOpts = append(clientOpts, autopkgtestclient.WithCookies(cookies))
+		fmt.Fprintf(os.Stderr, "Error generating trigger links: %v\n", err)
+		os.Exit(1)
 	}
 
-	client, err := autopkgtestclient.NewClient(clientOpts...)
+	if output != "" {
+		var urls []string
+		for _, pkgLinks := range links {
+			urls = append(urls, pkgLinks.URLs...)
+		}
+		writeLinksOutput(output, urls)
+		return
+	}
+
+	fmt.Printf("Generated autopkgtest trigger URL(s) for %d packages\n\n", len(links))
+	fmt.Println("Visit the following URL(s) in your browser:")
+	fmt.Println("(You must be logged into Launchpad with appropriate permissions)")
+
+	for _, pkgLinks := range links {
+		fmt.Printf("\n%s:\n", pkgLinks.Package)
+		for _, link := range pkgLinks.URLs {
+			fmt.Println(link)
+		}
+	}
+}
+
+// writeLinksOutput writes urls to path for -output and confirms on stderr,
+// keeping stdout empty
+func writeLinksOutput(path string, urls []string) {
+	if err := writeLinksFile(path, urls); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d trigger URL(s) to %s\n", len(urls), path)
+}
+
+// writeLinksFile writes urls to path, one per line, replacing any existing
+// content, so that the file can be fed to xargs
+func writeLinksFile(path string, urls []string) error {
+	var content strings.Builder
+	for _, u := range urls {
+		content.WriteString(u + "\n")
+	}
+
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// triggerExitCode returns the exit code of the trigger command for err
+func triggerExitCode(err error) int {
+	switch {
+	case errors.Is(err, autopkgtestclient.ErrAuthRequired):
+		return exitAuthRequired
+	case errors.Is(err, autopkgtestclient.ErrInvalidRequest):
+		return exitInvalidRequest
+	case errors.Is(err, autopkgtestclient.ErrTimeout):
+		return exitTimeout
+	default:
+		return 1
+	}
+}
+
+// triggerOptions holds the trigger flags shared by single and -batch
+// requests, and those that only apply to a single request
+type triggerOptions struct {
+	Triggers        []string
+	PPAs            []string
+	ReadableBy      []string
+	AllProposed     bool
+	AllowAnyTrigger bool
+	Credentials     string
+	SessionFile     string
+	SkipRunning     bool
+	Quiet           bool
+
+	// Ignored by -batch
+	Version      string
+	Archs        []string // Empty for all architectures
+	Wait         bool
+	Timeout      time.Duration
+	PollInterval time.Duration
+	LogTail      int
+	RetryTmpfail int
+	Color        bool
+	Format       string
+	EmitScript   string
+	Webhook      string
+}
+
+// handleTrigger triggers autopkgtest with authentication
+func handleTrigger(packageName, suite string, opts triggerOptions) {
+	// In quiet mode stdout only gets the uuid= lines printed by
+	// printQuietResults, and in JSON mode the document printed by
+	// writeTriggerJSON; progress output is dropped and errors still go to
+	// stderr
+	machineOutput := opts.Quiet || opts.Format == "json"
+	var out io.Writer = os.Stdout
+	if machineOutput {
+		out = io.Discard
+	}
+
+	// Final statuses of the tests waited for, by UUID, for JSON output
+	finalStatuses := make(map[string]*autopkgtestclient.TestStatus)
+	emitJSON := func(results []*autopkgtestclient.TriggerResult) {
+		if opts.Format != "json" {
+			return
+		}
+		if err := writeTriggerJSON(os.Stdout, results, finalStatuses); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Fprintln(out, "=== Autopkgtest Trigger ===")
+	fmt.Fprintln(out)
+
+	if packageName == "" {
+		fmt.Fprintln(os.Stderr, "Error: -package is required")
+		os.Exit(exitUsage)
+	}
+
+	if suite == "" {
+		fmt.Fprintln(os.Stderr, "Error: -suite is required")
+		os.Exit(exitUsage)
+	}
+
+	// Generate the trigger URLs
+	req := &triggerlinkgenerator.LinkRequest{
+		Package:         packageName,
+		Version:         opts.Version,
+		Suite:           suite,
+		Triggers:        opts.Triggers,
+		PPAs:            opts.PPAs,
+		ReadableBy:      opts.ReadableBy,
+		AllProposed:     opts.AllProposed,
+		Architectures:   opts.Archs,
+		AllowAnyTrigger: opts.AllowAnyTrigger,
+	}
+
+	gen := newGenerator()
+	resp, err := gen.GenerateLinks(req)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating client: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error generating trigger links: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Trigger tests for each URL
-	var results []*autopkgtestclient.TriggerResult
-	for i, triggerURL := range resp.URLs {
-		if len(resp.URLs) > 1 {
-			fmt.Printf("[%d/%d] Triggering test...\n", i+1, len(resp.URLs))
-		} else {
-			fmt.Println("Triggering test...")
+	if len(resp.URLs) == 0 {
+		fmt.Fprintln(out, "No links to trigger.")
+		return
+	}
+
+	if opts.EmitScript != "" {
+		if err := writeTriggerScript(opts.EmitScript, resp.URLs); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing script: %v\n", err)
+			os.Exit(1)
 		}
+		fmt.Fprintf(out, "Wrote equivalent requests to %s\n\n", opts.EmitScript)
+	}
 
-		result, err := client.TriggerTest(triggerURL)
-		if err != nil {
-			if strings.Contains(err.Error(), "authentication required") {
-				fmt.Fprintf(os.Stderr, "\nAuthentication required!\n\n")
-				fmt.Fprintf(os.Stderr, "Please authenticate in your browser:\n")
-				fmt.Fprintf(os.Stderr, "\t1. Visit: https://autopkgtest.ubuntu.com/login\n")
-				fmt.Fprintf(os.Stderr, "\t2. Log in with your Launchpad credentials\n")
-				fmt.Fprintf(os.Stderr, "\t3. Export your session cookies and save to a file\n")
-				fmt.Fprintf(os.Stderr, "\t4. Retry with: -credentials <cookie-file>\n\n")
-				fmt.Fprintf(os.Stderr, "Alternatively, open the URL manually in your browser:\n")
-				fmt.Fprintf(os.Stderr, "  %s\n\n", triggerURL)
-				os.Exit(1)
-			} else if strings.Contains(err.Error(), "already running") {
-				// Try to find the running test
-				arch := extractArchFromURL(triggerURL)
-				fmt.Printf("⚠ Test already running for %s/%s/%s\n", packageName, suite, arch)
-				fmt.Printf("\tAttempting to find running test UUID...\n")
-
-				uuid, err := client.FindRunningTest(packageName, suite, arch)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "\tCould not find running test UUID: %v\n", err)
-					fmt.Fprintf(os.Stderr, "\tCheck status manually at: https://autopkgtest.ubuntu.com/packages/%s/%s/%s\n\n", packageName, suite, arch)
-					continue
-				}
+	client := newTriggerClient(opts.Credentials, opts.SessionFile, out, autopkgtestclient.WithTmpfailRetry(opts.RetryTmpfail))
 
-				// Create a fake result for the running test so we can monitor it
-				result = &autopkgtestclient.TriggerResult{
-					UUID:       uuid,
-					ResultURL:  fmt.Sprintf("https://autopkgtest.ubuntu.com/run/%s", uuid),
-					HistoryURL: fmt.Sprintf("https://autopkgtest.ubuntu.com/packages/%s/%s/%s", packageName, suite, arch),
-					Package:    packageName,
-					Release:    suite,
-					Arch:       arch,
-				}
+	triggerer := autopkgtestclient.NewTriggerer(client, gen)
+	triggerOpts := autopkgtestclient.TriggerOptions{
+		SkipRunning:  opts.SkipRunning,
+		PollInterval: opts.PollInterval,
+		Timeout:      opts.Timeout,
+	}
+
+	// Running tests are looked up before anything is submitted
+	if opts.SkipRunning {
+		fmt.Fprintln(out, "Checking for tests already running...")
+		fmt.Fprintln(out)
+	}
 
-				fmt.Printf("\t✓ Found running test!\n")
-				fmt.Printf("\tUUID:    %s\n", result.UUID)
-				fmt.Printf("\tResults: %s\n", result.ResultURL)
-				fmt.Println()
-			} else if strings.Contains(err.Error(), "invalid request") {
-				fmt.Fprintf(os.Stderr, "✗ %v\n", err)
-				os.Exit(1)
+	var skipped int
+	triggerOpts.OnEvent = func(event autopkgtestclient.TriggerEvent) {
+		result := event.Result
+		switch event.Kind {
+		case autopkgtestclient.EventSkipped:
+			fmt.Fprintf(out, "⏭ Skipping %s/%s/%s: test already running (UUID: %s)\n\n", result.Package, result.Release, result.Arch, result.UUID)
+			skipped++
+		case autopkgtestclient.EventTriggering:
+			if event.Total > 1 {
+				fmt.Fprintf(out, "[%d/%d] Triggering test...\n", event.Index+1, event.Total)
 			} else {
-				fmt.Fprintf(os.Stderr, "Error triggering test: %v\n", err)
-				os.Exit(1)
+				fmt.Fprintln(out, "Triggering test...")
 			}
-		} else {
-			fmt.Printf("✓ Test triggered successfully!\n")
+		case autopkgtestclient.EventAlreadyRunning:
+			fmt.Fprintf(out, "⚠ Test already running for %s/%s/%s\n", result.Package, result.Release, result.Arch)
+			fmt.Fprintf(out, "\tAttempting to find running test UUID...\n")
+			if event.Err != nil {
+				fmt.Fprintf(os.Stderr, "\tCould not find running test UUID: %v\n", event.Err)
+				fmt.Fprintf(os.Stderr, "\tCheck status manually at: %s\n\n", result.HistoryURL)
+				return
+			}
+			fmt.Fprintf(out, "\t✓ Found running test!\n")
+			fmt.Fprintf(out, "\tUUID:    %s\n", result.UUID)
+			fmt.Fprintf(out, "\tResults: %s\n", result.ResultURL)
+			fmt.Fprintln(out)
+		case autopkgtestclient.EventTriggered:
+			saveSession(client, opts.SessionFile)
+			fmt.Fprintf(out, "✓ Test triggered successfully!\n")
 			if result.UUID != "" {
-				fmt.Printf("\tUUID:     %s\n", result.UUID)
+				fmt.Fprintf(out, "\tUUID:     %s\n", result.UUID)
 			}
-			fmt.Printf("\tPackage:  %s\n", result.Package)
-			fmt.Printf("\tRelease:  %s\n", result.Release)
-			fmt.Printf("\tArch:     %s\n", result.Arch)
-			fmt.Printf("\tResults:  %s\n", result.ResultURL)
+			fmt.Fprintf(out, "\tPackage:  %s\n", result.Package)
+			fmt.Fprintf(out, "\tRelease:  %s\n", result.Release)
+			fmt.Fprintf(out, "\tArch:     %s\n", result.Arch)
+			fmt.Fprintf(out, "\tResults:  %s\n", result.ResultURL)
 			if result.UUID == "" {
-				fmt.Printf("\tNote:     PPA test submitted (no UUID available)\n")
+				fmt.Fprintf(out, "\tNote:     PPA test submitted (no UUID available)\n")
 			}
-			fmt.Println()
+			fmt.Fprintln(out)
+		}
+	}
+
+	results, err := triggerer.TriggerURLs(context.Background(), resp.URLs, triggerOpts)
+	var triggerErr *autopkgtestclient.TriggerError
+	if errors.As(err, &triggerErr) {
+		err := triggerErr.Err
+		if errors.Is(err, autopkgtestclient.ErrAuthRequired) {
+			fmt.Fprintf(os.Stderr, "\nAuthentication required!\n\n")
+			fmt.Fprintf(os.Stderr, "Please authenticate in your browser:\n")
+			fmt.Fprintf(os.Stderr, "\t1. Visit: %s/login\n", serverURL())
+			fmt.Fprintf(os.Stderr, "\t2. Log in with your Launchpad credentials\n")
+			fmt.Fprintf(os.Stderr, "\t3. Export your session cookies and save to a file\n")
+			fmt.Fprintf(os.Stderr, "\t4. Retry with: -credentials <cookie-file>\n\n")
+			fmt.Fprintf(os.Stderr, "Alternatively, open the URL manually in your browser:\n")
+			fmt.Fprintf(os.Stderr, "  %s\n\n", triggerErr.URL)
+			os.Exit(triggerExitCode(err))
+		} else if errors.Is(err, autopkgtestclient.ErrThrottled) {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			fmt.Fprintf(os.Stderr, "Too many test requests have been submitted; wait before retrying.\n")
+			os.Exit(1)
+		} else if errors.Is(err, autopkgtestclient.ErrInvalidRequest) {
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			os.Exit(triggerExitCode(err))
 		}
+		fmt.Fprintf(os.Stderr, "Error triggering test: %v\n", err)
+		if triggerErr.Result != nil {
+			printPartialResult(os.Stderr, triggerErr.Result)
+		}
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Error triggering test: %v\n", err)
+		os.Exit(1)
+	}
 
-		results = append(results, result)
+	if skipped > 0 {
+		fmt.Fprintf(out, "Skipped %d test(s) already running.\n\n", skipped)
 	}
 
 	if len(results) == 0 {
-		fmt.Println("No tests were triggered.")
+		fmt.Fprintln(os.Stderr, "No tests were triggered.")
 		os.Exit(1)
 	}
 
+	if opts.Quiet {
+		printQuietResults(os.Stdout, results)
+	}
+
 	// Wait for completion if requested
-	if wait {
+	if opts.Wait {
 		// Filter out PPA tests (those without UUIDs) since we can't track them individually
 		var trackableResults []*autopkgtestclient.TriggerResult
 		var ppaResults []*autopkgtestclient.TriggerResult
@@ -460,141 +1732,813 @@ func handleTrigger(packageName, version, suite string, triggers []string, ppa st
 		}
 
 		if len(ppaResults) > 0 {
-			fmt.Println("Note: PPA tests cannot be tracked automatically. Please check results manually at:")
+			fmt.Fprintln(out, "Note: PPA tests cannot be tracked automatically. Please check results manually at:")
 			for _, result := range ppaResults {
-				fmt.Printf("  • %s (%s/%s) - %s\n", result.Package, result.Release, result.Arch, result.ResultURL)
+				fmt.Fprintf(out, "  • %s (%s/%s) - %s\n", result.Package, result.Release, result.Arch, result.ResultURL)
 			}
-			fmt.Println()
+			fmt.Fprintln(out)
 		}
 
 		if len(trackableResults) == 0 {
-			fmt.Println("No trackable tests to wait for.")
+			fmt.Fprintln(out, "No trackable tests to wait for.")
+			emitJSON(results)
 			return
 		}
 
-		fmt.Printf("Waiting for test completion (timeout: %v, poll interval: %v)...\n\n", timeout, pollInterval)
+		fmt.Fprintf(out, "Waiting for test completion (timeout: %v, poll interval: %v)...\n\n", opts.Timeout, opts.PollInterval)
 
-		hasFailure := false
-		for _, result := range trackableResults {
-			fmt.Printf("Monitoring: %s [%s/%s]\n", result.Package, result.Release, result.Arch)
-			fmt.Printf("UUID: %s\n", result.UUID)
+		history := newScraper()
+		// With several tests, progress lines are labelled with the test
+		// they are about since the tests are polled concurrently
+		label := func(result *autopkgtestclient.TriggerResult) string {
+			if len(trackableResults) == 1 {
+				return ""
+			}
+			return fmt.Sprintf("[%s/%s] ", result.Release, result.Arch)
+		}
+		expected := make([]time.Duration, len(trackableResults))
+		for i, result := range trackableResults {
+			fmt.Fprintf(out, "Monitoring: %s [%s/%s]\n", result.Package, result.Release, result.Arch)
+			fmt.Fprintf(out, "UUID: %s\n", result.UUID)
 			// Print the packages page URL where live logs can be viewed
-			packagesURL := fmt.Sprintf("https://autopkgtest.ubuntu.com/packages/%s", result.Package)
-			fmt.Printf("View logs: %s\n", packagesURL)
-			fmt.Println("Waiting for test to complete...")
-			fmt.Println()
+			fmt.Fprintf(out, "View logs: %s/packages/%s\n", serverURL(), result.Package)
+			// The ETA is best effort: new tests have no history to go by
+			if d, err := history.ExpectedDuration(result.Package, result.Release, result.Arch); err == nil {
+				expected[i] = d
+				fmt.Fprintf(out, "Expected duration: ~%s (median of past runs)\n", formatApproxDuration(d))
+			}
+			fmt.Fprintln(out)
+		}
 
-			status, err := client.WaitForCompletion(result.Package, result.UUID, pollInterval, timeout)
+		// Report each status change (e.g. queued, then running) while
+		// polling, and the elapsed time on every poll while running
+		lastStatuses := make([]autopkgtest.Status, len(trackableResults))
+		runningSince := make([]time.Time, len(trackableResults))
+		onUpdate := func(i int, update *autopkgtestclient.TestStatus) {
+			result := trackableResults[i]
+			if update.Status == autopkgtest.StatusRunning && expected[i] > 0 {
+				if runningSince[i].IsZero() {
+					runningSince[i] = time.Now()
+				}
+				lastStatuses[i] = update.Status
+				fmt.Fprintf(out, "\t%sStatus: running (~%s expected, %s elapsed)\n", label(result), formatApproxDuration(expected[i]), formatApproxDuration(time.Since(runningSince[i])))
+				return
+			}
+			if update.Status == lastStatuses[i] {
+				return
+			}
+			lastStatuses[i] = update.Status
+			if update.Status == autopkgtest.StatusQueued {
+				if pos, total, err := client.GetQueuePosition(update.UUID); err == nil {
+					fmt.Fprintf(out, "\t%sStatus: queued (position %d of %d)\n", label(result), pos, total)
+					return
+				}
+			}
+			fmt.Fprintf(out, "\t%sStatus: %s\n", label(result), colorize(string(update.Status), opts.Color))
+		}
+
+		// Each test is reported as soon as it completes. A test failure
+		// takes precedence over a timeout, which takes precedence over
+		// other monitoring errors.
+		hasFailure, hasTimeout, hasError := false, false, false
+		onDone := func(i int, status *autopkgtestclient.TestStatus, err error) {
+			result := trackableResults[i]
+			packagesURL := fmt.Sprintf("%s/packages/%s", serverURL(), result.Package)
 			if err != nil {
-				if strings.Contains(err.Error(), "timeout") {
-					fmt.Fprintf(os.Stderr, "⏱ Timeout reached. Test still running.\n")
+				if errors.Is(err, autopkgtestclient.ErrTimeout) {
+					fmt.Fprintf(os.Stderr, "⏱ Timeout reached. Test %s [%s/%s] still running.\n", result.Package, result.Release, result.Arch)
 					fmt.Fprintf(os.Stderr, "Check status at: %s\n\n", packagesURL)
-					hasFailure = true
-					continue
+					hasTimeout = true
+					return
 				}
-				fmt.Fprintf(os.Stderr, "Error monitoring test: %v\n\n", err)
-				hasFailure = true
-				continue
+				fmt.Fprintf(os.Stderr, "Error monitoring test %s [%s/%s]: %v\n\n", result.Package, result.Release, result.Arch, err)
+				hasError = true
+				return
 			}
 
-			fmt.Println("=== Test Complete ===")
+			finalStatuses[result.UUID] = status
+			fmt.Fprintf(out, "=== Test Complete: %s [%s/%s] ===\n", result.Package, result.Release, result.Arch)
+			var verdict string
 			switch status.Status {
-			case "pass":
-				fmt.Printf("✓ PASS")
-			case "fail":
-				fmt.Printf("✗ FAIL")
-				hasFailure = true
-			case "neutral":
-				fmt.Printf("○ NEUTRAL")
+			case autopkgtest.StatusPass:
+				verdict = "✓ PASS"
+			case autopkgtest.StatusFail:
+				verdict = "✗ FAIL"
+			case autopkgtest.StatusNeutral:
+				verdict = "○ NEUTRAL"
 			default:
-				fmt.Printf("? %s", strings.ToUpper(status.Status))
+				verdict = "? " + strings.ToUpper(string(status.Status))
+			}
+			if isTestFailure(status.Status) {
+				hasFailure = true
 			}
+			fmt.Fprint(out, colorizeAs(verdict, string(status.Status), opts.Color))
 
 			if status.Duration != "" {
-				fmt.Printf(" (Duration: %s)", status.Duration)
+				fmt.Fprintf(out, " (Duration: %s)", status.Duration)
+			}
+			fmt.Fprintln(out)
+			if status.Testbed != "" {
+				fmt.Fprintf(out, "Testbed: %s\n", status.Testbed)
+			}
+			if status.Region != "" {
+				fmt.Fprintf(out, "Region: %s\n", status.Region)
+			}
+			if status.Retries > 0 {
+				fmt.Fprintf(out, "Retried %d time(s) after tmpfail (final UUID: %s)\n", status.Retries, status.UUID)
 			}
-			fmt.Println()
 			// Now print the result URL since the test is complete
-			fmt.Printf("Results: %s\n\n", status.LogURL)
+			fmt.Fprintf(out, "Results: %s\n\n", status.LogURL)
+
+			// A failed delivery is reported but does not change the exit code
+			if opts.Webhook != "" {
+				if err := client.PostWebhook(opts.Webhook, autopkgtestclient.NewCompletionNotification(result, status)); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: webhook delivery failed: %v\n\n", err)
+				}
+			}
+
+			if opts.LogTail > 0 && isTestFailure(status.Status) {
+				tail, err := client.GetTestLogTail(status.UUID, opts.LogTail)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Could not fetch log: %v\n\n", err)
+				} else {
+					logOut := out
+					if machineOutput {
+						logOut = os.Stderr
+					}
+					fmt.Fprintf(logOut, "--- Last %d lines of log ---\n%s\n\n", opts.LogTail, tail)
+				}
+			}
 		}
 
-		if hasFailure {
-			fmt.Fprintln(os.Stderr, "One or more tests failed or timed out.")
+		// Errors are reported by onDone as each test finishes
+		triggerOpts.OnEvent = func(event autopkgtestclient.TriggerEvent) {
+			switch event.Kind {
+			case autopkgtestclient.EventStatus:
+				onUpdate(event.Index, event.Status)
+			case autopkgtestclient.EventDone:
+				onDone(event.Index, event.Status, event.Err)
+			}
+		}
+		triggerer.Wait(context.Background(), trackableResults, triggerOpts)
+		emitJSON(results)
+		switch {
+		case hasFailure:
+			fmt.Fprintln(os.Stderr, "One or more tests failed.")
+			os.Exit(exitTestFailure)
+		case hasTimeout:
+			fmt.Fprintln(os.Stderr, "One or more tests timed out.")
+			os.Exit(exitTimeout)
+		case hasError:
+			fmt.Fprintln(os.Stderr, "One or more tests could not be monitored.")
 			os.Exit(1)
 		}
-		fmt.Println("All tests completed successfully.")
+		fmt.Fprintln(out, "All tests completed successfully.")
 	} else {
-		fmt.Println("Tests triggered. Check status and logs at:")
+		fmt.Fprintln(out, "Tests triggered. Check status and logs at:")
 		for _, result := range results {
-			packagesURL := fmt.Sprintf("https://autopkgtest.ubuntu.com/packages/%s", result.Package)
-			fmt.Printf("  • %s (%s/%s) - %s\n", result.Package, result.Release, result.Arch, packagesURL)
+			packagesURL := fmt.Sprintf("%s/packages/%s", serverURL(), result.Package)
+			fmt.Fprintf(out, "  • %s (%s/%s) - %s\n", result.Package, result.Release, result.Arch, packagesURL)
 		}
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Tip: Use --wait flag to monitor test completion automatically.")
+		emitJSON(results)
+	}
+}
+
+// formatApproxDuration formats d rounded to the minute, e.g. "1h20m" or
+// "15m"; durations under a minute are "<1m"
+func formatApproxDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "<1m"
+	}
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh%02dm", hours, minutes)
+}
+
+// triggerJSONEntry is one triggered test in trigger -format json output,
+// with its final status when it was waited for
+type triggerJSONEntry struct {
+	*autopkgtestclient.TriggerResult
+	FinalStatus *autopkgtestclient.TestStatus `json:"final_status,omitempty"`
+}
+
+// writeTriggerJSON writes results as a JSON array, adding the final status
+// of the tests found in statuses (keyed by UUID)
+func writeTriggerJSON(w io.Writer, results []*autopkgtestclient.TriggerResult, statuses map[string]*autopkgtestclient.TestStatus) error {
+	entries := make([]triggerJSONEntry, 0, len(results))
+	for _, result := range results {
+		entry := triggerJSONEntry{TriggerResult: result}
+		if result.UUID != "" {
+			entry.FinalStatus = statuses[result.UUID]
+		}
+		entries = append(entries, entry)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+// printQuietResults writes one machine-parseable "uuid=<uuid> arch=<arch>"
+// line per triggered test. uuid is empty for PPA tests, which have none.
+func printQuietResults(w io.Writer, results []*autopkgtestclient.TriggerResult) {
+	for _, result := range results {
+		fmt.Fprintf(w, "uuid=%s arch=%s\n", result.UUID, result.Arch)
+	}
+}
+
+// batchItem is one request of a -batch file
+type batchItem struct {
+	Line     int
+	Package  string
+	Release  string
+	Archs    []string // Empty for all architectures
+	Triggers []string // Empty to use -trigger or the package default
+}
+
+// parseBatchFile reads trigger requests, one per line:
+//
+//	<package> <release> [<arch>[,<arch>...]] [<trigger>...]
+//
+// An arch of "all" (or none) requests every architecture. Blank lines and
+// lines starting with # are ignored.
+func parseBatchFile(r io.Reader) ([]batchItem, error) {
+	var items []batchItem
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected <package> <release> [<arch>] [<trigger>...], got %q", lineNum, line)
+		}
+
+		item := batchItem{Line: lineNum, Package: fields[0], Release: fields[1]}
+		if len(fields) > 2 && fields[2] != triggerlinkgenerator.AllArchitectures {
+			item.Archs = strings.Split(fields[2], ",")
+		}
+		if len(fields) > 3 {
+			item.Triggers = fields[3:]
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// batchOutcome is the result of one package/release/arch of a batch
+type batchOutcome struct {
+	Package string
+	Release string
+	Arch    string
+	Result  *autopkgtestclient.TriggerResult // Set on success, and for running tests
+	Running bool                             // Already running, so not submitted again
+	Err     error
+}
+
+// runBatch generates and triggers the tests of every item with triggerer,
+// with the other options (default triggers, PPAs, all-proposed) taken from
+// base. With skipRunning, the tests already running are skipped. Errors are
+// recorded per outcome and the batch carries on, except for an
+// authentication error, which would fail every remaining request: it is
+// returned along with the outcomes so far, as is ctx's error.
+func runBatch(ctx context.Context, gen *triggerlinkgenerator.Generator, triggerer *autopkgtestclient.Triggerer, items []batchItem, base triggerlinkgenerator.LinkRequest, skipRunning bool, progress io.Writer) ([]batchOutcome, error) {
+	var outcomes []batchOutcome
+	for _, item := range items {
+		req := base
+		req.Package = item.Package
+		req.Suite = item.Release
+		req.Architectures = item.Archs
+		if len(item.Triggers) > 0 {
+			req.Triggers = item.Triggers
+		}
+
+		resp, err := gen.GenerateLinks(&req)
+		if err != nil {
+			arch := triggerlinkgenerator.AllArchitectures
+			if len(item.Archs) > 0 {
+				arch = strings.Join(item.Archs, ",")
+			}
+			outcomes = append(outcomes, batchOutcome{
+				Package: item.Package,
+				Release: item.Release,
+				Arch:    arch,
+				Err:     fmt.Errorf("line %d: %w", item.Line, err),
+			})
+			continue
+		}
+
+		// TriggerURLs stops at the first failure, so it is called again for
+		// the URLs after the failed one
+		urls := resp.URLs
+		for len(urls) > 0 {
+			failed := 0
+			opts := autopkgtestclient.TriggerOptions{
+				SkipRunning: skipRunning,
+				OnEvent: func(event autopkgtestclient.TriggerEvent) {
+					outcome := batchOutcome{Package: item.Package, Release: item.Release, Arch: extractArchFromURL(event.URL), Result: event.Result}
+					switch event.Kind {
+					case autopkgtestclient.EventTriggering:
+						fmt.Fprintf(progress, "Triggering %s/%s/%s...\n", outcome.Package, outcome.Release, outcome.Arch)
+						failed = event.Index
+						return
+					case autopkgtestclient.EventSkipped, autopkgtestclient.EventAlreadyRunning:
+						outcome.Running = true
+					}
+					outcomes = append(outcomes, outcome)
+				},
+			}
+
+			_, err := triggerer.TriggerURLs(ctx, urls, opts)
+			var triggerErr *autopkgtestclient.TriggerError
+			if !errors.As(err, &triggerErr) {
+				if err != nil {
+					return outcomes, err
+				}
+				break
+			}
+			outcomes = append(outcomes, batchOutcome{
+				Package: item.Package,
+				Release: item.Release,
+				Arch:    extractArchFromURL(triggerErr.URL),
+				Err:     triggerErr.Err,
+			})
+			if errors.Is(err, autopkgtestclient.ErrAuthRequired) {
+				return outcomes, triggerErr.Err
+			}
+			urls = urls[failed+1:]
+		}
+	}
+	return outcomes, nil
+}
+
+// printBatchSummary writes a table of the batch outcomes followed by the
+// number of tests triggered, already running and failed
+func printBatchSummary(w io.Writer, outcomes []batchOutcome) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PACKAGE\tRELEASE\tARCH\tRESULT\tDETAILS")
+
+	running, failed := 0, 0
+	for _, o := range outcomes {
+		switch {
+		case o.Err != nil:
+			failed++
+			fmt.Fprintf(tw, "%s\t%s\t%s\tFAILED\t%v\n", o.Package, o.Release, o.Arch, o.Err)
+		case o.Running:
+			running++
+			details := o.Result.UUID
+			if details == "" {
+				details = "UUID not found, see " + o.Result.HistoryURL
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\talready running\t%s\n", o.Package, o.Release, o.Arch, details)
+		case o.Result.UUID != "":
+			fmt.Fprintf(tw, "%s\t%s\t%s\ttriggered\t%s\n", o.Package, o.Release, o.Arch, o.Result.UUID)
+		default:
+			fmt.Fprintf(tw, "%s\t%s\t%s\ttriggered\t%s\n", o.Package, o.Release, o.Arch, o.Result.ResultURL)
+		}
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%d triggered, %d already running, %d failed\n", len(outcomes)-running-failed, running, failed)
+}
+
+// handleTriggerBatch triggers every request of a -batch file and prints a
+// summary. It exits non-zero if any request failed.
+func handleTriggerBatch(path string, opts triggerOptions) {
+	var out io.Writer = os.Stdout
+	if opts.Quiet {
+		out = io.Discard
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	items, err := parseBatchFile(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading batch file %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	if len(items) == 0 {
+		fmt.Fprintf(os.Stderr, "No requests found in batch file %s\n", path)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(out, "=== Autopkgtest Batch Trigger (%d requests) ===\n\n", len(items))
+	client := newTriggerClient(opts.Credentials, opts.SessionFile, out)
+
+	base := triggerlinkgenerator.LinkRequest{
+		Triggers:        opts.Triggers,
+		PPAs:            opts.PPAs,
+		ReadableBy:      opts.ReadableBy,
+		AllProposed:     opts.AllProposed,
+		AllowAnyTrigger: opts.AllowAnyTrigger,
+	}
+	gen := newGenerator()
+	outcomes, err := runBatch(context.Background(), gen, autopkgtestclient.NewTriggerer(client, gen), items, base, opts.SkipRunning, out)
+	saveSession(client, opts.SessionFile)
+
+	if opts.Quiet {
+		var results []*autopkgtestclient.TriggerResult
+		for _, o := range outcomes {
+			if o.Err == nil && (!o.Running || o.Result.UUID != "") {
+				results = append(results, o.Result)
+			}
+		}
+		printQuietResults(os.Stdout, results)
+	} else {
 		fmt.Println()
-		fmt.Println("Tip: Use --wait flag to monitor test completion automatically.")
+		printBatchSummary(os.Stdout, outcomes)
 	}
+
+	if errors.Is(err, autopkgtestclient.ErrAuthRequired) {
+		fmt.Fprintf(os.Stderr, "\nAuthentication required; the remaining requests were not submitted.\n")
+		fmt.Fprintf(os.Stderr, "Log in at %s/login and retry with -credentials <cookie-file>.\n", serverURL())
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\nError: %v\n", err)
+		os.Exit(1)
+	}
+	for _, o := range outcomes {
+		if o.Err != nil {
+			os.Exit(1)
+		}
+	}
+}
+
+// loadIndexCache makes the trigger link validators use the suites and
+// architectures saved by refresh-index, if any
+func loadIndexCache() {
+	path, err := triggerlinkgenerator.DefaultIndexPath()
+	if err != nil {
+		return
+	}
+	idx, err := triggerlinkgenerator.LoadIndex(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring index cache: %v\n", err)
+		}
+		return
+	}
+	idx.Apply()
+}
+
+// parseArchs returns the comma-separated architectures of an -arch flag, or
+// with -arch-all those supported on suite. -arch wins over -arch-all, with a
+// warning.
+func parseArchs(arch string, archAll bool, suite string) []string {
+	if arch == "" {
+		if archAll {
+			return triggerlinkgenerator.ArchesForSuite(suite)
+		}
+		return nil
+	}
+	if archAll {
+		fmt.Fprintln(os.Stderr, "Warning: -arch-all is ignored since -arch is given")
+	}
+	archs := strings.Split(arch, ",")
+	for i := range archs {
+		archs[i] = strings.TrimSpace(archs[i])
+	}
+	return archs
+}
+
+// handleRefreshIndex rebuilds the suite/architecture cache from the results
+// matrix of packageName
+func handleRefreshIndex(packageName string) {
+	path, err := triggerlinkgenerator.DefaultIndexPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	idx, err := refreshIndex(newScraper(), packageName, path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error refreshing index: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Suites: %s\n", strings.Join(idx.Suites, ", "))
+	fmt.Printf("Architectures: %s\n", strings.Join(idx.Arches, ", "))
+	fmt.Printf("Index saved to %s\n", path)
+}
+
+// refreshIndex fetches the results matrix of packageName, saves the suites
+// and architectures it lists to path and applies them to the validators
+func refreshIndex(s *scraper.Scraper, packageName, path string) (*triggerlinkgenerator.Index, error) {
+	results, err := s.FetchPackageResults(packageName)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Releases) == 0 || len(results.Architectures) == 0 {
+		return nil, fmt.Errorf("no results found for package %s", packageName)
+	}
+
+	idx := &triggerlinkgenerator.Index{
+		Suites:  slices.Sorted(slices.Values(results.Releases)),
+		Arches:  slices.Sorted(slices.Values(results.Architectures)),
+		Updated: time.Now().UTC(),
+	}
+	if err := idx.Save(path); err != nil {
+		return nil, err
+	}
+	idx.Apply()
+	return idx, nil
+}
+
+// newTriggerClient creates the autopkgtest client used to trigger tests,
+// authenticated with the explicit credentials, if any, or else the cookies
+// saved in sessionFile, if any, or else those found by loadCookies, and
+// configured with opts. It exits if the credentials given are empty or the
+// client cannot be created.
+func newTriggerClient(credentials, sessionFile string, out io.Writer, opts ...autopkgtestclient.ClientOption) *autopkgtestclient.Client {
+	clientOpts := opts
+
+	// A saved session is the most recent one, so it wins over the cookies
+	// from the environment, but not over -credentials: that is how to log
+	// in again once the saved session has expired
+	if sessionFile != "" && credentials == "" {
+		cookies, err := autopkgtestclient.LoadCookies(sessionFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring session file: %v\n", err)
+		} else if len(cookies) > 0 {
+			fmt.Fprintf(out, "Loaded session cookie from session file: %s\n\n", sessionFile)
+			clientOpts = append(clientOpts, autopkgtestclient.WithCookies(cookies))
+			return createTriggerClient(clientOpts)
+		}
+	}
+
+	// Try to load cookies from multiple sources (in priority order)
+	cookies, source, err := loadCookies(credentials)
+	if errors.Is(err, ErrEmptyCredentials) {
+		// The user explicitly asked to authenticate, so don't fall back to
+		// an unauthenticated request
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to load cookies: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Will attempt to trigger without authentication (may fail)\n\n")
+	} else if len(cookies) > 0 {
+		fmt.Fprintf(out, "Loaded session cookie from %s\n\n", source)
+		clientOpts = append(clientOpts, autopkgtestclient.WithCookies(cookies))
+	}
+
+	return createTriggerClient(clientOpts)
+}
+
+// createTriggerClient creates a client with opts, exiting on failure
+func createTriggerClient(opts []autopkgtestclient.ClientOption) *autopkgtestclient.Client {
+	client, err := autopkgtestclient.NewClient(append([]autopkgtestclient.ClientOption{autopkgtestclient.WithBaseURL(serverURL())}, opts...)...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating client: %v\n", err)
+		os.Exit(1)
+	}
+	return client
+}
+
+// saveSession saves the session cookies of client to sessionFile, if set,
+// so that the next run picks up a session the server renewed. Failing to
+// save it is only a warning: the tests were triggered regardless.
+func saveSession(client *autopkgtestclient.Client, sessionFile string) {
+	if sessionFile == "" {
+		return
+	}
+	if err := client.SaveCookies(sessionFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save session: %v\n", err)
+	}
+}
+
+// printPartialResult writes the fields TriggerTest could extract from a
+// submission confirmation it failed to fully parse
+func printPartialResult(w io.Writer, result *autopkgtestclient.TriggerResult) {
+	fmt.Fprintln(w, "The request was submitted, but its response could not be fully parsed. Fields found:")
+	for _, field := range []struct{ name, value string }{
+		{"Package", result.Package},
+		{"Release", result.Release},
+		{"Arch", result.Arch},
+		{"Triggers", result.Triggers},
+		{"Requester", result.Requester},
+		{"Results", result.ResultURL},
+		{"History", result.HistoryURL},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(w, "\t%-10s%s\n", field.name+":", field.value)
+		}
+	}
+}
+
+// writeTriggerScript writes a shell script to path that replays the test
+// requests for urls with curl. The session cookie is read from
+// AUTOPKGTEST_COOKIE when the script runs rather than written to the file.
+func writeTriggerScript(path string, urls []string) error {
+	var script strings.Builder
+	script.WriteString("#!/bin/sh\n")
+	script.WriteString(fmt.Sprintf("# Generated by autopkgtest-cli %s on %s\n", version, time.Now().UTC().Format(time.RFC3339)))
+	script.WriteString("# Replays the autopkgtest requests below. Requires AUTOPKGTEST_COOKIE to\n")
+	host, _ := serverHost()
+	script.WriteString(fmt.Sprintf("# hold a valid %s session cookie.\n", host))
+	script.WriteString("set -e\n")
+	script.WriteString(": \"${AUTOPKGTEST_COOKIE:?set AUTOPKGTEST_COOKIE to your session cookie}\"\n\n")
+	for _, u := range urls {
+		script.WriteString(curlCommand(u) + "\n")
+	}
+
+	if err := os.WriteFile(path, []byte(script.String()), 0755); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
 }
 
+// curlCommand formats a curl invocation that submits triggerURL with the
+// session cookie taken from AUTOPKGTEST_COOKIE
+func curlCommand(triggerURL string) string {
+	return fmt.Sprintf("curl --fail --silent --show-error --location --cookie \"session=$AUTOPKGTEST_COOKIE\" %s", shellQuote(triggerURL))
+}
+
+// shellQuote quotes s for use as a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ErrEmptyCredentials is returned when the credentials file (or stdin) given
+// via -credentials contains no cookie value
+var ErrEmptyCredentials = errors.New("credentials file is empty")
+
 // loadCookies loads cookie from multiple sources in priority order:
 // 1. File specified via -credentials flag (supports "-" for stdin)
-// 2. AUTOPKGTEST_COOKIE environment variable
+// 2. File specified via AUTOPKGTEST_COOKIES environment variable
+// 3. AUTOPKGTEST_COOKIE environment variable
 // Returns cookies, source description, and error
 func loadCookies(credentialsPath string) ([]*http.Cookie, string, error) {
-	var cookieValue string
-	var source string
-
 	// Priority 1: -credentials flag (file path or "-" for stdin)
 	if credentialsPath != "" {
+		cookies, err := loadCookiesFromFile(credentialsPath)
+		if err != nil {
+			return nil, "", err
+		}
+		source := fmt.Sprintf("file: %s", credentialsPath)
 		if credentialsPath == "-" {
-			// Read from stdin
-			data, err := io.ReadAll(os.Stdin)
-			if err != nil {
-				return nil, "", fmt.Errorf("failed to read from stdin: %w", err)
-			}
-			cookieValue = strings.TrimSpace(string(data))
 			source = "stdin"
-		} else {
-			// Read from file
-			data, err := os.ReadFile(credentialsPath)
-			if err != nil {
-				return nil, "", fmt.Errorf("failed to read file %s: %w", credentialsPath, err)
-			}
-			cookieValue = strings.TrimSpace(string(data))
-			source = fmt.Sprintf("file: %s", credentialsPath)
 		}
-	} else {
-		// Priority 2: Environment variable
-		envCookie := os.Getenv("AUTOPKGTEST_COOKIE")
-		if envCookie != "" {
-			cookieValue = strings.TrimSpace(envCookie)
-			source = "AUTOPKGTEST_COOKIE environment variable"
+		return cookies, source, nil
+	}
+
+	// Priority 2: Credentials file named by the environment
+	if envPath := strings.TrimSpace(os.Getenv("AUTOPKGTEST_COOKIES")); envPath != "" {
+		cookies, err := loadCookiesFromFile(envPath)
+		if err != nil {
+			return nil, "", fmt.Errorf("AUTOPKGTEST_COOKIES: %w", err)
 		}
+		return cookies, fmt.Sprintf("file: %s (AUTOPKGTEST_COOKIES)", envPath), nil
 	}
 
-	if cookieValue == "" {
-		return nil, "", fmt.Errorf("no cookie found (checked: -credentials flag, AUTOPKGTEST_COOKIE env var)")
+	// Priority 3: Environment variable
+	if envCookie := strings.TrimSpace(os.Getenv("AUTOPKGTEST_COOKIE")); envCookie != "" {
+		return []*http.Cookie{sessionCookie(envCookie)}, "AUTOPKGTEST_COOKIE environment variable", nil
 	}
 
-	// Create session cookie
-	cookie := &http.Cookie{
+	return nil, "", fmt.Errorf("no cookie found (checked: -credentials flag, AUTOPKGTEST_COOKIES and AUTOPKGTEST_COOKIE env vars)")
+}
+
+// sessionCookie creates the session cookie of serverHost
+func sessionCookie(value string) *http.Cookie {
+	host, secure := serverHost()
+	return &http.Cookie{
 		Name:     "session",
-		Value:    cookieValue,
-		Domain:   "autopkgtest.ubuntu.com",
+		Value:    value,
+		Domain:   host,
 		Path:     "/",
-		Secure:   true,
+		Secure:   secure,
 		HttpOnly: true,
 	}
+}
 
-	return []*http.Cookie{cookie}, source, nil
+// loadCookiesFromFile reads cookies from a file, or from stdin when path is
+// "-". The input is either a Netscape cookies.txt export, from which every
+// cookie for serverHost is used, or a bare session cookie value.
+// An empty or whitespace-only input returns ErrEmptyCredentials.
+func loadCookiesFromFile(path string) ([]*http.Cookie, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from stdin: %w", err)
+		}
+	} else {
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", path, err)
+		}
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return nil, fmt.Errorf("%w: %s", ErrEmptyCredentials, path)
+	}
+
+	if isNetscapeCookies(value) {
+		cookies := parseNetscapeCookies(value, time.Now())
+		if len(cookies) == 0 {
+			host, _ := serverHost()
+			return nil, fmt.Errorf("no unexpired %s cookies found in %s", host, path)
+		}
+		return cookies, nil
+	}
+
+	return []*http.Cookie{sessionCookie(value)}, nil
+}
+
+// isNetscapeCookies reports whether data looks like a Netscape cookies.txt
+// file: it has the usual header, or a line of seven tab-separated fields
+func isNetscapeCookies(data string) bool {
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "# Netscape HTTP Cookie File") || strings.HasPrefix(line, "# HTTP Cookie File") {
+			return true
+		}
+		if len(strings.Split(line, "\t")) == 7 {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNetscapeCookies returns the cookies in a Netscape cookies.txt file
+// that would be sent to serverHost and have not expired at now.
+// Each line holds: domain, include-subdomains flag, path, secure, expiry
+// (Unix time, 0 for session cookies), name and value.
+func parseNetscapeCookies(data string, now time.Time) []*http.Cookie {
+	host, _ := serverHost()
+	var cookies []*http.Cookie
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		httpOnly := false
+		if rest, ok := strings.CutPrefix(line, "#HttpOnly_"); ok {
+			line = rest
+			httpOnly = true
+		} else if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			continue
+		}
+
+		domain := strings.TrimPrefix(fields[0], ".")
+		if domain != host && !strings.HasSuffix(host, "."+domain) {
+			continue
+		}
+
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			continue
+		}
+		if expiry != 0 && time.Unix(expiry, 0).Before(now) {
+			continue
+		}
+
+		cookie := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Domain:   host,
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if expiry != 0 {
+			cookie.Expires = time.Unix(expiry, 0)
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies
 }
 
-// extractArchFromURL extracts architecture from trigger URL
+// extractArchFromURL extracts architecture from trigger URL, returning
+// triggerlinkgenerator.AllArchitectures when it has none
 func extractArchFromURL(url string) string {
 	if strings.Contains(url, "arch=") {
 		parts := strings.Split(url, "arch=")
 		if len(parts) > 1 {
-			arch := strings.Split(parts[1], "&")[0]
-			return arch
+			if arch := strings.Split(parts[1], "&")[0]; arch != "" {
+				return arch
+			}
 		}
 	}
-	return "all"
+	return triggerlinkgenerator.AllArchitectures
 }
@@ -0,0 +1,1259 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+	autopkgtestclient "github.com/canonical/autopkgtest-automation/internal/autopkgtest-client"
+	"github.com/canonical/autopkgtest-automation/internal/scraper"
+	triggerlinkgenerator "github.com/canonical/autopkgtest-automation/internal/trigger-link-generator"
+)
+
+// runMainEnv is set when the test binary is re-executed to run main()
+const runMainEnv = "AUTOPKGTEST_CLI_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCLI re-executes the test binary as the CLI with args and returns its
+// stdout, stderr and exit code
+func runCLI(t *testing.T, args ...string) (string, string, int) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), runMainEnv+"=1")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	code := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatalf("Failed to run CLI: %v", err)
+	}
+	return stdout.String(), stderr.String(), code
+}
+
+func TestCLIHelp(t *testing.T) {
+	for _, arg := range []string{"help", "-h", "--help"} {
+		t.Run(arg, func(t *testing.T) {
+			stdout, stderr, code := runCLI(t, arg)
+
+			if code != 0 {
+				t.Errorf("Expected exit code 0, got %d", code)
+			}
+			if !strings.Contains(stdout, "Usage:") {
+				t.Errorf("Expected usage on stdout, got: %q", stdout)
+			}
+			if stderr != "" {
+				t.Errorf("Expected empty stderr, got: %q", stderr)
+			}
+		})
+	}
+}
+
+func TestCLIUsageErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantStderr string
+	}{
+		{name: "no command", args: nil, wantStderr: "Usage:"},
+		{name: "unknown command", args: []string{"bogus"}, wantStderr: "Unknown command: bogus"},
+		{name: "check without package", args: []string{"check"}, wantStderr: "-package flag is required"},
+		{name: "check with unknown format", args: []string{"check", "-package", "ovn", "-format", "xml"}, wantStderr: "unknown -format"},
+		{name: "check with unknown color", args: []string{"check", "-package", "ovn", "-color", "rainbow"}, wantStderr: "unknown -color"},
+		{name: "check with package and packages", args: []string{"check", "-package", "ovn", "-packages", "ovn,systemd"}, wantStderr: "only one of -package, -packages and -package-file"},
+		{name: "check with packages and package-file", args: []string{"check", "-packages", "ovn,systemd", "-package-file", "packages.txt"}, wantStderr: "only one of -package, -packages and -package-file"},
+		{name: "check packages with from", args: []string{"check", "-packages", "ovn,systemd", "-from", "ovn.json"}, wantStderr: "-save and -from cannot be used with -packages or -package-file"},
+		{name: "check from with failing-releases", args: []string{"check", "-from", "ovn.json", "-failing-releases"}, wantStderr: "-save and -from cannot be used with -watch-until-pass or -failing-releases"},
+		{name: "check from with retry-tmpfail", args: []string{"check", "-from", "ovn.json", "-retry-tmpfail"}, wantStderr: "-retry-tmpfail cannot be used with -packages, -package-file, -from"},
+		{name: "check with unknown fail-on status", args: []string{"check", "-package", "ovn", "-fail-on", "fail,fial"}, wantStderr: `unknown -fail-on status "fial"`},
+		{name: "check retry-delay without retry-tmpfail", args: []string{"check", "-package", "ovn", "-retry-delay", "1m"}, wantStderr: "-retry-delay requires -retry-tmpfail"},
+		{name: "check with negative retry-count", args: []string{"check", "-package", "ovn", "-retry-tmpfail", "-retry-count", "-1"}, wantStderr: "-retry-count cannot be negative"},
+		{name: "check with empty packages", args: []string{"check", "-packages", " , ,"}, wantStderr: "-packages lists no package"},
+		{name: "check json with packages", args: []string{"check", "-packages", "ovn,systemd", "-format", "json"}, wantStderr: "cannot be used with -packages"},
+		{name: "check verbose with packages", args: []string{"check", "-packages", "ovn,systemd", "-verbose"}, wantStderr: "cannot be used with -packages"},
+		{name: "generate-trigger-link without suite", args: []string{"generate-trigger-link", "-package", "ovn"}, wantStderr: "-suite flag is required"},
+		{name: "generate-trigger-link with unknown format", args: []string{"generate-trigger-link", "-package", "ovn", "-suite", "noble", "-format", "json"}, wantStderr: "unknown -format"},
+		{name: "generate-trigger-link discover-arch with arch", args: []string{"generate-trigger-link", "-package", "ovn", "-suite", "noble", "-arch", "amd64", "-discover-arch"}, wantStderr: "-discover-arch and -arch cannot be used together"},
+		{name: "generate-trigger-link discover-arch with arch-all", args: []string{"generate-trigger-link", "-package", "ovn", "-suite", "noble", "-arch-all", "-discover-arch"}, wantStderr: "-discover-arch and -arch-all cannot be used together"},
+		{name: "trigger batch with arch-all", args: []string{"trigger", "-batch", "requests.txt", "-arch-all"}, wantStderr: "-batch cannot be combined with -package, -suite, -arch, -arch-all or -version"},
+		{name: "generate-trigger-link yaml with packages", args: []string{"generate-trigger-link", "-packages", "ovn,openvswitch", "-suite", "noble", "-format", "yaml"}, wantStderr: "cannot be used with -packages"},
+		{name: "trigger without package", args: []string{"trigger", "-suite", "noble"}, wantStderr: "-package flag is required"},
+		{name: "trigger with unknown format", args: []string{"trigger", "-package", "ovn", "-suite", "noble", "-format", "yaml"}, wantStderr: "unknown -format"},
+		{name: "trigger with unknown color", args: []string{"trigger", "-package", "ovn", "-suite", "noble", "-color", "rainbow"}, wantStderr: "unknown -color"},
+		{name: "trigger retry-tmpfail without wait", args: []string{"trigger", "-package", "ovn", "-suite", "noble", "-retry-tmpfail", "2"}, wantStderr: "-retry-tmpfail requires -wait"},
+		{name: "trigger wait with zero timeout", args: []string{"trigger", "-package", "ovn", "-suite", "noble", "-wait", "-timeout", "0"}, wantStderr: "-timeout must be positive"},
+		{name: "trigger webhook without wait", args: []string{"trigger", "-package", "ovn", "-suite", "noble", "-webhook", "http://localhost/hook"}, wantStderr: "-webhook requires -wait"},
+		{name: "trigger json with quiet", args: []string{"trigger", "-package", "ovn", "-suite", "noble", "-format", "json", "-quiet"}, wantStderr: "cannot be combined with -quiet or -batch"},
+		{name: "undefined flag", args: []string{"check", "-bogus"}, wantStderr: "flag provided but not defined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, code := runCLI(t, tt.args...)
+
+			if code != exitUsage {
+				t.Errorf("Expected exit code %d, got %d", exitUsage, code)
+			}
+			if stdout != "" {
+				t.Errorf("Expected empty stdout, got: %q", stdout)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("Expected stderr to contain %q, got: %q", tt.wantStderr, stderr)
+			}
+		})
+	}
+}
+
+func TestCLIInvalidTrigger(t *testing.T) {
+	_, stderr, code := runCLI(t, "generate-trigger-link", "-package", "ovn", "-suite", "noble", "-trigger", "systemd259-1")
+	if code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr, `invalid trigger "systemd259-1"`) {
+		t.Errorf("Expected invalid trigger error, got: %q", stderr)
+	}
+
+	stdout, _, code := runCLI(t, "generate-trigger-link", "-package", "ovn", "-suite", "noble", "-trigger", "systemd259-1", "-allow-any-trigger")
+	if code != 0 {
+		t.Errorf("Expected exit code 0 with -allow-any-trigger, got %d", code)
+	}
+	if !strings.Contains(stdout, "trigger=systemd259-1") {
+		t.Errorf("Expected trigger URL on stdout, got: %q", stdout)
+	}
+}
+
+func TestCLIResultsOnStdout(t *testing.T) {
+	stdout, stderr, code := runCLI(t, "generate-trigger-link", "-package", "ovn", "-suite", "noble")
+
+	if code != 0 {
+		t.Errorf("Expected exit code 0, got %d", code)
+	}
+	if !strings.Contains(stdout, "request.cgi?") {
+		t.Errorf("Expected trigger URL on stdout, got: %q", stdout)
+	}
+	if stderr != "" {
+		t.Errorf("Expected empty stderr, got: %q", stderr)
+	}
+}
+
+func TestCLIArchAll(t *testing.T) {
+	stdout, stderr, code := runCLI(t, "generate-trigger-link", "-package", "ovn", "-suite", "jammy", "-arch-all")
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	for _, arch := range triggerlinkgenerator.ArchesForSuite("jammy") {
+		if !strings.Contains(stdout, "arch="+arch+"&") {
+			t.Errorf("Expected a URL for %s, got: %q", arch, stdout)
+		}
+	}
+
+	// -arch wins, with a warning
+	stdout, stderr, code = runCLI(t, "generate-trigger-link", "-package", "ovn", "-suite", "jammy", "-arch-all", "-arch", "amd64")
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if strings.Count(stdout, "request.cgi?") != 1 || !strings.Contains(stdout, "arch=amd64") {
+		t.Errorf("Expected only the amd64 URL, got: %q", stdout)
+	}
+	if !strings.Contains(stderr, "Warning: -arch-all is ignored since -arch is given") {
+		t.Errorf("Expected a warning on stderr, got: %q", stderr)
+	}
+}
+
+func TestCLICheckFromSaved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ovn.json")
+	results := &scraper.PackageResults{
+		Package: "ovn",
+		Tests: []scraper.TestResult{
+			{Package: "ovn", Release: "noble", Architecture: "amd64", Status: "regression"},
+			{Package: "ovn", Release: "jammy", Architecture: "amd64", Status: "pass"},
+		},
+		Releases:      []string{"noble", "jammy"},
+		Architectures: []string{"amd64"},
+	}
+	if err := scraper.SaveResults(results, path); err != nil {
+		t.Fatalf("SaveResults failed: %v", err)
+	}
+
+	// Filters apply to the saved results, which are read without a -package
+	stdout, stderr, code := runCLI(t, "check", "-from", path, "-release", "jammy", "-format", "json")
+	if code != 0 {
+		t.Fatalf("Expected exit code 0 with only the passing release, got %d: %s", code, stderr)
+	}
+	var loaded scraper.PackageResults
+	if err := json.Unmarshal([]byte(stdout), &loaded); err != nil {
+		t.Fatalf("Expected JSON on stdout, got %q: %v", stdout, err)
+	}
+	if loaded.Package != "ovn" || len(loaded.Tests) != 1 || len(loaded.Errors) != 0 {
+		t.Errorf("Expected the jammy pass only, got %+v", loaded)
+	}
+
+	_, stderr, code = runCLI(t, "check", "-from", path, "-package", "systemd")
+	if code != 1 || !strings.Contains(stderr, "holds results for package ovn, not systemd") {
+		t.Errorf("Expected a package mismatch error, got %d: %q", code, stderr)
+	}
+}
+
+func TestCLICheckMarkdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ovn.json")
+	results := &scraper.PackageResults{
+		Package: "ovn",
+		Tests: []scraper.TestResult{
+			{Package: "ovn", Release: "noble", Architecture: "amd64", Status: "regression"},
+			{Package: "ovn", Release: "noble", Architecture: "arm64", Status: "pass"},
+		},
+	}
+	if err := scraper.SaveResults(results, path); err != nil {
+		t.Fatalf("SaveResults failed: %v", err)
+	}
+
+	stdout, _, code := runCLI(t, "check", "-from", path, "-format", "markdown")
+	if code != 1 {
+		t.Errorf("Expected exit code 1 for a regression, got %d", code)
+	}
+	if !strings.HasPrefix(stdout, "**autopkgtest results for ovn**") {
+		t.Errorf("Expected only Markdown on stdout, got: %q", stdout)
+	}
+	if !strings.Contains(stdout, "| amd64 | **regression** |") || !strings.Contains(stdout, "| arm64 | pass |") {
+		t.Errorf("Expected one row per architecture, got: %q", stdout)
+	}
+
+	stdout, _, _ = runCLI(t, "check", "-from", path, "-format", "markdown", "-arch-order", "arm64")
+	if !strings.HasSuffix(stdout, "| arm64 | pass |\n| amd64 | **regression** |\n") {
+		t.Errorf("Expected the rows to follow -arch-order, got: %q", stdout)
+	}
+}
+
+func TestCheckStrictLayoutChange(t *testing.T) {
+	// A results matrix whose layout changed: a row lost its architecture
+	// header, a row has more cells than releases and a status is unknown
+	page := `<html><body><table>
+  <tr><th></th><th>noble</th><th>jammy</th></tr>
+  <tr><th>amd64</th><td class="pass">pass</td><td class="pass">pass</td></tr>
+  <tr><td></td><td class="pass">pass</td><td class="pass">pass</td></tr>
+  <tr><th>arm64</th><td class="pass">pass</td><td class="pass">pass</td><td class="pass">pass</td></tr>
+  <tr><th>s390x</th><td class="pass">pass</td><td>✔ succeeded</td></tr>
+</table></body></html>`
+	results, err := scraper.NewScraper(scraper.WithFailOn("fail")).ParseHTML(page, "ovn", nil)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+
+	// The warnings alone do not fail without -strict
+	if err := checkStrict(results, false); err != nil {
+		t.Errorf("Expected warnings to be ignored without -strict, got: %v", err)
+	}
+	if err := checkStrict(results, true); err == nil {
+		t.Error("Expected the layout change to fail in strict mode")
+	}
+	warnings := strings.Join(results.Warnings, "\n")
+	for _, want := range []string{"without an architecture header", "arm64 row has 3 result cells for 2 releases", `unknown status "✔ succeeded" for jammy/s390x`} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("Expected a warning containing %q, got:\n%s", want, warnings)
+		}
+	}
+}
+
+func TestCheckStrictNoResultsTable(t *testing.T) {
+	page := `<html><body><div class="results">noble amd64 pass</div></body></html>`
+	results, err := scraper.NewScraper().ParseHTML(page, "ovn", nil)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+	if err := checkStrict(results, true); err == nil {
+		t.Errorf("Expected a page without the results table to fail in strict mode, got warnings %q", results.Warnings)
+	}
+}
+
+func TestLoadCookiesFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookie")
+	if err := os.WriteFile(path, []byte("  test-session-id\n"), 0600); err != nil {
+		t.Fatalf("Failed to write cookie file: %v", err)
+	}
+
+	cookies, err := loadCookiesFromFile(path)
+	if err != nil {
+		t.Fatalf("loadCookiesFromFile() failed: %v", err)
+	}
+
+	if len(cookies) != 1 {
+		t.Fatalf("Expected 1 cookie, got %d", len(cookies))
+	}
+	if cookies[0].Name != "session" || cookies[0].Value != "test-session-id" {
+		t.Errorf("Expected session cookie 'test-session-id', got %s=%q", cookies[0].Name, cookies[0].Value)
+	}
+}
+
+func TestLoadCookiesFromFile_Netscape(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour).Unix()
+	past := time.Now().Add(-24 * time.Hour).Unix()
+	content := "# Netscape HTTP Cookie File\n" +
+		"# This is a generated file! Do not edit.\n\n" +
+		fmt.Sprintf("#HttpOnly_autopkgtest.ubuntu.com\tFALSE\t/\tTRUE\t%d\tsession\tabc123\n", future) +
+		"autopkgtest.ubuntu.com\tFALSE\t/\tTRUE\t0\tcsrftoken\txyz\n" +
+		fmt.Sprintf(".ubuntu.com\tTRUE\t/\tFALSE\t%d\tlang\ten\n", future) +
+		fmt.Sprintf("autopkgtest.ubuntu.com\tFALSE\t/\tTRUE\t%d\told\texpired\n", past) +
+		fmt.Sprintf(".launchpad.net\tTRUE\t/\tTRUE\t%d\tlp\tother\n", future)
+
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write cookie file: %v", err)
+	}
+
+	cookies, err := loadCookiesFromFile(path)
+	if err != nil {
+		t.Fatalf("loadCookiesFromFile() failed: %v", err)
+	}
+
+	got := map[string]*http.Cookie{}
+	for _, c := range cookies {
+		got[c.Name] = c
+	}
+	if len(got) != 3 {
+		t.Errorf("Expected session, csrftoken and lang cookies, got %v", cookies)
+	}
+	if c := got["session"]; c == nil || c.Value != "abc123" || !c.HttpOnly || !c.Secure {
+		t.Errorf("Expected HttpOnly, secure session cookie 'abc123', got %+v", c)
+	}
+	if _, ok := got["old"]; ok {
+		t.Error("Expected expired cookie to be skipped")
+	}
+	if _, ok := got["lp"]; ok {
+		t.Error("Expected cookie for another domain to be skipped")
+	}
+}
+
+func TestLoadCookiesFromFile_NetscapeNoMatch(t *testing.T) {
+	content := "# Netscape HTTP Cookie File\n.launchpad.net\tTRUE\t/\tTRUE\t0\tlp\tother\n"
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write cookie file: %v", err)
+	}
+
+	if _, err := loadCookiesFromFile(path); err == nil {
+		t.Error("Expected error when cookies.txt has no autopkgtest.ubuntu.com cookies")
+	}
+}
+
+func TestLoadCookiesFromFile_Empty(t *testing.T) {
+	for name, content := range map[string]string{
+		"empty":      "",
+		"whitespace": " \n\t\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cookie")
+			if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatalf("Failed to write cookie file: %v", err)
+			}
+
+			_, err := loadCookiesFromFile(path)
+			if !errors.Is(err, ErrEmptyCredentials) {
+				t.Errorf("Expected ErrEmptyCredentials, got: %v", err)
+			}
+
+			// loadCookies must propagate the error rather than fall back
+			_, _, err = loadCookies(path)
+			if !errors.Is(err, ErrEmptyCredentials) {
+				t.Errorf("Expected loadCookies to return ErrEmptyCredentials, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadCookiesFromFile_Missing(t *testing.T) {
+	_, err := loadCookiesFromFile(filepath.Join(t.TempDir(), "missing"))
+	if err == nil {
+		t.Fatal("Expected error for missing file")
+	}
+
+	if errors.Is(err, ErrEmptyCredentials) {
+		t.Error("Missing file should not be reported as ErrEmptyCredentials")
+	}
+}
+
+func TestLoadCookies_Precedence(t *testing.T) {
+	dir := t.TempDir()
+	flagPath := filepath.Join(dir, "flag-cookie")
+	envPath := filepath.Join(dir, "env-cookie")
+	if err := os.WriteFile(flagPath, []byte("from-flag\n"), 0600); err != nil {
+		t.Fatalf("Failed to write cookie file: %v", err)
+	}
+	if err := os.WriteFile(envPath, []byte("from-env-file\n"), 0600); err != nil {
+		t.Fatalf("Failed to write cookie file: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		flag       string
+		cookiesEnv string
+		cookieEnv  string
+		want       string
+	}{
+		{"flag beats env", flagPath, envPath, "from-env-value", "from-flag"},
+		{"AUTOPKGTEST_COOKIES beats AUTOPKGTEST_COOKIE", "", envPath, "from-env-value", "from-env-file"},
+		{"AUTOPKGTEST_COOKIE as last resort", "", "", "from-env-value", "from-env-value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AUTOPKGTEST_COOKIES", tt.cookiesEnv)
+			t.Setenv("AUTOPKGTEST_COOKIE", tt.cookieEnv)
+
+			cookies, _, err := loadCookies(tt.flag)
+			if err != nil {
+				t.Fatalf("loadCookies() failed: %v", err)
+			}
+			if len(cookies) != 1 || cookies[0].Value != tt.want {
+				t.Errorf("Expected cookie %q, got %v", tt.want, cookies)
+			}
+		})
+	}
+}
+
+func TestLoadCookies_EnvPathMissing(t *testing.T) {
+	t.Setenv("AUTOPKGTEST_COOKIES", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("AUTOPKGTEST_COOKIE", "from-env-value")
+
+	// A broken AUTOPKGTEST_COOKIES is reported rather than silently skipped
+	_, _, err := loadCookies("")
+	if err == nil || !strings.Contains(err.Error(), "AUTOPKGTEST_COOKIES") {
+		t.Errorf("Expected AUTOPKGTEST_COOKIES error, got: %v", err)
+	}
+}
+
+func TestNewTriggerClient_SessionFile(t *testing.T) {
+	dir := t.TempDir()
+	sessionFile := filepath.Join(dir, "session.json")
+	t.Setenv("AUTOPKGTEST_COOKIES", "")
+	t.Setenv("AUTOPKGTEST_COOKIE", "from-env-value")
+
+	// Without a saved session, the usual sources are used and then saved
+	client := newTriggerClient("", sessionFile, io.Discard)
+	saveSession(client, sessionFile)
+	if _, err := os.Stat(sessionFile); err != nil {
+		t.Fatalf("Expected the session to be saved: %v", err)
+	}
+
+	// A saved session wins over the other sources
+	saved := []*http.Cookie{{Name: "session", Value: "renewed"}}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	if err := os.WriteFile(sessionFile, data, 0600); err != nil {
+		t.Fatalf("Failed to write session file: %v", err)
+	}
+	client = newTriggerClient("", sessionFile, io.Discard)
+	cookies := client.GetCookies()
+	if len(cookies) != 1 || cookies[0].Value != "renewed" {
+		t.Errorf("Expected the saved session cookie, got %v", cookies)
+	}
+}
+
+func TestNewTriggerClient_CredentialsOverrideExpiredSession(t *testing.T) {
+	dir := t.TempDir()
+	sessionFile := filepath.Join(dir, "session.json")
+	credentialsFile := filepath.Join(dir, "cookies")
+	t.Setenv("AUTOPKGTEST_COOKIES", "")
+	t.Setenv("AUTOPKGTEST_COOKIE", "")
+
+	data, err := json.Marshal([]*http.Cookie{sessionCookie("expired")})
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	if err := os.WriteFile(sessionFile, data, 0600); err != nil {
+		t.Fatalf("Failed to write session file: %v", err)
+	}
+	if err := os.WriteFile(credentialsFile, []byte("fresh\n"), 0600); err != nil {
+		t.Fatalf("Failed to write credentials file: %v", err)
+	}
+
+	// The expired session saved on a previous run must not hide the cookie
+	// the user just logged in with
+	client := newTriggerClient(credentialsFile, sessionFile, io.Discard)
+	cookies := client.GetCookies()
+	if len(cookies) != 1 || cookies[0].Value != "fresh" {
+		t.Fatalf("Expected the -credentials cookie, got %v", cookies)
+	}
+
+	// Its session then replaces the expired one for the next runs
+	saveSession(client, sessionFile)
+	client = newTriggerClient("", sessionFile, io.Discard)
+	cookies = client.GetCookies()
+	if len(cookies) != 1 || cookies[0].Value != "fresh" {
+		t.Errorf("Expected the new session to be saved, got %v", cookies)
+	}
+}
+
+func TestServerURL_AuthenticatesAgainstOtherServer(t *testing.T) {
+	// A staging server only accepts requests with its own session cookie
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "staging-session" {
+			w.Write([]byte("Please login to continue"))
+			return
+		}
+		w.Write([]byte("Test request submitted.\nUUID\n    aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa\n"))
+	}))
+	defer server.Close()
+	t.Setenv("AUTOPKGTEST_URL", server.URL+"/")
+	t.Setenv("AUTOPKGTEST_COOKIES", "")
+	t.Setenv("AUTOPKGTEST_COOKIE", "")
+
+	if got := serverURL(); got != server.URL {
+		t.Errorf("Expected serverURL() %q, got %q", server.URL, got)
+	}
+
+	host := strings.Split(strings.TrimPrefix(server.URL, "http://"), ":")[0]
+	dir := t.TempDir()
+	bare := filepath.Join(dir, "cookie")
+	netscape := filepath.Join(dir, "cookies.txt")
+	if err := os.WriteFile(bare, []byte("staging-session\n"), 0600); err != nil {
+		t.Fatalf("Failed to write cookie file: %v", err)
+	}
+	content := "# Netscape HTTP Cookie File\n" +
+		host + "\tFALSE\t/\tFALSE\t0\tsession\tstaging-session\n" +
+		"autopkgtest.ubuntu.com\tFALSE\t/\tTRUE\t0\tsession\tproduction-session\n"
+	if err := os.WriteFile(netscape, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write cookie file: %v", err)
+	}
+
+	for _, credentials := range []string{bare, netscape} {
+		client := newTriggerClient(credentials, "", io.Discard)
+		resp, err := newGenerator().GenerateLinks(&triggerlinkgenerator.LinkRequest{Package: "ovn", Suite: "noble", Architectures: []string{"amd64"}})
+		if err != nil {
+			t.Fatalf("GenerateLinks() failed: %v", err)
+		}
+		if !strings.HasPrefix(resp.URLs[0], server.URL+"/request.cgi?") {
+			t.Errorf("Expected the trigger URL on the configured server, got %s", resp.URLs[0])
+		}
+		if _, err := client.TriggerTest(resp.URLs[0]); err != nil {
+			t.Errorf("Expected the session cookie from %s to be sent to the configured server, got: %v", credentials, err)
+		}
+	}
+}
+
+func TestCheckStrict(t *testing.T) {
+	results := &scraper.PackageResults{
+		Package:  "ovn",
+		Warnings: []string{"architecture s390x has no results for package ovn"},
+	}
+
+	if err := checkStrict(results, false); err != nil {
+		t.Errorf("Expected warnings to be ignored without -strict, got: %v", err)
+	}
+
+	err := checkStrict(results, true)
+	if err == nil {
+		t.Fatal("Expected a warning to fail in strict mode")
+	}
+	if !strings.Contains(err.Error(), "1 warning(s)") {
+		t.Errorf("Expected error to report the warning count, got: %v", err)
+	}
+
+	results.Warnings = nil
+	if err := checkStrict(results, true); err != nil {
+		t.Errorf("Expected no error without warnings, got: %v", err)
+	}
+}
+
+const mockIndexMatrix = `
+<table class="table">
+  <tr><th></th><th>stonking</th><th>noble</th></tr>
+  <tr>
+    <th>riscv64</th>
+    <td class="pass"><a href="dpkg/stonking/riscv64">pass</a></td>
+    <td class="pass"><a href="dpkg/noble/riscv64">pass</a></td>
+  </tr>
+  <tr>
+    <th>amd64</th>
+    <td class="pass"><a href="dpkg/stonking/amd64">pass</a></td>
+    <td class="fail"><a href="dpkg/noble/amd64">fail</a></td>
+  </tr>
+</table>
+`
+
+func TestRefreshIndex(t *testing.T) {
+	origSuites, origArches := triggerlinkgenerator.SupportedSuites, triggerlinkgenerator.SupportedArches
+	defer func() {
+		triggerlinkgenerator.SupportedSuites, triggerlinkgenerator.SupportedArches = origSuites, origArches
+	}()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/packages/dpkg" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockIndexMatrix))
+	}))
+	defer server.Close()
+
+	s := scraper.NewScraper()
+	s.BaseURL = server.URL
+	path := filepath.Join(t.TempDir(), "index.json")
+
+	if _, err := refreshIndex(s, "dpkg", path); err != nil {
+		t.Fatalf("refreshIndex failed: %v", err)
+	}
+
+	cached, err := triggerlinkgenerator.LoadIndex(path)
+	if err != nil {
+		t.Fatalf("LoadIndex failed: %v", err)
+	}
+	if want := []string{"noble", "stonking"}; !slices.Equal(cached.Suites, want) {
+		t.Errorf("Expected cached suites %v, got %v", want, cached.Suites)
+	}
+	if want := []string{"amd64", "riscv64"}; !slices.Equal(cached.Arches, want) {
+		t.Errorf("Expected cached arches %v, got %v", want, cached.Arches)
+	}
+
+	// The validators now accept the newly opened suite
+	if !slices.Contains(triggerlinkgenerator.SupportedSuites, "stonking") {
+		t.Errorf("Expected SupportedSuites to be updated, got %v", triggerlinkgenerator.SupportedSuites)
+	}
+
+	if _, err := refreshIndex(s, "missing", path); err == nil {
+		t.Error("Expected error when the index package cannot be fetched")
+	}
+}
+
+func TestCLIGenerateTriggerLinkOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "links.txt")
+	if err := os.WriteFile(path, []byte("stale content that must go away\n"), 0644); err != nil {
+		t.Fatalf("Failed to seed output file: %v", err)
+	}
+
+	stdout, stderr, code := runCLI(t, "generate-trigger-link", "-package", "ovn", "-suite", "noble", "-arch", "amd64,arm64", "-output", path)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr: %s)", code, stderr)
+	}
+	if stdout != "" {
+		t.Errorf("Expected empty stdout, got: %q", stdout)
+	}
+	if !strings.Contains(stderr, "Wrote 2 trigger URL(s) to "+path) {
+		t.Errorf("Expected confirmation on stderr, got: %q", stderr)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d:\n%s", len(lines), data)
+	}
+	for i, arch := range []string{"amd64", "arm64"} {
+		if !strings.HasPrefix(lines[i], "https://") || !strings.Contains(lines[i], "arch="+arch) {
+			t.Errorf("Expected line %d to be the %s URL, got %q", i+1, arch, lines[i])
+		}
+	}
+}
+
+func TestWriteTriggerScript(t *testing.T) {
+	urls := []string{
+		"https://autopkgtest.ubuntu.com/request.cgi?arch=amd64&package=ovn&release=noble&trigger=ovn%2F25.09.0-3",
+		"https://autopkgtest.ubuntu.com/request.cgi?arch=arm64&package=ovn&release=noble&trigger=ovn%2F25.09.0-3",
+	}
+	path := filepath.Join(t.TempDir(), "replay.sh")
+
+	if err := writeTriggerScript(path, urls); err != nil {
+		t.Fatalf("writeTriggerScript failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read script: %v", err)
+	}
+	script := string(data)
+
+	if !strings.HasPrefix(script, "#!/bin/sh\n") {
+		t.Errorf("Expected script to start with a shebang, got:\n%s", script)
+	}
+	for _, u := range urls {
+		want := `--cookie "session=$AUTOPKGTEST_COOKIE" '` + u + `'`
+		if !strings.Contains(script, want) {
+			t.Errorf("Expected script to contain %q, got:\n%s", want, script)
+		}
+	}
+	if n := strings.Count(script, "\ncurl "); n != len(urls) {
+		t.Errorf("Expected %d curl invocations, got %d", len(urls), n)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if got, want := shellQuote("it's"), `'it'\''s'`; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestPrintQuietResults(t *testing.T) {
+	results := []*autopkgtestclient.TriggerResult{
+		{UUID: "12345678-1234-1234-1234-123456789abc", Package: "ovn", Release: "noble", Arch: "amd64"},
+		{Package: "ovn", Release: "noble", Arch: "arm64"},
+	}
+
+	var buf bytes.Buffer
+	printQuietResults(&buf, results)
+
+	want := "uuid=12345678-1234-1234-1234-123456789abc arch=amd64\nuuid= arch=arm64\n"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestParseBatchFile(t *testing.T) {
+	input := `# retrigger after kernel bump
+ovn noble amd64
+openvswitch jammy amd64,arm64 linux/6.8.0-50.51
+
+systemd noble
+dpkg noble all
+`
+
+	items, err := parseBatchFile(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseBatchFile failed: %v", err)
+	}
+
+	want := []batchItem{
+		{Line: 2, Package: "ovn", Release: "noble", Archs: []string{"amd64"}},
+		{Line: 3, Package: "openvswitch", Release: "jammy", Archs: []string{"amd64", "arm64"}, Triggers: []string{"linux/6.8.0-50.51"}},
+		{Line: 5, Package: "systemd", Release: "noble"},
+		{Line: 6, Package: "dpkg", Release: "noble"},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("Expected %d items, got %d: %+v", len(want), len(items), items)
+	}
+	for i := range want {
+		if fmt.Sprint(items[i]) != fmt.Sprint(want[i]) {
+			t.Errorf("Item %d: expected %+v, got %+v", i, want[i], items[i])
+		}
+	}
+}
+
+func TestParseBatchFile_Invalid(t *testing.T) {
+	_, err := parseBatchFile(strings.NewReader("ovn noble amd64\nsystemd\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected error for line 2, got: %v", err)
+	}
+}
+
+func TestParsePackageFile(t *testing.T) {
+	input := `# OpenStack networking
+ovn
+openvswitch  # also pulls in ovn
+
+  systemd
+ovn
+`
+
+	packages, err := parsePackageFile(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parsePackageFile failed: %v", err)
+	}
+
+	want := []string{"ovn", "openvswitch", "systemd"}
+	if !slices.Equal(packages, want) {
+		t.Errorf("Expected %v, got %v", want, packages)
+	}
+}
+
+func TestParsePackageFile_Invalid(t *testing.T) {
+	_, err := parsePackageFile(strings.NewReader("ovn\nsystemd noble\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected error for line 2, got: %v", err)
+	}
+}
+
+func TestIsTestFailure(t *testing.T) {
+	tests := []struct {
+		status autopkgtest.Status
+		want   bool
+	}{
+		{autopkgtest.StatusPass, false},
+		{autopkgtest.StatusNeutral, false},
+		{autopkgtest.StatusFail, true},
+		{autopkgtest.StatusRegression, true},
+		{autopkgtest.StatusTmpfail, true},
+		{autopkgtest.StatusUnknown, true},
+	}
+
+	for _, tt := range tests {
+		if got := isTestFailure(tt.status); got != tt.want {
+			t.Errorf("isTestFailure(%q): expected %v, got %v", tt.status, tt.want, got)
+		}
+	}
+}
+
+func TestCLITriggerWaitTmpfailExitCode(t *testing.T) {
+	// The test is submitted and ends in tmpfail
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/request.cgi":
+			w.Write([]byte("Test request submitted.\nUUID\n    aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa\npackage\n    ovn\nrelease\n    noble\narch\n    amd64\n"))
+		case strings.HasPrefix(r.URL.Path, "/run/"):
+			w.Write([]byte(`<table><tr><th>Result</th><td>tmpfail</td></tr></table>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("AUTOPKGTEST_URL", server.URL)
+	t.Setenv("AUTOPKGTEST_COOKIES", "")
+	t.Setenv("AUTOPKGTEST_COOKIE", "session-value")
+
+	stdout, stderr, code := runCLI(t, "trigger", "-package", "ovn", "-suite", "noble", "-arch", "amd64", "-wait", "-poll-interval", "10ms")
+	if code != exitTestFailure {
+		t.Errorf("Expected exit code %d, got %d (stderr: %q)", exitTestFailure, code, stderr)
+	}
+	if strings.Contains(stdout, "All tests completed successfully") {
+		t.Errorf("Expected no success message for a tmpfail, got:\n%s", stdout)
+	}
+}
+
+func TestPrintUsageCheckFormats(t *testing.T) {
+	var buf strings.Builder
+	printUsage(&buf)
+	if !strings.Contains(buf.String(), "[-format text|table|html|json|csv|tsv|markdown]") {
+		t.Errorf("Expected the check synopsis to list every -format, got:\n%s", buf.String())
+	}
+}
+
+func TestSplitPackages(t *testing.T) {
+	packages := splitPackages("ovn, systemd,,ovn, ,openvswitch,")
+	want := []string{"ovn", "systemd", "openvswitch"}
+	if !slices.Equal(packages, want) {
+		t.Errorf("Expected %v, got %v", want, packages)
+	}
+}
+
+func TestWriteTriggerJSON(t *testing.T) {
+	results := []*autopkgtestclient.TriggerResult{
+		{UUID: "uuid-amd64", Package: "ovn", Release: "noble", Arch: "amd64"},
+		{UUID: "uuid-arm64", Package: "ovn", Release: "noble", Arch: "arm64"},
+		{Package: "ovn", Release: "noble", Arch: "s390x", ResultURL: "https://autopkgtest.ubuntu.com/user/me/ppa/test"},
+	}
+	statuses := map[string]*autopkgtestclient.TestStatus{
+		"uuid-amd64": {UUID: "uuid-amd64", Status: "pass", Duration: "5m"},
+	}
+
+	var buf bytes.Buffer
+	if err := writeTriggerJSON(&buf, results, statuses); err != nil {
+		t.Fatalf("writeTriggerJSON failed: %v", err)
+	}
+
+	var decoded []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not a JSON array: %v\n%s", err, buf.String())
+	}
+	if len(decoded) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(decoded))
+	}
+	if decoded[0]["uuid"] != "uuid-amd64" || decoded[0]["arch"] != "amd64" {
+		t.Errorf("Expected flattened trigger result fields, got %v", decoded[0])
+	}
+	final, ok := decoded[0]["final_status"].(map[string]any)
+	if !ok || final["status"] != "pass" || final["duration"] != "5m" {
+		t.Errorf("Expected final status pass, got %v", decoded[0]["final_status"])
+	}
+	if _, ok := final["start_time"]; ok {
+		t.Errorf("Expected zero start time to be omitted, got %v", final)
+	}
+	for _, entry := range decoded[1:] {
+		if _, ok := entry["final_status"]; ok {
+			t.Errorf("Expected no final status for %v", entry)
+		}
+	}
+}
+
+func TestPrintPartialResult(t *testing.T) {
+	var buf strings.Builder
+	printPartialResult(&buf, &autopkgtestclient.TriggerResult{
+		Package: "ovn",
+		Release: "noble",
+		Arch:    "amd64",
+	})
+
+	expected := "The request was submitted, but its response could not be fully parsed. Fields found:\n" +
+		"\tPackage:  ovn\n" +
+		"\tRelease:  noble\n" +
+		"\tArch:     amd64\n"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestExtractArchFromURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://autopkgtest.ubuntu.com/request.cgi?arch=amd64&package=ovn&release=noble", want: "amd64"},
+		{url: "https://autopkgtest.ubuntu.com/request.cgi?package=ovn&release=noble", want: "all"},
+		{url: "https://autopkgtest.ubuntu.com/request.cgi?arch=&package=ovn&release=noble", want: "all"},
+	}
+
+	for _, tt := range tests {
+		if got := extractArchFromURL(tt.url); got != tt.want {
+			t.Errorf("extractArchFromURL(%q): expected %q, got %q", tt.url, tt.want, got)
+		}
+	}
+}
+
+const mockBatchRunningPage = `<html><body>
+<table>
+  <tr><th>Release:</th><td>noble</td></tr>
+  <tr><th>Architecture:</th><td>ppc64el</td></tr>
+  <tr><th>UUID:</th><td>11111111-1111-1111-1111-111111111111</td></tr>
+</table>
+</body></html>`
+
+// newBatchTriggerer returns a Triggerer and a generator for an httptest
+// server which accepts test requests with a UUID per architecture, except
+// that s390x is rejected and riscv64 asks for a login. A noble/ppc64el test
+// is running. The architectures submitted are recorded in requested.
+func newBatchTriggerer(t *testing.T) (*autopkgtestclient.Triggerer, *triggerlinkgenerator.Generator, *[]string) {
+	uuids := map[string]string{
+		"amd64": "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa",
+		"arm64": "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb",
+	}
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/request.cgi":
+			arch := r.URL.Query().Get("arch")
+			requested = append(requested, arch)
+			switch arch {
+			case "s390x":
+				w.Write([]byte("<p>You submitted an invalid request: no s390x testbeds</p>"))
+			case "riscv64":
+				w.Write([]byte("Please login to continue"))
+			default:
+				fmt.Fprintf(w, "Test request submitted.\nUUID\n    %s\n", uuids[arch])
+			}
+		case strings.HasPrefix(r.URL.Path, "/packages/"):
+			w.Write([]byte(mockBatchRunningPage))
+		case r.URL.Path == "/running":
+			w.Write([]byte("<html><body></body></html>"))
+		default:
+			w.Write([]byte("Test In progress..."))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := autopkgtestclient.NewClient(autopkgtestclient.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	gen := triggerlinkgenerator.NewGenerator()
+	gen.BaseURL = server.URL + "/request.cgi"
+	return autopkgtestclient.NewTriggerer(client, gen), gen, &requested
+}
+
+func TestRunBatch(t *testing.T) {
+	triggerer, gen, requested := newBatchTriggerer(t)
+	items := []batchItem{
+		{Line: 1, Package: "ovn", Release: "noble", Archs: []string{"amd64", "s390x", "arm64"}},
+		{Line: 2, Package: "ovn", Release: "nobel", Archs: []string{"amd64"}},
+		{Line: 3, Package: "systemd", Release: "jammy", Archs: []string{"arm64"}},
+	}
+
+	outcomes, err := runBatch(context.Background(), gen, triggerer, items, triggerlinkgenerator.LinkRequest{}, false, io.Discard)
+	if err != nil {
+		t.Fatalf("runBatch failed: %v", err)
+	}
+
+	if len(outcomes) != 5 {
+		t.Fatalf("Expected 5 outcomes, got %d: %+v", len(outcomes), outcomes)
+	}
+	if !slices.Equal(*requested, []string{"amd64", "s390x", "arm64", "arm64"}) {
+		t.Errorf("Expected 4 requests (invalid suite never submitted), got %v", *requested)
+	}
+	if outcomes[0].Err != nil || outcomes[0].Result.UUID != "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa" {
+		t.Errorf("Expected ovn/noble/amd64 to be triggered, got %+v", outcomes[0])
+	}
+	if !errors.Is(outcomes[1].Err, autopkgtestclient.ErrInvalidRequest) || outcomes[1].Arch != "s390x" {
+		t.Errorf("Expected ovn/noble/s390x to fail, got %+v", outcomes[1])
+	}
+	if outcomes[2].Err != nil || outcomes[2].Arch != "arm64" {
+		t.Errorf("Expected the line to continue with ovn/noble/arm64, got %+v", outcomes[2])
+	}
+	if outcomes[3].Err == nil || !strings.Contains(outcomes[3].Err.Error(), "line 2") {
+		t.Errorf("Expected line 2 to fail validation, got %+v", outcomes[3])
+	}
+	if outcomes[4].Err != nil || outcomes[4].Package != "systemd" {
+		t.Errorf("Expected batch to continue with systemd, got %+v", outcomes[4])
+	}
+
+	var buf bytes.Buffer
+	printBatchSummary(&buf, outcomes)
+	for _, want := range []string{"aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa", "FAILED", "3 triggered, 0 already running, 2 failed"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected summary to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestRunBatch_SkipRunning(t *testing.T) {
+	triggerer, gen, requested := newBatchTriggerer(t)
+	items := []batchItem{
+		{Line: 1, Package: "ovn", Release: "noble", Archs: []string{"amd64", "ppc64el"}},
+	}
+
+	outcomes, err := runBatch(context.Background(), gen, triggerer, items, triggerlinkgenerator.LinkRequest{}, true, io.Discard)
+	if err != nil {
+		t.Fatalf("runBatch failed: %v", err)
+	}
+
+	// ppc64el is already running, so it is not submitted
+	if !slices.Equal(*requested, []string{"amd64"}) {
+		t.Errorf("Expected only amd64 to be submitted, got %v", *requested)
+	}
+	if len(outcomes) != 2 {
+		t.Fatalf("Expected 2 outcomes, got %d: %+v", len(outcomes), outcomes)
+	}
+	if outcomes[0].Running || outcomes[0].Err != nil {
+		t.Errorf("Expected ovn/noble/amd64 to be triggered, got %+v", outcomes[0])
+	}
+	if !outcomes[1].Running || outcomes[1].Err != nil || outcomes[1].Result.UUID != "11111111-1111-1111-1111-111111111111" {
+		t.Errorf("Expected ovn/noble/ppc64el to be skipped as running, got %+v", outcomes[1])
+	}
+
+	var buf bytes.Buffer
+	printBatchSummary(&buf, outcomes)
+	for _, want := range []string{"already running  11111111-1111-1111-1111-111111111111", "1 triggered, 1 already running, 0 failed"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected summary to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestRunBatch_StopsOnAuthError(t *testing.T) {
+	triggerer, gen, requested := newBatchTriggerer(t)
+	items := []batchItem{
+		{Line: 1, Package: "ovn", Release: "noble", Archs: []string{"riscv64", "amd64"}},
+		{Line: 2, Package: "systemd", Release: "noble", Archs: []string{"amd64"}},
+	}
+
+	outcomes, err := runBatch(context.Background(), gen, triggerer, items, triggerlinkgenerator.LinkRequest{}, false, io.Discard)
+	if !errors.Is(err, autopkgtestclient.ErrAuthRequired) {
+		t.Errorf("Expected ErrAuthRequired, got: %v", err)
+	}
+	if len(outcomes) != 1 || len(*requested) != 1 {
+		t.Errorf("Expected the batch to stop after the first request, got %d outcomes, requests %v", len(outcomes), *requested)
+	}
+}
+
+func TestCLITriggerBatchConflicts(t *testing.T) {
+	_, stderr, code := runCLI(t, "trigger", "-batch", "requests.txt", "-package", "ovn")
+	if code != exitUsage {
+		t.Errorf("Expected exit code %d, got %d", exitUsage, code)
+	}
+	if !strings.Contains(stderr, "-batch cannot be combined") {
+		t.Errorf("Expected conflict error, got: %s", stderr)
+	}
+}
+
+func TestFormatDiff(t *testing.T) {
+	diffs := []scraper.ResultDiff{
+		{Release: "jammy", Architecture: "amd64", StatusA: "pass", StatusB: "regression"},
+		{Release: "questing", Architecture: "amd64", StatusB: "neutral"},
+	}
+
+	out := formatDiff("ovn", "openvswitch", diffs)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected header, 2 rows, blank line and summary, got:\n%s", out)
+	}
+	if fields := strings.Fields(lines[0]); !slices.Equal(fields, []string{"RELEASE", "ARCH", "ovn", "openvswitch"}) {
+		t.Errorf("Expected header with package names, got %q", lines[0])
+	}
+	if fields := strings.Fields(lines[2]); !slices.Equal(fields, []string{"questing", "amd64", "-", "neutral"}) {
+		t.Errorf("Expected missing cell shown as '-', got %q", lines[2])
+	}
+	if lines[4] != "2 cell(s) differ" {
+		t.Errorf("Expected summary line, got %q", lines[4])
+	}
+
+	if out := formatDiff("ovn", "openvswitch", nil); !strings.Contains(out, "No differences") {
+		t.Errorf("Expected no differences message, got %q", out)
+	}
+}
+
+func TestCLIDiffRequiresPackages(t *testing.T) {
+	_, stderr, code := runCLI(t, "diff", "-package-a", "ovn")
+	if code != exitUsage {
+		t.Errorf("Expected exit code %d, got %d", exitUsage, code)
+	}
+	if !strings.Contains(stderr, "-package-a and -package-b flags are required") {
+		t.Errorf("Expected missing flag error, got: %s", stderr)
+	}
+}
+
+func TestFormatApproxDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{in: 10 * time.Second, want: "<1m"},
+		{in: 4*time.Minute + 40*time.Second, want: "5m"},
+		{in: 15 * time.Minute, want: "15m"},
+		{in: time.Hour + 20*time.Minute + 25*time.Second, want: "1h20m"},
+		{in: 2*time.Hour + 5*time.Minute, want: "2h05m"},
+	}
+	for _, tt := range tests {
+		if got := formatApproxDuration(tt.in); got != tt.want {
+			t.Errorf("formatApproxDuration(%v): expected %q, got %q", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestCheckFilter(t *testing.T) {
+	if filter := checkFilter("ovn", "", "", "", ""); filter != nil {
+		t.Errorf("Expected no filter without options, got %+v", filter)
+	}
+
+	filter := checkFilter("ovn", "noble", "", "", "25.09.0-3")
+	if filter == nil {
+		t.Fatal("Expected a filter")
+	}
+	if filter.Release != "noble" || filter.Trigger != "ovn/25.09.0-3" {
+		t.Errorf("Expected release noble and trigger ovn/25.09.0-3, got %+v", filter)
+	}
+}
+
+func TestFormatQueueSizes(t *testing.T) {
+	out := formatQueueSizes(map[string]int{"noble/amd64": 340, "jammy/s390x": 3, "noble/arm64": 0})
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("Expected header, 3 rows, blank line and total, got:\n%s", out)
+	}
+	if fields := strings.Fields(lines[1]); !slices.Equal(fields, []string{"jammy", "s390x", "3"}) {
+		t.Errorf("Expected queues sorted by release, got %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); !slices.Equal(fields, []string{"noble", "amd64", "340"}) {
+		t.Errorf("Expected noble/amd64 second, got %q", lines[2])
+	}
+	if lines[5] != "343 request(s) queued in total" {
+		t.Errorf("Expected total line, got %q", lines[5])
+	}
+
+	if out := formatQueueSizes(nil); out != "No queues found.\n" {
+		t.Errorf("Expected no queues message, got %q", out)
+	}
+}
+
+func TestTriggerExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: fmt.Errorf("submitting: %w", autopkgtestclient.ErrAuthRequired), want: exitAuthRequired},
+		{err: fmt.Errorf("%w: unknown package", autopkgtestclient.ErrInvalidRequest), want: exitInvalidRequest},
+		{err: fmt.Errorf("%w after 2h0m0s", autopkgtestclient.ErrTimeout), want: exitTimeout},
+		{err: errors.New("connection refused"), want: 1},
+	}
+	for _, tt := range tests {
+		if got := triggerExitCode(tt.err); got != tt.want {
+			t.Errorf("triggerExitCode(%v): expected %d, got %d", tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestFormatStatusSummary(t *testing.T) {
+	got := formatStatusSummary(map[string]int{"regression": 1, "fail": 2, "pass": 10, "tmpfail": 1}, false)
+	if want := "pass: 10, fail: 2, regression: 1, tmpfail: 1"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	if got := formatStatusSummary(nil, false); got != "" {
+		t.Errorf("Expected empty summary, got %q", got)
+	}
+}
+
+func TestColorize(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{"pass", "\033[32mpass\033[0m"},
+		{"FAIL", "\033[31mFAIL\033[0m"},
+		{"regression", "\033[31mregression\033[0m"},
+		{"tmpfail", "\033[33mtmpfail\033[0m"},
+		{"neutral", "neutral"},
+	}
+
+	for _, tt := range tests {
+		if got := colorize(tt.status, true); got != tt.want {
+			t.Errorf("colorize(%q): expected %q, got %q", tt.status, tt.want, got)
+		}
+		if got := colorize(tt.status, false); got != tt.status {
+			t.Errorf("colorize(%q) without color: expected it unchanged, got %q", tt.status, got)
+		}
+	}
+}
+
+func TestColorizeReport(t *testing.T) {
+	report := "Found 1 errors for package: ovn\n\nError 1:\n\tStatus: fail\n\tRelease: noble\n"
+
+	want := "Found 1 errors for package: ovn\n\nError 1:\n\tStatus: \033[31mfail\033[0m\n\tRelease: noble\n"
+	if got := colorizeReport(report, true); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := colorizeReport(report, false); got != report {
+		t.Errorf("Expected the report unchanged without color, got %q", got)
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	// Test output is not a terminal
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatalf("CreateTemp() failed: %v", err)
+	}
+	defer f.Close()
+
+	t.Setenv("NO_COLOR", "")
+	if useColor("auto", f) {
+		t.Error("Expected no color in auto mode when not writing to a terminal")
+	}
+	if !useColor("always", f) {
+		t.Error("Expected color in always mode")
+	}
+	if useColor("never", f) {
+		t.Error("Expected no color in never mode")
+	}
+
+	// NO_COLOR only changes the default
+	t.Setenv("NO_COLOR", "1")
+	if !useColor("always", f) {
+		t.Error("Expected -color always to override NO_COLOR")
+	}
+}
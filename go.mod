@@ -2,4 +2,9 @@ module github.com/canonical/autopkgtest-automation
 
 go 1.25.3
 
-require golang.org/x/net v0.49.0
+require (
+	golang.org/x/net v0.49.0
+	golang.org/x/time v0.15.0
+)
+
+require golang.org/x/text v0.33.0 // indirect
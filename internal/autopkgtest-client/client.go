@@ -1,37 +1,114 @@
 package autopkgtestclient
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
+	"log/slog"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/net/publicsuffix"
+	"golang.org/x/time/rate"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+	"github.com/canonical/autopkgtest-automation/internal/httpbody"
 )
 
 // TriggerResult represents the result of triggering an autopkgtest
 type TriggerResult struct {
-	UUID       string // Test UUID
-	ResultURL  string // URL to view test results
-	HistoryURL string // URL to view result history
-	Package    string // Package name
-	Release    string // Ubuntu release
-	Arch       string // Architecture
-	Triggers   string // Trigger string used
-	Requester  string // Username that requested the test
+	UUID       string `json:"uuid"`        // Test UUID
+	ResultURL  string `json:"result_url"`  // URL to view test results
+	HistoryURL string `json:"history_url"` // URL to view result history
+	Package    string `json:"package"`     // Package name
+	Release    string `json:"release"`     // Ubuntu release
+	Arch       string `json:"arch"`        // Architecture
+	Triggers   string `json:"triggers"`    // Trigger string used
+	Requester  string `json:"requester"`   // Username that requested the test
 }
 
 // TestStatus represents the status of a running test
 type TestStatus struct {
-	UUID      string    // Test UUID
-	Status    string    // "queued", "running", "pass", "fail", "neutral", "tmpfail", "unknown"
-	StartTime time.Time // When the test started (if available)
-	Duration  string    // Test duration (if completed)
-	LogURL    string    // URL to test logs
+	UUID      string             `json:"uuid"`                // Test UUID
+	Status    autopkgtest.Status `json:"status"`              // One of the Status constants
+	StartTime time.Time          `json:"start_time,omitzero"` // When the test started (if available)
+	Duration  string             `json:"duration,omitempty"`  // Test duration (if completed)
+	LogURL    string             `json:"log_url"`             // URL to test logs
+	Testbed   string             `json:"testbed,omitempty"`   // Testbed (worker/instance) that ran the test (if available)
+	Region    string             `json:"region,omitempty"`    // Cloud region the test ran in (if available)
+
+	DurationParsed time.Duration `json:"-"`                 // Duration as a time.Duration (zero if absent or not parsable)
+	Retries        int           `json:"retries,omitempty"` // Times the test was re-triggered after a tmpfail (see WithTmpfailRetry)
+}
+
+// ErrAuthRequired is returned when the server requires a (valid) Launchpad
+// session: the request was redirected to the login page or refused with 403
+var ErrAuthRequired = errors.New("authentication required")
+
+// ErrUUIDNotFound is returned (wrapped) by TriggerTest when the server
+// confirmed the submission but its response could not be fully parsed. The
+// test was submitted; the partially filled TriggerResult is returned with it.
+var ErrUUIDNotFound = errors.New("test submission response parsed but UUID not found")
+
+// ErrInvalidRequest is returned (wrapped) when the server rejects a test
+// request as invalid, e.g. for an unknown package or release
+var ErrInvalidRequest = errors.New("invalid request")
+
+// ErrTimeout is returned (wrapped) by WaitForCompletion when the test has
+// not completed before the timeout
+var ErrTimeout = errors.New("timeout reached")
+
+// ErrTestAlreadyRunning is returned (wrapped in an *AlreadyRunningError) when
+// a test for the same package/release/arch is already queued or running
+var ErrTestAlreadyRunning = errors.New("test already running")
+
+// AlreadyRunningError describes the test the server reported as already
+// running. Fields the server did not report are taken from the request.
+type AlreadyRunningError struct {
+	Package string
+	Release string
+	Arch    string
+}
+
+func (e *AlreadyRunningError) Error() string {
+	return fmt.Sprintf("%s for %s/%s/%s", ErrTestAlreadyRunning, e.Package, e.Release, e.Arch)
+}
+
+// Unwrap allows errors.Is(err, ErrTestAlreadyRunning)
+func (e *AlreadyRunningError) Unwrap() error {
+	return ErrTestAlreadyRunning
+}
+
+// ErrThrottled is returned (wrapped in a *ThrottledError) when the server
+// refuses a test request because the requester has submitted too many
+var ErrThrottled = errors.New("test request throttled")
+
+// ThrottledError describes a throttled test request
+type ThrottledError struct {
+	RetryAfter time.Duration // Server hint for when to retry (zero if not given)
+	Message    string        // Server-provided explanation (if available)
+}
+
+func (e *ThrottledError) Error() string {
+	msg := ErrThrottled.Error()
+	if e.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Message)
+	}
+	if e.RetryAfter > 0 {
+		msg = fmt.Sprintf("%s (retry after %v)", msg, e.RetryAfter)
+	}
+	return msg
+}
+
+// Unwrap allows errors.Is(err, ErrThrottled)
+func (e *ThrottledError) Unwrap() error {
+	return ErrThrottled
 }
 
 // AuthMethod defines how to authenticate with autopkgtest.ubuntu.com
@@ -49,6 +126,19 @@ type Client struct {
 	httpClient *http.Client
 	baseURL    string
 	authMethod AuthMethod
+	headers    http.Header
+	cookies    []*http.Cookie // Set on the jar for baseURL once all options are applied
+	limiter    *rate.Limiter  // Shared by every request; nil for no limit
+	logger     *slog.Logger
+
+	maxResponseBytes int64 // Largest page body read (<= 0: the default); logs are not limited
+
+	statusParser func(body string) autopkgtest.Status
+	pollStrategy PollStrategy
+
+	tmpfailRetries int
+	triggersMu     sync.Mutex
+	triggerURLs    map[string]string // Trigger URL of each test triggered, by UUID
 }
 
 // ClientOption configures the Client
@@ -57,10 +147,96 @@ type ClientOption func(*Client)
 // WithCookies configures the client to use specific cookies for authentication
 func WithCookies(cookies []*http.Cookie) ClientOption {
 	return func(c *Client) {
-		if jar, ok := c.httpClient.Jar.(*cookiejar.Jar); ok {
-			u, _ := url.Parse(c.baseURL)
-			jar.SetCookies(u, cookies)
+		c.cookies = append(c.cookies, cookies...)
+	}
+}
+
+// WithBaseURL points the client at another autopkgtest instance, such as a
+// staging deployment or a mirror, instead of https://autopkgtest.ubuntu.com.
+// Result, status, running and log URLs are all built from it.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithHeader adds a header that is sent on every request, independently of
+// cookie authentication. It may be given multiple times.
+func WithHeader(key, value string) ClientOption {
+	return func(c *Client) {
+		c.headers.Add(key, value)
+	}
+}
+
+// WithStatusParser replaces the built-in detection of a test's status from
+// its run page, for autopkgtest deployments whose pages differ from
+// autopkgtest.ubuntu.com. parser receives the full page body.
+func WithStatusParser(parser func(body string) autopkgtest.Status) ClientOption {
+	return func(c *Client) {
+		c.statusParser = parser
+	}
+}
+
+// WithHTTPClient makes the client send its requests through a copy of hc,
+// e.g. one with a proxy, custom TLS configuration or tuned connection pool.
+// When hc has no cookie jar the client keeps its own so that cookie
+// authentication still works. Options applied earlier that change the HTTP
+// client itself (WithTransport) are replaced.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		clone := *hc
+		if clone.Jar == nil {
+			clone.Jar = c.httpClient.Jar
 		}
+		c.httpClient = &clone
+	}
+}
+
+// WithTransport sets the transport used for requests, leaving the rest of the
+// HTTP client (cookie jar, timeout, redirect policy) unchanged
+func WithTransport(rt http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.httpClient.Transport = rt
+	}
+}
+
+// WithRateLimit limits the client to perSecond requests per second across
+// all of its methods (triggering, status polling, running test lookups, log
+// downloads). Requests over the limit wait for their turn rather than fail;
+// with a context, as while waiting for tests or with a Triggerer, the wait
+// stops when it is cancelled. A perSecond of zero or less disables the
+// limit.
+func WithRateLimit(perSecond float64) ClientOption {
+	return func(c *Client) {
+		if perSecond <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = rate.NewLimiter(rate.Limit(perSecond), 1)
+	}
+}
+
+// WithLogger makes the client log its requests and parsing decisions at
+// debug level to logger, e.g. to find out which part of a server response
+// could no longer be parsed after a layout change. Nothing is logged by
+// default.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		if logger == nil {
+			logger = slog.New(slog.DiscardHandler)
+		}
+		c.logger = logger
+	}
+}
+
+// WithMaxResponseBytes sets the largest page body the client reads, so that
+// a broken or malicious server cannot exhaust memory. Larger responses fail
+// with httpbody.ErrResponseTooLarge. Test logs, which are legitimately large,
+// are not limited. A limit of zero or less restores
+// httpbody.DefaultMaxResponseBytes.
+func WithMaxResponseBytes(limit int64) ClientOption {
+	return func(c *Client) {
+		c.maxResponseBytes = limit
 	}
 }
 
@@ -92,27 +268,129 @@ func NewClient(opts ...ClientOption) (*Client, error) {
 				return nil
 			},
 		},
-		baseURL:    "https://autopkgtest.ubuntu.com",
-		authMethod: AuthInteractive,
+		baseURL:      "https://autopkgtest.ubuntu.com",
+		authMethod:   AuthInteractive,
+		headers:      http.Header{},
+		logger:       slog.New(slog.DiscardHandler),
+		statusParser: parseStatus,
+
+		maxResponseBytes: httpbody.DefaultMaxResponseBytes,
 	}
 
 	for _, opt := range opts {
 		opt(client)
 	}
 
+	if len(client.cookies) > 0 {
+		u, err := url.Parse(client.baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base URL: %w", err)
+		}
+		client.httpClient.Jar.SetCookies(u, client.cookies)
+	}
+
 	return client, nil
 }
 
+// Close releases idle connections held by the client's transport. The client
+// remains usable; new connections are opened as needed.
+func (c *Client) Close() {
+	c.httpClient.CloseIdleConnections()
+}
+
+// get performs a GET request with the configured extra headers. Waiting for
+// the rate limiter and the request itself stop when ctx is done. A 403
+// response is returned as ErrAuthRequired, since the server uses it for
+// invalid or expired sessions on some endpoints.
+func (c *Client) get(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("rate limit: %w", err)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Debug("request failed", "url", rawURL, "error", err)
+		return nil, err
+	}
+	c.logger.Debug("request done", "url", rawURL, "final_url", resp.Request.URL.String(), "status", resp.StatusCode)
+	if resp.StatusCode == http.StatusForbidden {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s returned 403 Forbidden", ErrAuthRequired, req.URL.Path)
+	}
+	return resp, nil
+}
+
+// extractField returns the first submatch of re in body. A miss is logged
+// at debug level with the regex, so that a field lost to a change of the
+// server's HTML can be told apart from one the response never had.
+func (c *Client) extractField(re *regexp.Regexp, body, field string) string {
+	if matches := re.FindStringSubmatch(body); len(matches) > 1 {
+		return matches[1]
+	}
+	c.logger.Debug("field not found in response", "field", field, "regex", re.String())
+	return ""
+}
+
+// ValidateTriggerURL checks that triggerURL is a well-formed request.cgi URL:
+// an http(s) URL with a host, a path ending in /request.cgi, and non-empty
+// release, package and trigger parameters
+func ValidateTriggerURL(triggerURL string) error {
+	u, err := url.Parse(triggerURL)
+	if err != nil {
+		return fmt.Errorf("invalid trigger URL: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("invalid trigger URL: unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid trigger URL: missing host")
+	}
+	if !strings.HasSuffix(u.Path, "/request.cgi") {
+		return fmt.Errorf("invalid trigger URL: path %q is not request.cgi", u.Path)
+	}
+
+	params := u.Query()
+	for _, name := range []string{"release", "package", "trigger"} {
+		if params.Get(name) == "" {
+			return fmt.Errorf("invalid trigger URL: missing %s parameter", name)
+		}
+	}
+	return nil
+}
+
 // TriggerTest attempts to trigger an autopkgtest
-// Returns TriggerResult if successful, or an error if authentication is needed or request failed
+// Returns TriggerResult if successful, or an error if authentication is needed or request failed.
+// If the submission was confirmed but its UUID could not be parsed, the
+// fields that were extracted are returned along with an ErrUUIDNotFound error.
 func (c *Client) TriggerTest(triggerURL string) (*TriggerResult, error) {
-	resp, err := c.httpClient.Get(triggerURL)
+	return c.triggerTest(context.Background(), triggerURL)
+}
+
+// triggerTest implements TriggerTest, stopping when ctx is done
+func (c *Client) triggerTest(ctx context.Context, triggerURL string) (*TriggerResult, error) {
+	if err := ValidateTriggerURL(triggerURL); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.get(ctx, triggerURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to trigger test: %w", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := httpbody.Read(resp, c.maxResponseBytes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
@@ -121,62 +399,49 @@ func (c *Client) TriggerTest(triggerURL string) (*TriggerResult, error) {
 
 	// Check for success response first
 	if strings.Contains(bodyStr, "Test request submitted") {
+		c.logger.Debug("trigger response is a submission confirmation", "url", triggerURL)
 		result := &TriggerResult{}
 
 		// Extract UUID - handle both plain text and HTML formats
 		// Plain text: "UUID\n    uuid-value"
 		// HTML: "<dt>UUID</dt>\n<dd>uuid-value</dd>"
 		uuidRegex := regexp.MustCompile(`(?:UUID\s*\n\s*|<dt>UUID</dt>\s*\n\s*<dd>)([a-f0-9-]{36})`)
-		if matches := uuidRegex.FindStringSubmatch(bodyStr); len(matches) > 1 {
-			result.UUID = matches[1]
-		}
+		result.UUID = c.extractField(uuidRegex, bodyStr, "uuid")
 
 		// Extract Result URL
 		resultURLRegex := regexp.MustCompile(`(?:Result url\s*\n\s*|<dt>Result url</dt>\s*\n\s*<dd>(?:<a[^>]*>)?)([^<\s]+)`)
-		if matches := resultURLRegex.FindStringSubmatch(bodyStr); len(matches) > 1 {
-			result.ResultURL = matches[1]
-		}
+		result.ResultURL = c.extractField(resultURLRegex, bodyStr, "result url")
 
 		// Extract History URL
 		historyURLRegex := regexp.MustCompile(`(?:Result history\s*\n\s*|<dt>Result history</dt>\s*\n\s*<dd>(?:<a[^>]*>)?)([^<\s]+)`)
-		if matches := historyURLRegex.FindStringSubmatch(bodyStr); len(matches) > 1 {
-			result.HistoryURL = matches[1]
-		}
+		result.HistoryURL = c.extractField(historyURLRegex, bodyStr, "result history")
 
 		// Extract package, release, arch
 		packageRegex := regexp.MustCompile(`(?:package\s*\n\s*|<dt>package</dt>\s*\n\s*<dd>)([^<\s]+)`)
-		if matches := packageRegex.FindStringSubmatch(bodyStr); len(matches) > 1 {
-			result.Package = matches[1]
-		}
+		result.Package = c.extractField(packageRegex, bodyStr, "package")
 
 		releaseRegex := regexp.MustCompile(`(?:release\s*\n\s*|<dt>release</dt>\s*\n\s*<dd>)([^<\s]+)`)
-		if matches := releaseRegex.FindStringSubmatch(bodyStr); len(matches) > 1 {
-			result.Release = matches[1]
-		}
+		result.Release = c.extractField(releaseRegex, bodyStr, "release")
 
 		archRegex := regexp.MustCompile(`(?:arch\s*\n\s*|<dt>arch</dt>\s*\n\s*<dd>)([^<\s]+)`)
-		if matches := archRegex.FindStringSubmatch(bodyStr); len(matches) > 1 {
-			result.Arch = matches[1]
-		}
+		result.Arch = c.extractField(archRegex, bodyStr, "arch")
 
 		// Extract requester
 		requesterRegex := regexp.MustCompile(`(?:requester\s*\n\s*|<dt>requester</dt>\s*\n\s*<dd>)([^<\s]+)`)
-		if matches := requesterRegex.FindStringSubmatch(bodyStr); len(matches) > 1 {
-			result.Requester = matches[1]
-		}
+		result.Requester = c.extractField(requesterRegex, bodyStr, "requester")
 
 		// Extract triggers
 		triggersRegex := regexp.MustCompile(`(?:triggers\s*\n\s*|<dt>triggers</dt>\s*\n\s*<dd>)(.+?)(?:\n|</dd>)`)
-		if matches := triggersRegex.FindStringSubmatch(bodyStr); len(matches) > 1 {
-			result.Triggers = matches[1]
-		}
+		result.Triggers = c.extractField(triggersRegex, bodyStr, "triggers")
 
 		// Extract PPAs (for PPA test requests)
-		// Format: <dt>ppas</dt>\n<dd>['username/ppa-name']</dd>
-		ppaRegex := regexp.MustCompile(`(?:ppas\s*\n\s*|<dt>ppas</dt>\s*\n\s*<dd>)\['([^']+)'\]`)
+		// Format: <dt>ppas</dt>\n<dd>['username/ppa-name', 'other/ppa-name']</dd>
+		// When several PPAs are layered, results are stored under the last one
+		ppaRegex := regexp.MustCompile(`(?:ppas\s*\n\s*|<dt>ppas</dt>\s*\n\s*<dd>)\[([^\]]+)\]`)
 		var ppaStr string
-		if matches := ppaRegex.FindStringSubmatch(bodyStr); len(matches) > 1 {
-			ppaStr = matches[1]
+		if ppaList := c.extractField(ppaRegex, bodyStr, "ppas"); ppaList != "" {
+			ppas := strings.Split(ppaList, ",")
+			ppaStr = strings.Trim(strings.TrimSpace(ppas[len(ppas)-1]), "'")
 		}
 
 		// Handle PPA-based test requests (no UUID returned)
@@ -194,49 +459,150 @@ func (c *Client) TriggerTest(triggerURL string) (*TriggerResult, error) {
 				// UUID not available for PPA tests, leave it empty
 				return result, nil
 			}
-			return nil, fmt.Errorf("test submission response indicates PPA test but PPA format is invalid: %s", ppaStr)
+			return result, fmt.Errorf("%w: response indicates PPA test but PPA format is invalid: %s", ErrUUIDNotFound, ppaStr)
 		}
 
 		if result.UUID == "" {
-			return nil, fmt.Errorf("test submission response parsed but UUID not found and no PPA information available")
+			return result, fmt.Errorf("%w and no PPA information available", ErrUUIDNotFound)
 		}
 
+		c.rememberTrigger(result.UUID, triggerURL)
 		return result, nil
 	}
 
+	// Check for throttling before the generic invalid request handling, since
+	// the throttle message may be rendered as an invalid request
+	if throttled := checkThrottled(resp, bodyStr); throttled != nil {
+		c.logger.Debug("trigger response is a throttling notice", "url", triggerURL, "retry_after", throttled.RetryAfter)
+		return nil, throttled
+	}
+
 	// Check for invalid request error (check this before auth check)
 	if strings.Contains(bodyStr, "You submitted an invalid request") {
 		// Check for specific "Test already running" error
 		if strings.Contains(bodyStr, "Test already running") {
-			return nil, fmt.Errorf("test already running for this package/release/arch combination")
+			c.logger.Debug("trigger response reports the test already running", "url", triggerURL)
+			return nil, parseAlreadyRunning(bodyStr, triggerURL)
 		}
+		c.logger.Debug("trigger response reports an invalid request", "url", triggerURL)
 
 		// Extract the error message
 		// Pattern: <p>You submitted an invalid request: error message</p>
 		errorRegex := regexp.MustCompile(`<p>You submitted an invalid request:\s*([^<]+)</p>`)
 		if matches := errorRegex.FindStringSubmatch(bodyStr); len(matches) > 1 {
 			errorMsg := strings.TrimSpace(matches[1])
-			return nil, fmt.Errorf("invalid request: %s", errorMsg)
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRequest, errorMsg)
 		}
-		return nil, fmt.Errorf("invalid request (details not available)")
+		return nil, fmt.Errorf("%w (details not available)", ErrInvalidRequest)
 	}
 
 	// Check if we need authentication
 	// Look for redirect to login page or login prompt (but not "Logout" which means we're authenticated)
 	if strings.Contains(resp.Request.URL.String(), "/login") ||
 		(strings.Contains(bodyStr, "login") && !strings.Contains(bodyStr, "Logout")) {
-		return nil, fmt.Errorf("authentication required: please authenticate first")
+		c.logger.Debug("trigger response asks for a login", "url", triggerURL, "final_url", resp.Request.URL.String())
+		return nil, fmt.Errorf("%w: please authenticate first", ErrAuthRequired)
 	}
 
 	// Unknown response
+	c.logger.Debug("trigger response not recognized", "url", triggerURL, "status", resp.StatusCode, "body_length", len(bodyStr))
 	return nil, fmt.Errorf("unexpected response from server")
 }
 
+// parseAlreadyRunning builds an *AlreadyRunningError from the server's
+// "Test already running" response, which lists the release, pkg and arch of
+// the running test. Missing fields are taken from triggerURL.
+func parseAlreadyRunning(bodyStr, triggerURL string) *AlreadyRunningError {
+	field := func(name string) string {
+		re := regexp.MustCompile(`(?m)\b` + name + `:\s*(?:<[^>]+>\s*)*([^\s<]+)`)
+		if matches := re.FindStringSubmatch(bodyStr); len(matches) > 1 {
+			return matches[1]
+		}
+		return ""
+	}
+
+	runErr := &AlreadyRunningError{
+		Package: field("pkg"),
+		Release: field("release"),
+		Arch:    field("arch"),
+	}
+
+	if u, err := url.Parse(triggerURL); err == nil {
+		params := u.Query()
+		if runErr.Package == "" {
+			runErr.Package = params.Get("package")
+		}
+		if runErr.Release == "" {
+			runErr.Release = params.Get("release")
+		}
+		if runErr.Arch == "" {
+			runErr.Arch = params.Get("arch")
+		}
+	}
+	return runErr
+}
+
+// checkThrottled returns a *ThrottledError if the response indicates the
+// request was rejected for exceeding the requester's submission limit
+func checkThrottled(resp *http.Response, bodyStr string) *ThrottledError {
+	throttleRegex := regexp.MustCompile(`(?i)(too many (?:test )?requests|request(?:s)? (?:was |were |is |are )?throttled|rate limit(?:ed)?)[^<\n]*`)
+	match := throttleRegex.FindString(bodyStr)
+	if resp.StatusCode != http.StatusTooManyRequests && match == "" {
+		return nil
+	}
+
+	throttled := &ThrottledError{
+		Message:    strings.TrimSpace(match),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	// Fall back to a hint in the page body, e.g. "try again in 300 seconds"
+	if throttled.RetryAfter == 0 {
+		hintRegex := regexp.MustCompile(`(?i)(?:try again|retry) (?:in|after) (\d+) ?(seconds?|minutes?|hours?|s|m|h)\b`)
+		if matches := hintRegex.FindStringSubmatch(bodyStr); len(matches) > 2 {
+			n, _ := strconv.Atoi(matches[1])
+			switch strings.ToLower(matches[2][:1]) {
+			case "h":
+				throttled.RetryAfter = time.Duration(n) * time.Hour
+			case "m":
+				throttled.RetryAfter = time.Duration(n) * time.Minute
+			default:
+				throttled.RetryAfter = time.Duration(n) * time.Second
+			}
+		}
+	}
+
+	return throttled
+}
+
+// parseRetryAfter parses a Retry-After header given either as a number of
+// seconds or as an HTTP date. It returns zero if the header is absent or invalid.
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
 // GetTestStatus checks the status of a test by UUID
 func (c *Client) GetTestStatus(uuid string) (*TestStatus, error) {
+	return c.getTestStatus(context.Background(), uuid)
+}
+
+// getTestStatus implements GetTestStatus, stopping when ctx is done
+func (c *Client) getTestStatus(ctx context.Context, uuid string) (*TestStatus, error) {
 	resultURL := fmt.Sprintf("%s/run/%s", c.baseURL, uuid)
 
-	resp, err := c.httpClient.Get(resultURL)
+	resp, err := c.get(ctx, resultURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get test status: %w", err)
 	}
@@ -250,61 +616,20 @@ func (c *Client) GetTestStatus(uuid string) (*TestStatus, error) {
 	// If we get a 404, the test results page doesn't exist yet
 	// This means the test is either queued or still running
 	if resp.StatusCode == 404 {
-		status.Status = "running"
+		c.logger.Debug("run page not found, assuming the test is running", "uuid", uuid)
+		status.Status = autopkgtest.StatusRunning
 		return status, nil
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := httpbody.Read(resp, c.maxResponseBytes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	bodyStr := string(body)
 
-	// Determine test status based on the Result field in the page
-	// The HTML structure is: <th>Result</th> followed by <td class="...">status_text</td>
-	// Also support the Markdown table format for backward compatibility: | Result | status |
-	resultHTMLRegex := regexp.MustCompile(`(?s)<th>Result</th>\s*<td[^>]*>([^<]+)</td>`)
-	resultMarkdownRegex := regexp.MustCompile(`(?i)\|\s*Result\s*\|[^|]*\|`)
-
-	var resultValue string
-
-	// Try HTML format first (the actual format used by the website)
-	if matches := resultHTMLRegex.FindStringSubmatch(bodyStr); len(matches) > 1 {
-		resultValue = strings.TrimSpace(matches[1])
-	} else if resultMatch := resultMarkdownRegex.FindString(bodyStr); resultMatch != "" {
-		// Fallback to Markdown table format (for backward compatibility with tests)
-		resultValue = resultMatch
-	}
-
-	if resultValue != "" {
-		// Normalize the result match for comparison (lowercase, trim spaces)
-		resultLower := strings.ToLower(resultValue)
-
-		// Check the actual result value - check for tmpfail first to avoid substring issues with fail
-		// Status values can be: pass, fail, neutral, tmpfail
-		if strings.Contains(resultLower, "tmpfail") {
-			status.Status = "tmpfail"
-		} else if strings.Contains(resultLower, "fail") {
-			status.Status = "fail"
-		} else if strings.Contains(resultLower, "pass") {
-			status.Status = "pass"
-		} else if strings.Contains(resultLower, "neutral") {
-			status.Status = "neutral"
-		} else {
-			// Result row found but status not recognized - set as unknown
-			status.Status = "unknown"
-		}
-	} else {
-		// Fallback to checking page content for in-progress states
-		if strings.Contains(bodyStr, "In progress") {
-			status.Status = "running"
-		} else if strings.Contains(bodyStr, "Queued") {
-			status.Status = "queued"
-		} else {
-			status.Status = "unknown"
-		}
-	}
+	status.Status = c.statusParser(bodyStr)
+	c.logger.Debug("parsed test status", "uuid", uuid, "status", status.Status)
 
 	// Try to extract duration if test is complete
 	// HTML format: <th>Duration</th> followed by <td>duration_text</td>
@@ -318,171 +643,185 @@ func (c *Client) GetTestStatus(uuid string) (*TestStatus, error) {
 	} else if matches := durationMarkdownRegex.FindStringSubmatch(bodyStr); len(matches) > 1 {
 		// Fallback to Markdown format
 		status.Duration = strings.TrimSpace(matches[1])
+	} else {
+		c.logger.Debug("field not found in response", "field", "duration", "regex", durationHTMLRegex.String())
+	}
+	if status.Duration != "" {
+		if d, err := autopkgtest.ParseDuration(status.Duration); err == nil {
+			status.DurationParsed = d
+		} else {
+			c.logger.Debug("could not parse duration", "duration", status.Duration, "error", err)
+		}
 	}
 
+	// Extract the testbed and cloud region when the run page lists them
+	// HTML format: <th>Testbed</th> followed by <td>testbed_name</td>
+	testbedHTMLRegex := regexp.MustCompile(`(?s)<th>Testbed</th>\s*<td[^>]*>([^<]+)</td>`)
+	status.Testbed = strings.TrimSpace(c.extractField(testbedHTMLRegex, bodyStr, "testbed"))
+
+	regionHTMLRegex := regexp.MustCompile(`(?s)<th>(?:Cloud )?[Rr]egion</th>\s*<td[^>]*>([^<]+)</td>`)
+	status.Region = strings.TrimSpace(c.extractField(regionHTMLRegex, bodyStr, "region"))
+
 	return status, nil
 }
 
-// FindRunningTest attempts to find the UUID of a currently running test
-// by checking the running tests page for the given package/release/arch combination
-func (c *Client) FindRunningTest(packageName, release, arch string) (string, error) {
-	// Try the main package page first (without release/arch) - shows running tests
-	packagesURL := fmt.Sprintf("%s/packages/%s", c.baseURL, packageName)
+// parseStatus is the default status parser. It reads the Result row of an
+// autopkgtest.ubuntu.com run page, falling back to the in-progress markers
+// shown before a result is available.
+func parseStatus(body string) autopkgtest.Status {
+	// Determine test status based on the Result field in the page
+	// The HTML structure is: <th>Result</th> followed by <td class="...">status_text</td>
+	// Also support the Markdown table format for backward compatibility: | Result | status |
+	resultHTMLRegex := regexp.MustCompile(`(?s)<th>Result</th>\s*<td[^>]*>([^<]+)</td>`)
+	resultMarkdownRegex := regexp.MustCompile(`(?i)\|\s*Result\s*\|([^|]*)\|`)
 
-	resp, err := c.httpClient.Get(packagesURL)
-	if err != nil {
-		return "", fmt.Errorf("failed to fetch packages page: %w", err)
-	}
-	defer resp.Body.Close()
+	var resultValue string
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+	// Try HTML format first (the actual format used by the website)
+	if matches := resultHTMLRegex.FindStringSubmatch(body); len(matches) > 1 {
+		resultValue = strings.TrimSpace(matches[1])
+	} else if matches := resultMarkdownRegex.FindStringSubmatch(body); len(matches) > 1 {
+		// Fallback to Markdown table format (for backward compatibility with tests)
+		resultValue = strings.TrimSpace(matches[1])
 	}
 
-	bodyStr := string(body)
-
-	// Look for running tests - they have "Running for:" text near them
-	// Split into lines and search for UUID patterns near "Running for:"
-	// The HTML structure may have <th>UUID:</th> on one line and <td>uuid-value</td> on the next
-	uuidHeaderRegex := regexp.MustCompile(`<th>UUID:</th>`)
-	uuidValueRegex := regexp.MustCompile(`<td>([a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12})</td>`)
-	runningForRegex := regexp.MustCompile(`Running for:`)
-
-	lines := strings.Split(bodyStr, "\n")
-	for i, line := range lines {
-		// Look for "Running for:" indicator
-		if runningForRegex.MatchString(line) {
-			// Search backwards for UUID in the previous ~20 lines
-			for j := i - 1; j >= 0 && j >= i-20; j-- {
-				// Check if this line has <th>UUID:</th>
-				if uuidHeaderRegex.MatchString(lines[j]) {
-					// UUID value might be on the same line or the next line
-					var uuid string
-					uuidLine := j
-
-					// Try current line first
-					if matches := uuidValueRegex.FindStringSubmatch(lines[j]); len(matches) > 1 {
-						uuid = matches[1]
-					} else if j+1 < len(lines) {
-						// Try next line
-						if matches := uuidValueRegex.FindStringSubmatch(lines[j+1]); len(matches) > 1 {
-							uuid = matches[1]
-							uuidLine = j + 1
-						}
-					}
-
-					if uuid == "" {
-						continue
-					}
-
-					// Check if this test matches our release and arch
-					// Look for Release: and Architecture: in nearby lines
-					// The format is: <th>Release:</th> followed by <td>value</td>
-					// These fields are typically 30-50 lines before the UUID
-					contextStart := uuidLine - 50
-					if contextStart < 0 {
-						contextStart = 0
-					}
-					contextEnd := uuidLine + 10
-					if contextEnd >= len(lines) {
-						contextEnd = len(lines) - 1
-					}
-
-					context := strings.Join(lines[contextStart:contextEnd], "\n")
-					// Match the actual HTML structure: <th>Release:</th> possibly followed by newlines then <td>noble</td>
-					// Use (?s) for dot to match newlines, and \s* to allow whitespace/newlines
-					releasePattern := fmt.Sprintf(`<th>Release:</th>[\s\n]*<td>%s</td>`, release)
-					archPattern := fmt.Sprintf(`<th>Architecture:</th>[\s\n]*<td>%s</td>`, arch)
-
-					releaseMatch, _ := regexp.MatchString(releasePattern, context)
-					archMatch, _ := regexp.MatchString(archPattern, context)
-
-					if releaseMatch && archMatch {
-						// Verify it's actually running
-						status, err := c.GetTestStatus(uuid)
-						if err == nil && (status.Status == "running" || status.Status == "queued") {
-							return uuid, nil
-						}
-					}
-				}
-			}
-		}
+	if resultValue != "" {
+		// Status values can be: pass, fail, neutral, tmpfail; a Result row
+		// with anything else is autopkgtest.StatusUnknown
+		return autopkgtest.ParseStatus(resultValue)
 	}
 
-	// Fallback: try the running page
-	runningURL := fmt.Sprintf("%s/running", c.baseURL)
-	resp, err = c.httpClient.Get(runningURL)
-	if err != nil {
-		return "", fmt.Errorf("test not found on running page")
+	// Fallback to checking page content for in-progress states
+	switch {
+	case strings.Contains(body, "In progress"):
+		return autopkgtest.StatusRunning
+	case strings.Contains(body, "Queued"):
+		return autopkgtest.StatusQueued
+	default:
+		return autopkgtest.StatusUnknown
 	}
-	defer resp.Body.Close()
+}
 
-	body, err = io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read running page: %w", err)
-	}
+// WaitForCompletion polls the test status until it completes or times out,
+// in which case the error wraps ErrTimeout
+// pollInterval: how often to check status (e.g., 30s); see WithPollStrategy
+// for making it grow over time
+// timeout: maximum time to wait (e.g., 2h); <= 0 times out as soon as the
+// test is found not complete
+func (c *Client) WaitForCompletion(pkg, uuid string, pollInterval, timeout time.Duration) (*TestStatus, error) {
+	return c.WaitForCompletionFunc(pkg, uuid, pollInterval, timeout, nil)
+}
 
-	bodyStr = string(body)
+// WaitForCompletionFunc is WaitForCompletion with onUpdate called with the
+// status fetched on every poll, including the final one, so that callers can
+// show progress (e.g. queued, then running, then pass). onUpdate may be nil.
+// With WithTmpfailRetry, the returned status may be that of a re-triggered
+// run, with its own UUID.
+func (c *Client) WaitForCompletionFunc(pkg, uuid string, pollInterval, timeout time.Duration, onUpdate func(*TestStatus)) (*TestStatus, error) {
+	return c.waitForCompletion(context.Background(), uuid, pollInterval, immediateTimeout(timeout), onUpdate)
+}
 
-	// Look for matching package/release/arch in running tests
-	if strings.Contains(bodyStr, packageName) {
-		allUUIDRegex := regexp.MustCompile(`/run/([a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12})`)
-		matches := allUUIDRegex.FindAllStringSubmatch(bodyStr, -1)
-		for _, match := range matches {
-			uuid := match[1]
-			// Check if this UUID is for our package/release/arch
-			status, err := c.GetTestStatus(uuid)
-			if err == nil && (status.Status == "running" || status.Status == "queued") {
-				return uuid, nil
-			}
+// immediateTimeout maps a timeout <= 0 given to the public wait functions to
+// the shortest time limit, so that it times out at once as it always has,
+// rather than waiting without a limit as it does for waitForCompletion
+func immediateTimeout(timeout time.Duration) time.Duration {
+	return max(timeout, time.Nanosecond)
+}
+
+// waitForCompletion implements WaitForCompletionFunc, also stopping with
+// ctx.Err() when ctx is done. A timeout <= 0 waits without a time limit, for
+// WatchCompletion and for TriggerOptions.Timeout.
+func (c *Client) waitForCompletion(ctx context.Context, uuid string, pollInterval, timeout time.Duration, onUpdate func(*TestStatus)) (*TestStatus, error) {
+	retries := 0
+	// finish returns the status to return for a final status, or nil if the
+	// test was re-triggered after a tmpfail and its new run must be waited for
+	finish := func(status *TestStatus) (*TestStatus, error) {
+		if status.Status != autopkgtest.StatusTmpfail || retries >= c.tmpfailRetries {
+			status.Retries = retries
+			return status, nil
+		}
+		newUUID, ok, err := c.retrigger(uuid)
+		if err != nil {
+			return nil, err
 		}
+		if !ok {
+			status.Retries = retries
+			return status, nil
+		}
+		retries++
+		uuid = newUUID
+		return nil, nil
 	}
 
-	return "", fmt.Errorf("no running test found for %s/%s/%s", packageName, release, arch)
-}
+	polls := 0
+	poll := func() (*TestStatus, error) {
+		polls++
+		status, err := c.getTestStatus(ctx, uuid)
+		if err != nil {
+			c.logger.Debug("status poll failed", "uuid", uuid, "poll", polls, "error", err)
+			return nil, err
+		}
+		c.logger.Debug("status poll", "uuid", uuid, "poll", polls, "status", status.Status)
+		if onUpdate != nil {
+			onUpdate(status)
+		}
+		return status, nil
+	}
 
-// WaitForCompletion polls the test status until it completes or times out
-// pollInterval: how often to check status (e.g., 30s)
-// timeout: maximum time to wait (e.g., 2h)
-func (c *Client) WaitForCompletion(pkg, uuid string, pollInterval, timeout time.Duration) (*TestStatus, error) {
 	// Check status immediately before starting the polling loop
-	status, err := c.GetTestStatus(uuid)
+	status, err := poll()
 	if err != nil {
 		return nil, err
 	}
 
 	// Check if test is already complete
-	if status.Status == "pass" || status.Status == "fail" || status.Status == "neutral" || status.Status == "tmpfail" {
-		return status, nil
+	if isFinalStatus(status.Status) {
+		if final, err := finish(status); err != nil || final != nil {
+			return final, err
+		}
 	}
 
-	ticker := time.NewTicker(pollInterval)
-	defer ticker.Stop()
+	interval := pollInterval
+	pollTimer := time.NewTimer(interval)
+	defer pollTimer.Stop()
 
-	timeoutTimer := time.NewTimer(timeout)
-	defer timeoutTimer.Stop()
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timeoutTimer := time.NewTimer(timeout)
+		defer timeoutTimer.Stop()
+		timeoutC = timeoutTimer.C
+	}
 
 	for {
 		select {
-		case <-ticker.C:
-			status, err := c.GetTestStatus(uuid)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+
+		case <-pollTimer.C:
+			status, err := poll()
 			if err != nil {
 				return nil, err
 			}
 
 			// Check if test is complete
-			if status.Status == "pass" || status.Status == "fail" || status.Status == "neutral" || status.Status == "tmpfail" {
-				return status, nil
+			if isFinalStatus(status.Status) {
+				if final, err := finish(status); err != nil || final != nil {
+					return final, err
+				}
+				// Poll the re-triggered run from the initial interval again
+				interval = pollInterval
+			} else {
+				interval = c.pollStrategy.next(interval, pollInterval)
 			}
+			pollTimer.Reset(interval)
 
-		case <-timeoutTimer.C:
+		case <-timeoutC:
 			// Timeout reached, return last known status
-			status, err := c.GetTestStatus(uuid)
+			status, err := poll()
 			if err != nil {
-				return nil, fmt.Errorf("timeout reached and failed to get final status: %w", err)
+				return nil, fmt.Errorf("%w and failed to get final status: %w", ErrTimeout, err)
 			}
-			return nil, fmt.Errorf("timeout reached after %v (last status: %s)", timeout, status.Status)
+			return nil, fmt.Errorf("%w after %v (last status: %s)", ErrTimeout, timeout, status.Status)
 		}
 	}
 }
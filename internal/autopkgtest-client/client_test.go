@@ -1,13 +1,26 @@
 package autopkgtestclient
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+	"github.com/canonical/autopkgtest-automation/internal/httpbody"
 )
 
+// testTriggerURL returns a well-formed request.cgi URL on the mock server
+func testTriggerURL(serverURL string) string {
+	return serverURL + "/request.cgi?release=noble&package=ovn&trigger=migration-reference%2F0"
+}
+
 func TestNewClient(t *testing.T) {
 	client, err := NewClient()
 	if err != nil {
@@ -60,7 +73,7 @@ triggers
 		t.Fatalf("NewClient() failed: %v", err)
 	}
 
-	result, err := client.TriggerTest(server.URL)
+	result, err := client.TriggerTest(testTriggerURL(server.URL))
 	if err != nil {
 		t.Fatalf("TriggerTest() failed: %v", err)
 	}
@@ -116,40 +129,69 @@ triggers: migration-reference/0`
 		t.Fatalf("NewClient() failed: %v", err)
 	}
 
-	_, err = client.TriggerTest(server.URL)
+	_, err = client.TriggerTest(testTriggerURL(server.URL))
 	if err == nil {
 		t.Fatal("Expected error for already running test")
 	}
 
-	if !strings.Contains(err.Error(), "already running") {
-		t.Errorf("Expected 'already running' error, got: %v", err)
+	if !errors.Is(err, ErrTestAlreadyRunning) {
+		t.Errorf("Expected ErrTestAlreadyRunning, got: %v", err)
+	}
+
+	var runErr *AlreadyRunningError
+	if !errors.As(err, &runErr) {
+		t.Fatalf("Expected *AlreadyRunningError, got: %T", err)
+	}
+	// The test URL has no arch; it must come from the server response
+	if runErr.Package != "ovn" || runErr.Release != "noble" || runErr.Arch != "amd64" {
+		t.Errorf("Expected ovn/noble/amd64, got %s/%s/%s", runErr.Package, runErr.Release, runErr.Arch)
 	}
 }
 
 func TestTriggerTest_AuthRequired(t *testing.T) {
-	// Mock server that returns login page content
+	// Mock server that redirects unauthenticated requests to the login page
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/login" {
+			http.Redirect(w, r, "/login?next="+r.URL.Path, http.StatusFound)
+			return
+		}
 		response := `<html><body>Please login to continue</body></html>`
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(response))
 	}))
 	defer server.Close()
 
-	// Use the login URL pattern
-	loginURL := server.URL + "/login?next=" + server.URL
-
 	client, err := NewClient()
 	if err != nil {
 		t.Fatalf("NewClient() failed: %v", err)
 	}
 
-	_, err = client.TriggerTest(loginURL)
+	_, err = client.TriggerTest(testTriggerURL(server.URL))
 	if err == nil {
 		t.Fatal("Expected authentication error")
 	}
 
-	if !strings.Contains(err.Error(), "authentication required") {
-		t.Errorf("Expected authentication required error, got: %v", err)
+	if !errors.Is(err, ErrAuthRequired) {
+		t.Errorf("Expected ErrAuthRequired, got: %v", err)
+	}
+}
+
+func TestTriggerTest_Forbidden(t *testing.T) {
+	// Mock server that refuses an expired session with 403 instead of redirecting
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("Forbidden"))
+	}))
+	defer server.Close()
+
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	_, err = client.TriggerTest(testTriggerURL(server.URL))
+	if !errors.Is(err, ErrAuthRequired) {
+		t.Errorf("Expected ErrAuthRequired, got: %v", err)
 	}
 }
 
@@ -176,17 +218,17 @@ func TestTriggerTest_InvalidRequest_WithLogout(t *testing.T) {
 		t.Fatalf("NewClient() failed: %v", err)
 	}
 
-	_, err = client.TriggerTest(server.URL)
+	_, err = client.TriggerTest(testTriggerURL(server.URL))
 	if err == nil {
 		t.Fatal("Expected invalid request error")
 	}
 
 	// Should be invalid request, not authentication error
-	if !strings.Contains(err.Error(), "invalid request") {
-		t.Errorf("Expected 'invalid request' error, got: %v", err)
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest, got: %v", err)
 	}
 
-	if strings.Contains(err.Error(), "authentication") {
+	if errors.Is(err, ErrAuthRequired) {
 		t.Errorf("Should not be authentication error, got: %v", err)
 	}
 
@@ -238,7 +280,7 @@ func TestTriggerTest_SuccessPPA(t *testing.T) {
 	}
 	client.baseURL = server.URL
 
-	result, err := client.TriggerTest(server.URL)
+	result, err := client.TriggerTest(testTriggerURL(server.URL))
 	if err != nil {
 		t.Fatalf("TriggerTest() failed: %v", err)
 	}
@@ -275,6 +317,44 @@ func TestTriggerTest_SuccessPPA(t *testing.T) {
 	}
 }
 
+func TestTriggerTest_SuccessMultiplePPAs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<p><a href="/logout">Logout username</a></p>
+
+<p>Test request submitted.</p>
+<dl>
+<dt>arch</dt>
+<dd>amd64</dd>
+<dt>package</dt>
+<dd>openvswitch</dd>
+<dt>ppas</dt>
+<dd>['username/base', 'username/fixes']</dd>
+<dt>release</dt>
+<dd>resolute</dd>
+</dl>`
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	client.baseURL = server.URL
+
+	result, err := client.TriggerTest(testTriggerURL(server.URL))
+	if err != nil {
+		t.Fatalf("TriggerTest() failed: %v", err)
+	}
+
+	// Results of layered PPA tests are stored under the last PPA
+	expectedURL := server.URL + "/user/username/ppa/fixes"
+	if result.ResultURL != expectedURL {
+		t.Errorf("Expected ResultURL %s, got %s", expectedURL, result.ResultURL)
+	}
+}
+
 func TestGetTestStatus_Running(t *testing.T) {
 	// Mock server that returns running status
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -541,6 +621,133 @@ func TestGetTestStatus_RealHTMLFormat_Pass(t *testing.T) {
 	}
 }
 
+func TestGetTestStatus_TestbedAndRegion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<!DOCTYPE html>
+<html>
+  <body>
+    <table>
+      <tr>
+        <th>Result</th>
+        <td class="nowrap fail"
+            title="fail">fail</td>
+      </tr>
+      <tr>
+        <th>Duration</th>
+        <td>42m 10s</td>
+      </tr>
+      <tr>
+        <th>Testbed</th>
+        <td>juju-7f2b41-prod-proposed-migration-environment-2</td>
+      </tr>
+      <tr>
+        <th>Region</th>
+        <td>bos03-arm64</td>
+      </tr>
+    </table>
+  </body>
+</html>`
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	client.baseURL = server.URL
+
+	status, err := client.GetTestStatus("test-uuid")
+	if err != nil {
+		t.Fatalf("GetTestStatus() failed: %v", err)
+	}
+
+	if status.Testbed != "juju-7f2b41-prod-proposed-migration-environment-2" {
+		t.Errorf("Expected testbed 'juju-7f2b41-prod-proposed-migration-environment-2', got %s", status.Testbed)
+	}
+
+	if status.Region != "bos03-arm64" {
+		t.Errorf("Expected region 'bos03-arm64', got %s", status.Region)
+	}
+}
+
+func TestGetTestStatus_NoTestbedOrRegion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `<table><tr><th>Result</th><td class="pass">pass</td></tr></table>`
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	client.baseURL = server.URL
+
+	status, err := client.GetTestStatus("test-uuid")
+	if err != nil {
+		t.Fatalf("GetTestStatus() failed: %v", err)
+	}
+
+	if status.Testbed != "" || status.Region != "" {
+		t.Errorf("Expected empty testbed and region, got %q and %q", status.Testbed, status.Region)
+	}
+}
+
+func TestGetTestStatus_CustomStatusParser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A modified deployment that reports the outcome in a <div>
+		response := `<div id="outcome">PASSED</div>`
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	var parsedBody string
+	parser := func(body string) autopkgtest.Status {
+		parsedBody = body
+		if strings.Contains(body, `<div id="outcome">PASSED</div>`) {
+			return "pass"
+		}
+		return "unknown"
+	}
+
+	client, err := NewClient(WithStatusParser(parser))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	client.baseURL = server.URL
+
+	status, err := client.GetTestStatus("test-uuid")
+	if err != nil {
+		t.Fatalf("GetTestStatus() failed: %v", err)
+	}
+
+	if status.Status != "pass" {
+		t.Errorf("Expected status 'pass' from custom parser, got %q", status.Status)
+	}
+	if !strings.Contains(parsedBody, "outcome") {
+		t.Errorf("Expected custom parser to receive the page body, got %q", parsedBody)
+	}
+
+	// The built-in parser does not understand this page
+	client, err = NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	client.baseURL = server.URL
+
+	status, err = client.GetTestStatus("test-uuid")
+	if err != nil {
+		t.Fatalf("GetTestStatus() failed: %v", err)
+	}
+	if status.Status != "unknown" {
+		t.Errorf("Expected status 'unknown' from built-in parser, got %q", status.Status)
+	}
+}
+
 func TestWaitForCompletion(t *testing.T) {
 	// Track number of requests to simulate test progression
 	requestCount := 0
@@ -579,6 +786,51 @@ func TestWaitForCompletion(t *testing.T) {
 	}
 }
 
+func TestWaitForCompletionFunc(t *testing.T) {
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		var response string
+		switch {
+		case requestCount == 1:
+			response = `Test is queued`
+		case requestCount <= 3:
+			response = `Test In progress...`
+		default:
+			response = `| Result | ✔ pass |`
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	var updates []autopkgtest.Status
+	status, err := client.WaitForCompletionFunc("testpkg", "test-uuid", 50*time.Millisecond, time.Second, func(s *TestStatus) {
+		updates = append(updates, s.Status)
+	})
+	if err != nil {
+		t.Fatalf("WaitForCompletionFunc() failed: %v", err)
+	}
+
+	if status.Status != "pass" {
+		t.Errorf("Expected final status 'pass', got %s", status.Status)
+	}
+	if len(updates) != requestCount {
+		t.Errorf("Expected one update per poll (%d), got %d: %v", requestCount, len(updates), updates)
+	}
+	if len(updates) == 0 || updates[len(updates)-1] != "pass" {
+		t.Errorf("Expected the final status to be reported, got %v", updates)
+	}
+	if !slices.Contains(updates, "running") {
+		t.Errorf("Expected a running update, got %v", updates)
+	}
+}
+
 func TestWaitForCompletion_Timeout(t *testing.T) {
 	// Server always returns "running"
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -602,6 +854,29 @@ func TestWaitForCompletion_Timeout(t *testing.T) {
 	if !strings.Contains(err.Error(), "timeout") {
 		t.Errorf("Expected timeout error, got: %v", err)
 	}
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected error to wrap ErrTimeout, got: %v", err)
+	}
+}
+
+func TestWaitForCompletion_ZeroTimeout(t *testing.T) {
+	client := newWaitServer(t)
+
+	// A timeout <= 0 times out after the first poll, but a completed test
+	// is still returned
+	start := time.Now()
+	_, err := client.WaitForCompletion("ovn", "slow", time.Minute, 0)
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected error to wrap ErrTimeout, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected an immediate timeout, took %v", elapsed)
+	}
+
+	status, err := client.WaitForCompletion("ovn", "fast", time.Minute, -time.Second)
+	if err != nil || status.Status != autopkgtest.StatusPass {
+		t.Errorf("Expected the completed test to pass, got %+v, %v", status, err)
+	}
 }
 
 func TestWithCookies(t *testing.T) {
@@ -695,3 +970,516 @@ func TestFindRunningTest_NotFound(t *testing.T) {
 		t.Fatal("Expected error when no running test found")
 	}
 }
+
+func TestWithHeader(t *testing.T) {
+	var gotToken []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotToken = r.Header.Values("X-Gateway-Token")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithHeader("X-Gateway-Token", "secret"),
+		WithHeader("X-Gateway-Token", "other"),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	client.baseURL = server.URL
+
+	if _, err := client.GetTestStatus("test-uuid"); err != nil {
+		t.Fatalf("GetTestStatus() failed: %v", err)
+	}
+
+	if len(gotToken) != 2 || gotToken[0] != "secret" || gotToken[1] != "other" {
+		t.Errorf("Expected X-Gateway-Token headers [secret other], got %v", gotToken)
+	}
+}
+
+func TestTriggerTest_Throttled(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		body       string
+		wantRetry  time.Duration
+	}{
+		{
+			name:       "429 with Retry-After header",
+			status:     http.StatusTooManyRequests,
+			retryAfter: "120",
+			body:       `<html><body>Logout testuser<p>Too many requests</p></body></html>`,
+			wantRetry:  2 * time.Minute,
+		},
+		{
+			name:      "throttle page with hint in body",
+			status:    http.StatusOK,
+			body:      `<html><body>Logout testuser<p>You submitted an invalid request: Your test requests are throttled, please try again in 15 minutes</p></body></html>`,
+			wantRetry: 15 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client, err := NewClient()
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
+			}
+
+			_, err = client.TriggerTest(testTriggerURL(server.URL))
+			if !errors.Is(err, ErrThrottled) {
+				t.Fatalf("Expected ErrThrottled, got: %v", err)
+			}
+
+			var throttled *ThrottledError
+			if !errors.As(err, &throttled) {
+				t.Fatalf("Expected *ThrottledError, got %T", err)
+			}
+			if throttled.RetryAfter != tt.wantRetry {
+				t.Errorf("Expected RetryAfter %v, got %v", tt.wantRetry, throttled.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestValidateTriggerURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			url:  "https://autopkgtest.ubuntu.com/request.cgi?release=noble&package=ovn&trigger=migration-reference%2F0&arch=amd64",
+		},
+		{
+			name:    "unsupported scheme",
+			url:     "ftp://autopkgtest.ubuntu.com/request.cgi?release=noble&package=ovn&trigger=ovn%2F1.0",
+			wantErr: "unsupported scheme",
+		},
+		{
+			name:    "missing host",
+			url:     "https:///request.cgi?release=noble&package=ovn&trigger=ovn%2F1.0",
+			wantErr: "missing host",
+		},
+		{
+			name:    "wrong path",
+			url:     "https://autopkgtest.ubuntu.com/packages/ovn?release=noble&package=ovn&trigger=ovn%2F1.0",
+			wantErr: "not request.cgi",
+		},
+		{
+			name:    "missing release",
+			url:     "https://autopkgtest.ubuntu.com/request.cgi?package=ovn&trigger=ovn%2F1.0",
+			wantErr: "missing release",
+		},
+		{
+			name:    "missing package",
+			url:     "https://autopkgtest.ubuntu.com/request.cgi?release=noble&trigger=ovn%2F1.0",
+			wantErr: "missing package",
+		},
+		{
+			name:    "empty trigger",
+			url:     "https://autopkgtest.ubuntu.com/request.cgi?release=noble&package=ovn&trigger=",
+			wantErr: "missing trigger",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTriggerURL(tt.url)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTriggerTest_MalformedURL(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	_, err = client.TriggerTest(server.URL + "/request.cgi?package=ovn")
+	if err == nil {
+		t.Fatal("Expected error for malformed trigger URL")
+	}
+
+	if requests != 0 {
+		t.Errorf("Expected no request to be sent for a malformed URL, got %d", requests)
+	}
+}
+
+// countingTransport counts requests before passing them to the default transport
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestWithTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Test request submitted.\nUUID\n    ae232d9f-08bd-4e36-90b7-7e3811776a64\n"))
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	client, err := NewClient(WithTransport(transport))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	defer client.Close()
+	client.baseURL = server.URL
+
+	if _, err := client.TriggerTest(testTriggerURL(server.URL)); err != nil {
+		t.Fatalf("TriggerTest() failed: %v", err)
+	}
+
+	if transport.requests != 1 {
+		t.Errorf("Expected 1 request through the custom transport, got %d", transport.requests)
+	}
+	if client.httpClient.Jar == nil || client.httpClient.Timeout != 30*time.Second {
+		t.Error("Expected WithTransport to keep the cookie jar and timeout")
+	}
+}
+
+func TestWithMaxResponseBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Test request submitted.\nUUID\n    ae232d9f-08bd-4e36-90b7-7e3811776a64\n"))
+		w.Write([]byte(strings.Repeat("padding\n", 100)))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL), WithMaxResponseBytes(100))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.TriggerTest(testTriggerURL(server.URL)); !errors.Is(err, httpbody.ErrResponseTooLarge) {
+		t.Errorf("Expected error to wrap httpbody.ErrResponseTooLarge, got: %v", err)
+	}
+
+	// Zero restores the default limit, far above any real page
+	client, err = NewClient(WithBaseURL(server.URL), WithMaxResponseBytes(0))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.TriggerTest(testTriggerURL(server.URL)); err != nil {
+		t.Errorf("TriggerTest() failed: %v", err)
+	}
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	transport := &countingTransport{}
+	hc := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+
+	client, err := NewClient(
+		WithCookies([]*http.Cookie{{Name: "session", Value: "abc"}}),
+		WithHTTPClient(hc),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if client.httpClient.Transport != transport || client.httpClient.Timeout != 5*time.Second {
+		t.Error("Expected the injected client's transport and timeout to be used")
+	}
+	if hc.Jar != nil {
+		t.Error("Expected the injected client not to be modified")
+	}
+
+	// The client's own jar is kept, with the cookies set before
+	u, _ := url.Parse(client.baseURL)
+	if cookies := client.httpClient.Jar.Cookies(u); len(cookies) != 1 || cookies[0].Value != "abc" {
+		t.Errorf("Expected session cookie to be kept, got %v", cookies)
+	}
+}
+
+func TestWithBaseURL(t *testing.T) {
+	var paths, sessions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if c, err := r.Cookie("session"); err == nil {
+			sessions = append(sessions, c.Value)
+		}
+		if strings.HasPrefix(r.URL.Path, "/packages/") {
+			w.Write([]byte(`<table>
+				<tr><th>Release:</th><td>noble</td></tr>
+				<tr><th>Architecture:</th><td>amd64</td></tr>
+				<tr><th>UUID:</th><td>12345678-1234-1234-1234-123456789abc</td></tr>
+				<tr><th>Running for:</th><td>1h 30m</td></tr>
+			</table>`))
+			return
+		}
+		w.Write([]byte(`Test In progress...`))
+	}))
+	defer server.Close()
+
+	// Cookies given before the base URL must still be sent to it
+	client, err := NewClient(
+		WithCookies([]*http.Cookie{{Name: "session", Value: "staging"}}),
+		WithBaseURL(server.URL+"/"),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if client.baseURL != server.URL {
+		t.Errorf("Expected base URL %s without trailing slash, got %s", server.URL, client.baseURL)
+	}
+
+	uuid, err := client.FindRunningTest("ovn", "noble", "amd64")
+	if err != nil {
+		t.Fatalf("FindRunningTest() failed: %v", err)
+	}
+	status, err := client.GetTestStatus(uuid)
+	if err != nil {
+		t.Fatalf("GetTestStatus() failed: %v", err)
+	}
+
+	if !strings.HasPrefix(status.LogURL, server.URL+"/run/") {
+		t.Errorf("Expected status URL on the configured base URL, got %s", status.LogURL)
+	}
+	if len(paths) < 2 || !strings.HasPrefix(paths[0], "/packages/ovn") || paths[len(paths)-1] != "/run/"+uuid {
+		t.Errorf("Expected requests to the configured base URL, got %v", paths)
+	}
+	if len(sessions) != len(paths) {
+		t.Errorf("Expected the session cookie on every request, got %v for %v", sessions, paths)
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`Test In progress...`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL), WithRateLimit(10))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	// The first request goes out immediately, the next two wait 100ms each
+	start := time.Now()
+	for range 3 {
+		if _, err := client.GetTestStatus("test-uuid"); err != nil {
+			t.Fatalf("GetTestStatus() failed: %v", err)
+		}
+	}
+	elapsed := time.Since(start)
+
+	if requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests)
+	}
+	if elapsed < 180*time.Millisecond {
+		t.Errorf("Expected requests to be spaced by the rate limit, took %v", elapsed)
+	}
+}
+
+func TestWithRateLimit_Cancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/run/hanging" {
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+			return
+		}
+		w.Write([]byte(`Test In progress...`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	// One request per minute: the second poll waits for the limiter
+	client, err := NewClient(WithBaseURL(server.URL), WithRateLimit(1.0/60))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err = client.waitForCompletion(ctx, "test-uuid", time.Millisecond, 0, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the rate limited poll to be cancelled, got: %v", err)
+	}
+
+	// A request in flight is cancelled too
+	client, err = NewClient(WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = client.waitForCompletion(ctx, "hanging", time.Millisecond, 0, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the request in flight to be cancelled, got: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected cancellation to stop waiting at once, took %v", elapsed)
+	}
+}
+
+func TestWithRateLimit_Disabled(t *testing.T) {
+	client, err := NewClient(WithRateLimit(5), WithRateLimit(0))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	if client.limiter != nil {
+		t.Error("Expected a rate of 0 to disable the limiter")
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/run/") {
+			w.Write([]byte(`<table><tr><th>Result</th><td>pass</td></tr></table>`))
+			return
+		}
+		// A confirmation whose UUID is no longer in the expected markup
+		w.Write([]byte(`<p>Test request submitted.</p>
+<dl>
+<dt>arch</dt>
+<dd>amd64</dd>
+<dt>package</dt>
+<dd>ovn</dd>
+<dt>Request ID</dt>
+<dd>12345678-1234-1234-1234-123456789abc</dd>
+</dl>`))
+	}))
+	defer server.Close()
+
+	var logs strings.Builder
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client, err := NewClient(WithBaseURL(server.URL), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if _, err := client.TriggerTest(testTriggerURL(server.URL)); err == nil {
+		t.Fatal("Expected error when the UUID cannot be parsed")
+	}
+	if _, err := client.GetTestStatus("12345678-1234-1234-1234-123456789abc"); err != nil {
+		t.Fatalf("GetTestStatus() failed: %v", err)
+	}
+
+	output := logs.String()
+	for _, want := range []string{
+		"msg=\"request done\"",
+		"status=200",
+		"msg=\"trigger response is a submission confirmation\"",
+		"msg=\"field not found in response\" field=uuid regex=",
+		"msg=\"parsed test status\" uuid=12345678-1234-1234-1234-123456789abc status=pass",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected logs to contain %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "field=package") {
+		t.Errorf("Expected no miss logged for the package field, got:\n%s", output)
+	}
+}
+
+func TestWithLogger_DefaultDiscards(t *testing.T) {
+	client, err := NewClient(WithLogger(nil))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	if client.logger == nil || client.logger.Enabled(context.Background(), slog.LevelError) {
+		t.Error("Expected a logger discarding every record")
+	}
+}
+
+func TestTriggerTest_PartialResultWithoutUUID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<p>Test request submitted.</p>
+<dl>
+<dt>arch</dt>
+<dd>amd64</dd>
+<dt>package</dt>
+<dd>ovn</dd>
+<dt>release</dt>
+<dd>noble</dd>
+<dt>Request ID</dt>
+<dd>12345678-1234-1234-1234-123456789abc</dd>
+</dl>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	result, err := client.TriggerTest(testTriggerURL(server.URL))
+	if !errors.Is(err, ErrUUIDNotFound) {
+		t.Fatalf("Expected ErrUUIDNotFound, got %v", err)
+	}
+	if result == nil {
+		t.Fatal("Expected the partially parsed result to be returned")
+	}
+	if result.UUID != "" || result.Package != "ovn" || result.Release != "noble" || result.Arch != "amd64" {
+		t.Errorf("Expected package, release and arch without UUID, got %+v", result)
+	}
+}
+
+func TestTriggerTest_PartialResultInvalidPPA(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<p>Test request submitted.</p>
+<dl>
+<dt>package</dt>
+<dd>ovn</dd>
+<dt>ppas</dt>
+<dd>['not-a-ppa']</dd>
+</dl>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	result, err := client.TriggerTest(testTriggerURL(server.URL))
+	if !errors.Is(err, ErrUUIDNotFound) || !strings.Contains(err.Error(), "not-a-ppa") {
+		t.Fatalf("Expected ErrUUIDNotFound mentioning the PPA, got %v", err)
+	}
+	if result == nil || result.Package != "ovn" {
+		t.Errorf("Expected the partially parsed result, got %+v", result)
+	}
+}
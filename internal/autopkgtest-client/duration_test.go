@@ -0,0 +1,31 @@
+package autopkgtestclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGetTestStatus_DurationParsed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<table><tr><th>Result</th><td>pass</td></tr><tr><th>Duration</th><td>15m 32s</td></tr></table>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	status, err := client.GetTestStatus("12345678-1234-1234-1234-123456789abc")
+	if err != nil {
+		t.Fatalf("GetTestStatus() failed: %v", err)
+	}
+	if status.Duration != "15m 32s" {
+		t.Errorf("Expected duration '15m 32s', got %q", status.Duration)
+	}
+	if want := 15*time.Minute + 32*time.Second; status.DurationParsed != want {
+		t.Errorf("Expected parsed duration %v, got %v", want, status.DurationParsed)
+	}
+}
@@ -0,0 +1,132 @@
+package autopkgtestclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/canonical/autopkgtest-automation/internal/httpbody"
+)
+
+// GetTestLog returns the full plain-text log of the test run with the given
+// UUID. The log is only available once the test has finished.
+func (c *Client) GetTestLog(uuid string) (string, error) {
+	runURL := fmt.Sprintf("%s/run/%s", c.baseURL, uuid)
+
+	resp, err := c.get(context.Background(), runURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch run page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch run page: unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := httpbody.Read(resp, c.maxResponseBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to read run page: %w", err)
+	}
+
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse run page: %w", err)
+	}
+	href := findLogLink(doc)
+	if href == "" {
+		return "", fmt.Errorf("no log available for test %s (it may still be running)", uuid)
+	}
+
+	base, err := url.Parse(runURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid run URL: %w", err)
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("invalid log URL %q: %w", href, err)
+	}
+
+	return c.fetchLog(base.ResolveReference(ref).String())
+}
+
+// findLogLink returns the href of the first <a> whose target is a log.gz file
+func findLogLink(n *html.Node) string {
+	if n.Type == html.ElementNode && n.Data == "a" {
+		for _, attr := range n.Attr {
+			if attr.Key == "href" && strings.HasSuffix(attr.Val, "log.gz") {
+				return attr.Val
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if href := findLogLink(c); href != "" {
+			return href
+		}
+	}
+	return ""
+}
+
+// GetTestLogTail returns the last lines lines of the log of the test run
+// with the given UUID
+func (c *Client) GetTestLogTail(uuid string, lines int) (string, error) {
+	log, err := c.GetTestLog(uuid)
+	if err != nil {
+		return "", err
+	}
+	return tailLines(log, lines), nil
+}
+
+// fetchLog downloads a log artifact. log.gz files are usually served with
+// Content-Encoding: gzip and decompressed by the transport, but are
+// decompressed here when the raw gzip data is returned instead. Both the
+// response and the decompressed log are limited as WithMaxResponseBytes
+// sets.
+func (c *Client) fetchLog(logURL string) (string, error) {
+	resp, err := c.get(context.Background(), logURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch log: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch log: unexpected status code: %d", resp.StatusCode)
+	}
+
+	data, err := httpbody.Read(resp, c.maxResponseBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to read log: %w", err)
+	}
+
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress log: %w", err)
+		}
+		defer zr.Close()
+		if data, err = httpbody.ReadAll(zr, c.maxResponseBytes); err != nil {
+			return "", fmt.Errorf("failed to decompress log: %w", err)
+		}
+	}
+
+	return string(data), nil
+}
+
+// tailLines returns the last n lines of text (all of it if n <= 0 or text
+// has fewer lines)
+func tailLines(text string, n int) string {
+	text = strings.TrimRight(text, "\n")
+	if n <= 0 {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) <= n {
+		return text
+	}
+	return strings.Join(lines[len(lines)-n:], "\n")
+}
@@ -0,0 +1,166 @@
+package autopkgtestclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/canonical/autopkgtest-automation/internal/httpbody"
+)
+
+const mockRunPage = `<table>
+<tr><th>Result</th><td class="fail">fail</td></tr>
+<tr><th>Log</th><td><a href="/results/autopkgtest-noble/noble/amd64/o/ovn/20260202_153743_38f00@/log.gz">log.gz</a></td></tr>
+</table>`
+
+const mockLog = "autopkgtest [15:37:43]: starting date and time\nline 2\nline 3\nautopkgtest [16:58:08]: @@@@@@@@@@@@@@@@@@@@ summary\nsystem-tests FAIL non-zero exit status 1\n"
+
+func TestGetTestLog(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(mockLog))
+	zw.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/run/test-uuid":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockRunPage))
+		case "/results/autopkgtest-noble/noble/amd64/o/ovn/20260202_153743_38f00@/log.gz":
+			// Served as raw gzip data, without Content-Encoding
+			w.Header().Set("Content-Type", "application/gzip")
+			w.WriteHeader(http.StatusOK)
+			w.Write(compressed.Bytes())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	client.baseURL = server.URL
+
+	log, err := client.GetTestLog("test-uuid")
+	if err != nil {
+		t.Fatalf("GetTestLog() failed: %v", err)
+	}
+	if log != mockLog {
+		t.Errorf("Expected decompressed log %q, got %q", mockLog, log)
+	}
+
+	tail, err := client.GetTestLogTail("test-uuid", 2)
+	if err != nil {
+		t.Fatalf("GetTestLogTail() failed: %v", err)
+	}
+	want := "autopkgtest [16:58:08]: @@@@@@@@@@@@@@@@@@@@ summary\nsystem-tests FAIL non-zero exit status 1"
+	if tail != want {
+		t.Errorf("Expected tail %q, got %q", want, tail)
+	}
+}
+
+func TestGetTestLog_LinkMarkup(t *testing.T) {
+	// The link is found whatever the quoting and attribute order, and links
+	// to other artifacts are ignored
+	runPage := `<table>
+<tr><th>Artifacts</th><td><a class=artifact href='/results/artifacts.tar.gz'>artifacts</a></td></tr>
+<tr><th>Log</th><td><A title="log" HREF='/results/noble/amd64/o/ovn/log.gz'>log.gz</A></td></tr>
+</table>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/run/test-uuid":
+			w.Write([]byte(runPage))
+		case "/results/noble/amd64/o/ovn/log.gz":
+			w.Write([]byte(mockLog))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	log, err := client.GetTestLog("test-uuid")
+	if err != nil {
+		t.Fatalf("GetTestLog() failed: %v", err)
+	}
+	if log != mockLog {
+		t.Errorf("Expected log %q, got %q", mockLog, log)
+	}
+}
+
+func TestGetTestLog_TooLarge(t *testing.T) {
+	// A small gzip stream that inflates past the limit, and a plain log
+	// that is already too large
+	large := strings.Repeat("x", 64<<10)
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(large))
+	zw.Close()
+
+	for name, body := range map[string][]byte{"gzip": compressed.Bytes(), "plain": []byte(large)} {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/run/test-uuid":
+					w.Write([]byte(mockRunPage))
+				default:
+					w.Write(body)
+				}
+			}))
+			defer server.Close()
+
+			client, err := NewClient(WithBaseURL(server.URL), WithMaxResponseBytes(4<<10))
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
+			}
+			if _, err := client.GetTestLog("test-uuid"); !errors.Is(err, httpbody.ErrResponseTooLarge) {
+				t.Errorf("Expected ErrResponseTooLarge, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestGetTestLog_NotAvailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`<p>In progress</p>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	client.baseURL = server.URL
+
+	_, err = client.GetTestLog("test-uuid")
+	if err == nil || !strings.Contains(err.Error(), "no log available") {
+		t.Errorf("Expected 'no log available' error, got: %v", err)
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	tests := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{"a\nb\nc\n", 2, "b\nc"},
+		{"a\nb\n", 5, "a\nb"},
+		{"a\nb\n", 0, "a\nb"},
+	}
+	for _, tt := range tests {
+		if got := tailLines(tt.text, tt.n); got != tt.want {
+			t.Errorf("tailLines(%q, %d): expected %q, got %q", tt.text, tt.n, tt.want, got)
+		}
+	}
+}
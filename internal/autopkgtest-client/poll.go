@@ -0,0 +1,50 @@
+package autopkgtestclient
+
+import "time"
+
+// PollMode selects how the interval between the status polls of
+// WaitForCompletion changes over time
+type PollMode int
+
+const (
+	// PollFixed waits the same poll interval between every poll (default)
+	PollFixed PollMode = iota
+	// PollLinear adds the initial poll interval after every poll
+	PollLinear
+	// PollExponential doubles the interval after every poll
+	PollExponential
+)
+
+// PollStrategy controls the interval between the status polls of
+// WaitForCompletion. The first interval is the pollInterval given to
+// WaitForCompletion; with PollLinear or PollExponential it then grows up to
+// MaxInterval, so that long tests are polled often at first and less so later.
+type PollStrategy struct {
+	Mode        PollMode
+	MaxInterval time.Duration // Cap on the interval (zero for no cap)
+}
+
+// WithPollStrategy sets how the poll interval of WaitForCompletion grows.
+// The default is a fixed interval.
+func WithPollStrategy(strategy PollStrategy) ClientOption {
+	return func(c *Client) {
+		c.pollStrategy = strategy
+	}
+}
+
+// next returns the interval to wait after waiting interval, base being the
+// initial poll interval
+func (p PollStrategy) next(interval, base time.Duration) time.Duration {
+	switch p.Mode {
+	case PollLinear:
+		interval += base
+	case PollExponential:
+		interval *= 2
+	default:
+		return interval
+	}
+	if p.MaxInterval > 0 && interval > p.MaxInterval {
+		return max(p.MaxInterval, base)
+	}
+	return interval
+}
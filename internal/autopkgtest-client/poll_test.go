@@ -0,0 +1,70 @@
+package autopkgtestclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPollStrategyNext(t *testing.T) {
+	base := 10 * time.Second
+
+	tests := []struct {
+		name     string
+		strategy PollStrategy
+		want     []time.Duration
+	}{
+		{name: "fixed", strategy: PollStrategy{}, want: []time.Duration{10 * time.Second, 10 * time.Second, 10 * time.Second}},
+		{name: "fixed ignores cap", strategy: PollStrategy{Mode: PollFixed, MaxInterval: 5 * time.Second}, want: []time.Duration{10 * time.Second, 10 * time.Second}},
+		{name: "linear", strategy: PollStrategy{Mode: PollLinear}, want: []time.Duration{20 * time.Second, 30 * time.Second, 40 * time.Second}},
+		{name: "linear capped", strategy: PollStrategy{Mode: PollLinear, MaxInterval: 25 * time.Second}, want: []time.Duration{20 * time.Second, 25 * time.Second, 25 * time.Second}},
+		{name: "exponential", strategy: PollStrategy{Mode: PollExponential}, want: []time.Duration{20 * time.Second, 40 * time.Second, 80 * time.Second}},
+		{name: "exponential capped", strategy: PollStrategy{Mode: PollExponential, MaxInterval: time.Minute}, want: []time.Duration{20 * time.Second, 40 * time.Second, time.Minute, time.Minute}},
+		{name: "cap below base", strategy: PollStrategy{Mode: PollExponential, MaxInterval: time.Second}, want: []time.Duration{10 * time.Second, 10 * time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []time.Duration
+			interval := base
+			for range tt.want {
+				interval = tt.strategy.next(interval, base)
+				got = append(got, interval)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Expected intervals %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWaitForCompletion_PollStrategy(t *testing.T) {
+	countPolls := func(opts ...ClientOption) int32 {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.Write([]byte(`Test In progress...`))
+		}))
+		defer server.Close()
+
+		client, err := NewClient(append(opts, WithBaseURL(server.URL))...)
+		if err != nil {
+			t.Fatalf("NewClient() failed: %v", err)
+		}
+		if _, err := client.WaitForCompletion("testpkg", "test-uuid", 10*time.Millisecond, 300*time.Millisecond); err == nil {
+			t.Fatal("Expected timeout error")
+		}
+		return requests.Load()
+	}
+
+	fixed := countPolls()
+	exponential := countPolls(WithPollStrategy(PollStrategy{Mode: PollExponential, MaxInterval: 80 * time.Millisecond}))
+
+	// Fixed: ~30 polls; exponential: 10, 20, 40, 80, 80... ms, i.e. ~7 polls
+	if exponential >= fixed/2 {
+		t.Errorf("Expected exponential polling to make far fewer requests than fixed (%d), got %d", fixed, exponential)
+	}
+}
@@ -0,0 +1,185 @@
+package autopkgtestclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/canonical/autopkgtest-automation/internal/httpbody"
+)
+
+// ErrNotQueued is returned by GetQueuePosition when the test is not waiting
+// in any queue (it is already running, finished, or unknown)
+var ErrNotQueued = errors.New("test is not queued")
+
+// ErrQueuesUnavailable is returned when the /running page cannot be reached
+// or the server fails to render it, which happens for short periods while
+// it is under load; retrying later usually works
+var ErrQueuesUnavailable = errors.New("queue page temporarily unavailable")
+
+// queueLengthRegex matches the request count in a queue heading, e.g.
+// "Queued requests for ubuntu noble/amd64 (340)"
+var queueLengthRegex = regexp.MustCompile(`\((\d+)\)\s*$`)
+
+// queueNameRegex matches the release/arch of a queue heading, e.g.
+// "noble/amd64" in "Queued requests for ubuntu noble/amd64 (340)"
+var queueNameRegex = regexp.MustCompile(`([a-z][a-z0-9-]*)/([a-z0-9]+)\s*(?:\(\d+\))?\s*$`)
+
+// queueSection is one queue of the /running page: its heading and entries
+type queueSection struct {
+	name    string // "release/arch" from the heading, "" if not found
+	length  int    // Length announced in the heading, 0 if none
+	entries []string
+}
+
+// size returns the number of requests in the queue. Long queues may be
+// truncated on the page, so the length given in the heading is preferred.
+func (q queueSection) size() int {
+	return max(q.length, len(q.entries))
+}
+
+// GetQueuePosition returns the 1-based position of the test with the given
+// UUID in its release/arch queue on /running, and the length of that queue.
+// It returns ErrNotQueued if the test is in none of the queues.
+func (c *Client) GetQueuePosition(uuid string) (int, int, error) {
+	sections, err := c.fetchQueues()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, section := range sections {
+		for i, entry := range section.entries {
+			if strings.Contains(entry, uuid) {
+				return i + 1, section.size(), nil
+			}
+		}
+	}
+
+	return 0, 0, fmt.Errorf("%w: %s", ErrNotQueued, uuid)
+}
+
+// GetQueueSizes returns the number of queued requests for each release/arch
+// on /running, keyed by "release/arch" (e.g. "noble/amd64"). Queues of the
+// same release/arch (e.g. the main and the huge queue) are added up. It
+// returns ErrQueuesUnavailable if the page cannot be fetched right now.
+func (c *Client) GetQueueSizes() (map[string]int, error) {
+	sections, err := c.fetchQueues()
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[string]int)
+	for _, section := range sections {
+		if section.name == "" {
+			c.logger.Debug("queue heading without release/arch", "entries", len(section.entries))
+			continue
+		}
+		sizes[section.name] += section.size()
+	}
+	return sizes, nil
+}
+
+// fetchQueues fetches /running and parses its queues. Network errors and
+// server errors are reported as ErrQueuesUnavailable.
+func (c *Client) fetchQueues() ([]queueSection, error) {
+	runningURL := fmt.Sprintf("%s/running", c.baseURL)
+
+	resp, err := c.get(context.Background(), runningURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to fetch running page: %w", ErrQueuesUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("%w: unexpected status code: %d", ErrQueuesUnavailable, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch running page: unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := httpbody.Read(resp, c.maxResponseBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read running page: %w", ErrQueuesUnavailable, err)
+	}
+
+	return parseQueues(string(body))
+}
+
+// parseQueues extracts the queues of the /running page. A queue starts at a
+// heading mentioning "queue" and holds the list items or table rows that
+// follow it, up to the next heading. Running tests, listed under their own
+// headings, are not part of any queue.
+func parseQueues(htmlContent string) ([]queueSection, error) {
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse running page: %w", err)
+	}
+
+	var sections []queueSection
+	var current *queueSection
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				heading := strings.TrimSpace(nodeText(n))
+				current = nil
+				if strings.Contains(strings.ToLower(heading), "queue") {
+					section := queueSection{}
+					if m := queueLengthRegex.FindStringSubmatch(heading); m != nil {
+						section.length, _ = strconv.Atoi(m[1])
+					}
+					if m := queueNameRegex.FindStringSubmatch(heading); m != nil {
+						section.name = m[1] + "/" + m[2]
+					}
+					sections = append(sections, section)
+					current = &sections[len(sections)-1]
+				}
+				return
+			case "li", "tr":
+				if current != nil && !isHeadingRow(n) {
+					current.entries = append(current.entries, nodeText(n))
+					return
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return sections, nil
+}
+
+// isHeadingRow reports whether n is a table row made only of <th> cells
+func isHeadingRow(n *html.Node) bool {
+	if n.Data != "tr" {
+		return false
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "td" {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeText returns the concatenated text under n
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var text strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		text.WriteString(nodeText(c))
+	}
+	return text.String()
+}
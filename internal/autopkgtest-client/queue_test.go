@@ -0,0 +1,126 @@
+package autopkgtestclient
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const mockRunningPage = `<html><body>
+<h2>Running tests</h2>
+<table>
+  <tr><th>Release:</th><td>noble</td></tr>
+  <tr><th>Architecture:</th><td>amd64</td></tr>
+  <tr><th>UUID:</th><td>aaaaaaaa-1111-1111-1111-111111111111</td></tr>
+</table>
+
+<h2>Queues</h2>
+<h3>Queued requests for ubuntu noble/amd64 (340)</h3>
+<ol>
+  <li><pre>glibc {"triggers": ["glibc/2.39-0ubuntu8"], "uuid": "bbbbbbbb-2222-2222-2222-222222222222"}</pre></li>
+  <li><pre>ovn {"triggers": ["ovn/24.03.2-0ubuntu1"], "uuid": "cccccccc-3333-3333-3333-333333333333"}</pre></li>
+</ol>
+<h3>Queued requests for ubuntu noble/s390x</h3>
+<table>
+  <tr><th>#</th><th>Request</th></tr>
+  <tr><td>1</td><td>systemd {"uuid": "dddddddd-4444-4444-4444-444444444444"}</td></tr>
+  <tr><td>2</td><td>dpkg {"uuid": "eeeeeeee-5555-5555-5555-555555555555"}</td></tr>
+  <tr><td>3</td><td>ovn {"uuid": "ffffffff-6666-6666-6666-666666666666"}</td></tr>
+</table>
+</body></html>`
+
+func TestGetQueuePosition(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/running" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(mockRunningPage))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		uuid      string
+		wantPos   int
+		wantTotal int
+	}{
+		{"length from heading", "cccccccc-3333-3333-3333-333333333333", 2, 340},
+		{"length counted from table rows", "ffffffff-6666-6666-6666-666666666666", 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos, total, err := client.GetQueuePosition(tt.uuid)
+			if err != nil {
+				t.Fatalf("GetQueuePosition() failed: %v", err)
+			}
+			if pos != tt.wantPos || total != tt.wantTotal {
+				t.Errorf("Expected position %d of %d, got %d of %d", tt.wantPos, tt.wantTotal, pos, total)
+			}
+		})
+	}
+
+	// A running test is listed on the page but not queued
+	if _, _, err := client.GetQueuePosition("aaaaaaaa-1111-1111-1111-111111111111"); !errors.Is(err, ErrNotQueued) {
+		t.Errorf("Expected ErrNotQueued for running test, got: %v", err)
+	}
+}
+
+func TestGetQueueSizes(t *testing.T) {
+	page := strings.Replace(mockRunningPage, "</body>", `<h3>Queued requests for huge noble/amd64 (2)</h3>
+<ol><li>linux</li><li>llvm-toolchain-19</li></ol>
+<h3>Queued requests for ubuntu jammy/riscv64 (0)</h3>
+</body>`, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	sizes, err := client.GetQueueSizes()
+	if err != nil {
+		t.Fatalf("GetQueueSizes() failed: %v", err)
+	}
+
+	expected := map[string]int{
+		"noble/amd64":   342, // 340 from the heading, plus the huge queue
+		"noble/s390x":   3,
+		"jammy/riscv64": 0,
+	}
+	if !maps.Equal(sizes, expected) {
+		t.Errorf("Expected %v, got %v", expected, sizes)
+	}
+}
+
+func TestGetQueueSizes_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if _, err := client.GetQueueSizes(); !errors.Is(err, ErrQueuesUnavailable) {
+		t.Errorf("Expected ErrQueuesUnavailable for 503, got: %v", err)
+	}
+
+	// The server being unreachable is reported the same way
+	server.Close()
+	if _, err := client.GetQueueSizes(); !errors.Is(err, ErrQueuesUnavailable) {
+		t.Errorf("Expected ErrQueuesUnavailable for unreachable server, got: %v", err)
+	}
+}
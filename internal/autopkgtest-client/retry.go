@@ -0,0 +1,47 @@
+package autopkgtestclient
+
+import "fmt"
+
+// WithTmpfailRetry makes WaitForCompletion re-trigger a test that ends in
+// tmpfail (an infrastructure failure, not a test failure) up to maxRetries
+// times, and wait for the new run instead. Only tests triggered with this
+// client's TriggerTest can be re-triggered, since their trigger URL is needed.
+// A maxRetries of zero or less disables retrying (the default).
+func WithTmpfailRetry(maxRetries int) ClientOption {
+	return func(c *Client) {
+		c.tmpfailRetries = max(maxRetries, 0)
+	}
+}
+
+// rememberTrigger records the URL the test with the given UUID was triggered
+// with, so that it can be re-triggered after a tmpfail
+func (c *Client) rememberTrigger(uuid, triggerURL string) {
+	c.triggersMu.Lock()
+	defer c.triggersMu.Unlock()
+	if c.triggerURLs == nil {
+		c.triggerURLs = make(map[string]string)
+	}
+	c.triggerURLs[uuid] = triggerURL
+}
+
+// retrigger triggers the test with the given UUID again and returns the UUID
+// of the new run. ok is false if the test was not triggered by this client.
+func (c *Client) retrigger(uuid string) (newUUID string, ok bool, err error) {
+	c.triggersMu.Lock()
+	triggerURL, ok := c.triggerURLs[uuid]
+	c.triggersMu.Unlock()
+	if !ok {
+		c.logger.Debug("cannot retry tmpfail: trigger URL unknown", "uuid", uuid)
+		return "", false, nil
+	}
+
+	c.logger.Debug("retrying after tmpfail", "uuid", uuid, "url", triggerURL)
+	result, err := c.TriggerTest(triggerURL)
+	if err != nil {
+		return "", true, fmt.Errorf("failed to re-trigger test %s after tmpfail: %w", uuid, err)
+	}
+	if result.UUID == "" {
+		return "", true, fmt.Errorf("re-triggered test %s after tmpfail but got no UUID", uuid)
+	}
+	return result.UUID, true, nil
+}
@@ -0,0 +1,138 @@
+package autopkgtestclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTmpfailServer serves a submission confirmation with a new UUID for every
+// trigger request, and reports the first failures runs as tmpfail and later
+// ones as pass
+func newTmpfailServer(t *testing.T, failures int32) (*httptest.Server, *atomic.Int32) {
+	var triggered atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/request.cgi"):
+			n := triggered.Add(1)
+			fmt.Fprintf(w, "Logout testuser\n\nTest request submitted.\n\nUUID\n    00000000-0000-0000-0000-%012d\narch\n    amd64\npackage\n    ovn\nrelease\n    noble\n", n)
+		case strings.HasPrefix(r.URL.Path, "/run/"):
+			var n int32
+			fmt.Sscanf(r.URL.Path, "/run/00000000-0000-0000-0000-%012d", &n)
+			if n <= failures {
+				w.Write([]byte(`<table><tr><th>Result</th><td>tmpfail</td></tr></table>`))
+			} else {
+				w.Write([]byte(`<table><tr><th>Result</th><td>pass</td></tr></table>`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &triggered
+}
+
+func TestWaitForCompletion_TmpfailRetry(t *testing.T) {
+	server, triggered := newTmpfailServer(t, 2)
+
+	client, err := NewClient(WithBaseURL(server.URL), WithTmpfailRetry(3))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	result, err := client.TriggerTest(testTriggerURL(server.URL))
+	if err != nil {
+		t.Fatalf("TriggerTest() failed: %v", err)
+	}
+
+	status, err := client.WaitForCompletion("ovn", result.UUID, 10*time.Millisecond, time.Second)
+	if err != nil {
+		t.Fatalf("WaitForCompletion() failed: %v", err)
+	}
+
+	if status.Status != "pass" {
+		t.Errorf("Expected final status 'pass', got %s", status.Status)
+	}
+	if status.Retries != 2 {
+		t.Errorf("Expected 2 retries, got %d", status.Retries)
+	}
+	if status.UUID == result.UUID {
+		t.Errorf("Expected the status of the re-triggered run, got the original UUID %s", status.UUID)
+	}
+	if got := triggered.Load(); got != 3 {
+		t.Errorf("Expected 3 trigger requests, got %d", got)
+	}
+}
+
+func TestWaitForCompletion_TmpfailRetryLimit(t *testing.T) {
+	server, triggered := newTmpfailServer(t, 10)
+
+	client, err := NewClient(WithBaseURL(server.URL), WithTmpfailRetry(1))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	result, err := client.TriggerTest(testTriggerURL(server.URL))
+	if err != nil {
+		t.Fatalf("TriggerTest() failed: %v", err)
+	}
+
+	status, err := client.WaitForCompletion("ovn", result.UUID, 10*time.Millisecond, time.Second)
+	if err != nil {
+		t.Fatalf("WaitForCompletion() failed: %v", err)
+	}
+
+	if status.Status != "tmpfail" || status.Retries != 1 {
+		t.Errorf("Expected tmpfail after 1 retry, got %s after %d", status.Status, status.Retries)
+	}
+	if got := triggered.Load(); got != 2 {
+		t.Errorf("Expected 2 trigger requests, got %d", got)
+	}
+}
+
+func TestWaitForCompletion_TmpfailNotRetried(t *testing.T) {
+	server, triggered := newTmpfailServer(t, 10)
+
+	tests := []struct {
+		name string
+		opts []ClientOption
+		uuid string
+	}{
+		{name: "disabled by default", opts: nil},
+		{name: "unknown trigger URL", opts: []ClientOption{WithTmpfailRetry(3)}, uuid: "00000000-0000-0000-0000-000000000001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			triggered.Store(0)
+			client, err := NewClient(append(tt.opts, WithBaseURL(server.URL))...)
+			if err != nil {
+				t.Fatalf("NewClient() failed: %v", err)
+			}
+
+			uuid := tt.uuid
+			if uuid == "" {
+				result, err := client.TriggerTest(testTriggerURL(server.URL))
+				if err != nil {
+					t.Fatalf("TriggerTest() failed: %v", err)
+				}
+				uuid = result.UUID
+			}
+
+			status, err := client.WaitForCompletion("ovn", uuid, 10*time.Millisecond, time.Second)
+			if err != nil {
+				t.Fatalf("WaitForCompletion() failed: %v", err)
+			}
+			if status.Status != "tmpfail" || status.Retries != 0 {
+				t.Errorf("Expected tmpfail without retries, got %s after %d", status.Status, status.Retries)
+			}
+			if got := triggered.Load(); got > 1 {
+				t.Errorf("Expected no re-trigger, got %d trigger requests", got)
+			}
+		})
+	}
+}
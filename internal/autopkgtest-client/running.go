@@ -0,0 +1,189 @@
+package autopkgtestclient
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+	"github.com/canonical/autopkgtest-automation/internal/httpbody"
+)
+
+// runningUUIDRegex matches a test UUID
+var runningUUIDRegex = regexp.MustCompile(`^[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}$`)
+
+// runningEntry is one running test listed on a package page or on /running
+type runningEntry struct {
+	pkg     string // Empty if the page does not say
+	release string
+	arch    string
+	uuid    string
+}
+
+// FindRunningTest attempts to find the UUID of a currently running test
+// by checking the running tests page for the given package/release/arch combination
+func (c *Client) FindRunningTest(packageName, release, arch string) (string, error) {
+	uuids, err := c.FindRunningTests(packageName, release, arch)
+	if err != nil {
+		return "", err
+	}
+	return uuids[0], nil
+}
+
+// FindRunningTests returns the UUIDs of every running or queued test for
+// the given package/release/arch combination, in page order. The package
+// page is checked first, then /running. An error is returned if none is
+// found.
+func (c *Client) FindRunningTests(packageName, release, arch string) ([]string, error) {
+	// The package page only lists tests of that package
+	packagesURL := fmt.Sprintf("%s/packages/%s", c.baseURL, packageName)
+	entries, err := c.fetchRunningEntries(packagesURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch packages page: %w", err)
+	}
+	if uuids := c.activeUUIDs(entries, "", release, arch); len(uuids) > 0 {
+		return uuids, nil
+	}
+
+	// Fallback: try the running page, which lists every package
+	runningURL := fmt.Sprintf("%s/running", c.baseURL)
+	entries, err = c.fetchRunningEntries(runningURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch running page: %w", err)
+	}
+	if uuids := c.activeUUIDs(entries, packageName, release, arch); len(uuids) > 0 {
+		return uuids, nil
+	}
+
+	return nil, fmt.Errorf("no running test found for %s/%s/%s", packageName, release, arch)
+}
+
+// fetchRunningEntries fetches pageURL and parses the running tests it lists
+func (c *Client) fetchRunningEntries(pageURL string) ([]runningEntry, error) {
+	resp, err := c.get(context.Background(), pageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := httpbody.Read(resp, c.maxResponseBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return parseRunningEntries(string(body))
+}
+
+// activeUUIDs returns the UUIDs of entries matching release and arch (and
+// packageName, unless empty) whose test is still running or queued
+func (c *Client) activeUUIDs(entries []runningEntry, packageName, release, arch string) []string {
+	var uuids []string
+	for _, e := range entries {
+		if !strings.EqualFold(e.release, release) || !strings.EqualFold(e.arch, arch) {
+			continue
+		}
+		if packageName != "" && !strings.EqualFold(e.pkg, packageName) {
+			continue
+		}
+		if slices.Contains(uuids, e.uuid) {
+			continue
+		}
+		status, err := c.GetTestStatus(e.uuid)
+		if err == nil && (status.Status == autopkgtest.StatusRunning || status.Status == autopkgtest.StatusQueued) {
+			uuids = append(uuids, e.uuid)
+		}
+	}
+	return uuids
+}
+
+// parseRunningEntries extracts the running tests of a package page or of
+// /running. Each test is a table of "<th>Key:</th><td>value</td>" rows
+// (Release, Architecture, UUID, ...). Its package is given by a Package row
+// if there is one, or else by the heading preceding the table.
+func parseRunningEntries(htmlContent string) ([]runningEntry, error) {
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse running tests: %w", err)
+	}
+
+	var entries []runningEntry
+	var heading string
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				heading = ""
+				if fields := strings.Fields(nodeText(n)); len(fields) > 0 {
+					heading = fields[0]
+				}
+				return
+			case "table":
+				fields := tableFields(n)
+				if uuid := fields["uuid"]; runningUUIDRegex.MatchString(uuid) {
+					pkg := fields["package"]
+					if pkg == "" {
+						pkg = heading
+					}
+					entries = append(entries, runningEntry{
+						pkg:     pkg,
+						release: fields["release"],
+						arch:    fields["architecture"],
+						uuid:    uuid,
+					})
+					return
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return entries, nil
+}
+
+// tableFields returns the key/value rows of table, keyed by the lowercased
+// <th> text without its trailing colon. Rows of nested tables are ignored.
+func tableFields(table *html.Node) map[string]string {
+	fields := make(map[string]string)
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data == "table" {
+				continue
+			}
+			if c.Data != "tr" {
+				walk(c)
+				continue
+			}
+			var key, value string
+			var hasKey, hasValue bool
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type != html.ElementNode {
+					continue
+				}
+				if cell.Data == "th" && !hasKey {
+					key = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(nodeText(cell)), ":"))
+					hasKey = true
+				} else if cell.Data == "td" && hasKey && !hasValue {
+					value = strings.TrimSpace(nodeText(cell))
+					hasValue = true
+				}
+			}
+			if hasKey && hasValue {
+				fields[key] = value
+			}
+		}
+	}
+	walk(table)
+
+	return fields
+}
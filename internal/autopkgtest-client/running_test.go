@@ -0,0 +1,154 @@
+package autopkgtestclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const mockPackageRunningPage = `<html><body>
+<h3>Running tests</h3>
+<table>
+  <tr><th>Release:</th><td>noble</td></tr>
+  <tr><th>Architecture:</th><td>ppc64el</td></tr>
+  <tr><th>UUID:</th><td>11111111-1111-1111-1111-111111111111</td></tr>
+  <tr><th>Running for:</th><td>10m</td></tr>
+</table>
+<table>
+  <tbody>
+    <tr>
+      <th>Release:</th>
+      <td>noble</td>
+    </tr>
+    <tr>
+      <th>Architecture:</th>
+      <td>s390x</td>
+    </tr>
+    <tr>
+      <th>UUID:</th>
+      <td><a href="/run/22222222-2222-2222-2222-222222222222">22222222-2222-2222-2222-222222222222</a></td>
+    </tr>
+  </tbody>
+</table>
+<table>
+  <tr><th>Release:</th><td>noble</td></tr>
+  <tr><th>Architecture:</th><td>s390x</td></tr>
+  <tr><th>UUID:</th><td>33333333-3333-3333-3333-333333333333</td></tr>
+</table>
+<table>
+  <tr><th>Release:</th><td>jammy</td></tr>
+  <tr><th>Architecture:</th><td>s390x</td></tr>
+  <tr><th>UUID:</th><td>44444444-4444-4444-4444-444444444444</td></tr>
+</table>
+</body></html>`
+
+const mockAllRunningPage = `<html><body>
+<h2>systemd</h2>
+<table>
+  <tr><th>Release:</th><td>noble</td></tr>
+  <tr><th>Architecture:</th><td>riscv64</td></tr>
+  <tr><th>UUID:</th><td>55555555-5555-5555-5555-555555555555</td></tr>
+</table>
+<h2><a href="/packages/ovn">ovn</a></h2>
+<table>
+  <tr><th>Release:</th><td>noble</td></tr>
+  <tr><th>Architecture:</th><td>riscv64</td></tr>
+  <tr><th>UUID:</th><td>66666666-6666-6666-6666-666666666666</td></tr>
+</table>
+<table>
+  <tr><th>Package:</th><td>ovn</td></tr>
+  <tr><th>Release:</th><td>noble</td></tr>
+  <tr><th>Architecture:</th><td>arm64</td></tr>
+  <tr><th>UUID:</th><td>77777777-7777-7777-7777-777777777777</td></tr>
+</table>
+</body></html>`
+
+func TestParseRunningEntries(t *testing.T) {
+	entries, err := parseRunningEntries(mockAllRunningPage)
+	if err != nil {
+		t.Fatalf("parseRunningEntries failed: %v", err)
+	}
+
+	expected := []runningEntry{
+		{pkg: "systemd", release: "noble", arch: "riscv64", uuid: "55555555-5555-5555-5555-555555555555"},
+		{pkg: "ovn", release: "noble", arch: "riscv64", uuid: "66666666-6666-6666-6666-666666666666"},
+		{pkg: "ovn", release: "noble", arch: "arm64", uuid: "77777777-7777-7777-7777-777777777777"},
+	}
+	if !slices.Equal(entries, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, entries)
+	}
+}
+
+// newRunningServer serves packagePage for /packages/, runningPage for
+// /running and reports every UUID except finished as running
+func newRunningServer(t *testing.T, packagePage, runningPage, finished string) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/packages/"):
+			w.Write([]byte(packagePage))
+		case r.URL.Path == "/running":
+			w.Write([]byte(runningPage))
+		case r.URL.Path == "/run/"+finished:
+			w.Write([]byte(`<table><tr><th>Result</th><td>pass</td></tr></table>`))
+		default:
+			w.Write([]byte(`Test In progress...`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	return client
+}
+
+func TestFindRunningTests(t *testing.T) {
+	tests := []struct {
+		name     string
+		pkg      string
+		release  string
+		arch     string
+		finished string
+		want     []string
+	}{
+		{name: "ppc64el", pkg: "ovn", release: "noble", arch: "ppc64el", want: []string{"11111111-1111-1111-1111-111111111111"}},
+		{name: "all s390x matches", pkg: "ovn", release: "noble", arch: "s390x", want: []string{"22222222-2222-2222-2222-222222222222", "33333333-3333-3333-3333-333333333333"}},
+		{name: "finished runs skipped", pkg: "ovn", release: "noble", arch: "s390x", finished: "22222222-2222-2222-2222-222222222222", want: []string{"33333333-3333-3333-3333-333333333333"}},
+		{name: "running page by heading", pkg: "ovn", release: "noble", arch: "riscv64", want: []string{"66666666-6666-6666-6666-666666666666"}},
+		{name: "running page by package row", pkg: "ovn", release: "noble", arch: "arm64", want: []string{"77777777-7777-7777-7777-777777777777"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newRunningServer(t, mockPackageRunningPage, mockAllRunningPage, tt.finished)
+
+			uuids, err := client.FindRunningTests(tt.pkg, tt.release, tt.arch)
+			if err != nil {
+				t.Fatalf("FindRunningTests() failed: %v", err)
+			}
+			if !slices.Equal(uuids, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, uuids)
+			}
+
+			uuid, err := client.FindRunningTest(tt.pkg, tt.release, tt.arch)
+			if err != nil {
+				t.Fatalf("FindRunningTest() failed: %v", err)
+			}
+			if uuid != tt.want[0] {
+				t.Errorf("Expected first UUID %s, got %s", tt.want[0], uuid)
+			}
+		})
+	}
+}
+
+func TestFindRunningTests_OtherPackageNotMatched(t *testing.T) {
+	client := newRunningServer(t, `<html><body></body></html>`, mockAllRunningPage, "")
+
+	// systemd is running on noble/riscv64, but only ovn runs on noble/arm64
+	if _, err := client.FindRunningTests("systemd", "noble", "arm64"); err == nil {
+		t.Error("Expected error when only another package matches")
+	}
+}
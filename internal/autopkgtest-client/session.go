@@ -0,0 +1,53 @@
+package autopkgtestclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// LoadCookies reads cookies saved by SaveCookies, for use with WithCookies.
+// A missing file is reported with an error wrapping os.ErrNotExist.
+func LoadCookies(path string) ([]*http.Cookie, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+
+	var cookies []*http.Cookie
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		return nil, fmt.Errorf("failed to parse session file %s: %w", path, err)
+	}
+	return cookies, nil
+}
+
+// SaveCookies writes the client's current session cookies to path as JSON,
+// so that a later run can pick up a session the server renewed with
+// LoadCookies. The file is only readable by its owner, and is replaced
+// atomically so that an interrupted save never leaves a truncated session.
+func (c *Client) SaveCookies(path string) error {
+	data, err := json.MarshalIndent(c.GetCookies(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cookies: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".session-*")
+	if err != nil {
+		return fmt.Errorf("failed to create session file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	return nil
+}
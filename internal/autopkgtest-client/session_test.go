@@ -0,0 +1,80 @@
+package autopkgtestclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveAndLoadCookies(t *testing.T) {
+	// The server renews the session on every request
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("session"); err == nil {
+			received = append(received, cookie.Value)
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "renewed", Path: "/"})
+		w.Write([]byte(`<table><tr><th>Result</th><td>pass</td></tr></table>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithBaseURL(server.URL), WithCookies([]*http.Cookie{{Name: "session", Value: "original"}}))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	if _, err := client.GetTestStatus("test-uuid"); err != nil {
+		t.Fatalf("GetTestStatus() failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := client.SaveCookies(path); err != nil {
+		t.Fatalf("SaveCookies() failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() failed: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("Expected session file mode 0600, got %o", mode)
+	}
+
+	cookies, err := LoadCookies(path)
+	if err != nil {
+		t.Fatalf("LoadCookies() failed: %v", err)
+	}
+	if len(cookies) != 1 || cookies[0].Name != "session" || cookies[0].Value != "renewed" {
+		t.Fatalf("Expected the renewed session cookie, got %+v", cookies)
+	}
+
+	// A new client picks up the renewed session
+	next, err := NewClient(WithBaseURL(server.URL), WithCookies(cookies))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	if _, err := next.GetTestStatus("test-uuid"); err != nil {
+		t.Fatalf("GetTestStatus() failed: %v", err)
+	}
+	if len(received) != 2 || received[0] != "original" || received[1] != "renewed" {
+		t.Errorf("Expected the original then the renewed session to be sent, got %v", received)
+	}
+}
+
+func TestLoadCookies_Missing(t *testing.T) {
+	_, err := LoadCookies(filepath.Join(t.TempDir(), "missing.json"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected error to wrap os.ErrNotExist, got: %v", err)
+	}
+}
+
+func TestLoadCookies_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := os.WriteFile(path, []byte("session=abc"), 0600); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if _, err := LoadCookies(path); err == nil {
+		t.Error("Expected error for a file that is not JSON")
+	}
+}
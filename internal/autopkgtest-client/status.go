@@ -0,0 +1,8 @@
+package autopkgtestclient
+
+import "github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+
+// isFinalStatus reports whether a test with this status has completed
+func isFinalStatus(status autopkgtest.Status) bool {
+	return status == autopkgtest.StatusPass || status == autopkgtest.StatusFail || status == autopkgtest.StatusNeutral || status == autopkgtest.StatusTmpfail
+}
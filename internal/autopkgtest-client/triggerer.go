@@ -0,0 +1,242 @@
+package autopkgtestclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	triggerlinkgenerator "github.com/canonical/autopkgtest-automation/internal/trigger-link-generator"
+)
+
+// TriggerEventKind is the kind of a TriggerEvent
+type TriggerEventKind int
+
+const (
+	// EventSkipped: the test was already running and SkipRunning is set,
+	// so it was not submitted; Result describes the running test
+	EventSkipped TriggerEventKind = iota
+	// EventTriggering: URL is about to be submitted
+	EventTriggering
+	// EventTriggered: URL was submitted; Result is the new test
+	EventTriggered
+	// EventAlreadyRunning: the server refused URL because the test is
+	// already running. Result describes the running test; its UUID is
+	// empty and Err is set if it could not be found, in which case it is
+	// left out of the results.
+	EventAlreadyRunning
+	// EventStatus: Status was fetched for Result while waiting
+	EventStatus
+	// EventDone: Result completed with Status, or could not be waited for
+	// with Err
+	EventDone
+)
+
+// TriggerEvent reports the progress of a Triggerer to TriggerOptions.OnEvent
+type TriggerEvent struct {
+	Kind   TriggerEventKind
+	Index  int    // Index of the URL (or result, while waiting) the event is about
+	Total  int    // Number of URLs (or results, while waiting)
+	URL    string // Trigger URL, while triggering
+	Result *TriggerResult
+	Status *TestStatus
+	Err    error
+}
+
+// TriggerOptions configures a Triggerer run
+type TriggerOptions struct {
+	SkipRunning  bool               // Do not submit tests that are already running, and monitor the running ones instead
+	Wait         bool               // Wait for the triggered tests to complete
+	PollInterval time.Duration      // Status poll interval while waiting (default: one minute)
+	Timeout      time.Duration      // Time limit for the whole wait (<= 0: none)
+	OnEvent      func(TriggerEvent) // Called for each event, serialized (optional)
+}
+
+// TriggerError is returned when submitting a test request fails. The tests
+// triggered before it are still returned.
+type TriggerError struct {
+	URL    string
+	Result *TriggerResult // Fields parsed from a partial confirmation, if any
+	Err    error
+}
+
+func (e *TriggerError) Error() string {
+	return fmt.Sprintf("failed to trigger %s: %v", e.URL, e.Err)
+}
+
+// Unwrap allows errors.Is on the underlying error, e.g. ErrAuthRequired
+func (e *TriggerError) Unwrap() error {
+	return e.Err
+}
+
+// Triggerer generates the trigger URLs of a request, submits them and
+// optionally waits for the tests to complete
+type Triggerer struct {
+	client    *Client
+	generator *triggerlinkgenerator.Generator
+}
+
+// NewTriggerer creates a Triggerer submitting tests with client. A nil gen
+// uses triggerlinkgenerator.NewGenerator().
+func NewTriggerer(client *Client, gen *triggerlinkgenerator.Generator) *Triggerer {
+	if gen == nil {
+		gen = triggerlinkgenerator.NewGenerator()
+	}
+	return &Triggerer{client: client, generator: gen}
+}
+
+// Run generates the trigger URLs of req, triggers them with TriggerURLs and,
+// if opts.Wait is set, waits for them with Wait. The statuses are nil
+// without opts.Wait. On error, the results and statuses obtained so far are
+// returned with it.
+func (t *Triggerer) Run(ctx context.Context, req *triggerlinkgenerator.LinkRequest, opts TriggerOptions) ([]*TriggerResult, []*TestStatus, error) {
+	resp, err := t.generator.GenerateLinks(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate trigger links: %w", err)
+	}
+
+	results, err := t.TriggerURLs(ctx, resp.URLs, opts)
+	if err != nil || !opts.Wait {
+		return results, nil, err
+	}
+
+	statuses, err := t.Wait(ctx, results, opts)
+	return results, statuses, err
+}
+
+// TriggerURLs submits each of urls in turn. Tests the server reports as
+// already running are looked up and returned like triggered ones. With
+// opts.SkipRunning, running tests are looked up before anything is
+// submitted and are not submitted again; URLs without an architecture
+// cannot be matched and are always submitted. The first submission error
+// stops the run and is returned as a *TriggerError, along with the tests
+// triggered before it.
+func (t *Triggerer) TriggerURLs(ctx context.Context, urls []string, opts TriggerOptions) ([]*TriggerResult, error) {
+	emit := func(event TriggerEvent) {
+		if opts.OnEvent != nil {
+			event.Total = len(urls)
+			opts.OnEvent(event)
+		}
+	}
+
+	running := make(map[int]string)
+	if opts.SkipRunning {
+		for i, triggerURL := range urls {
+			pkg, release, arch := triggerTarget(triggerURL)
+			if arch == triggerlinkgenerator.AllArchitectures {
+				continue
+			}
+			if uuid, err := t.client.FindRunningTest(pkg, release, arch); err == nil {
+				running[i] = uuid
+			}
+		}
+	}
+
+	var results []*TriggerResult
+	for i, triggerURL := range urls {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		pkg, release, arch := triggerTarget(triggerURL)
+		if uuid, ok := running[i]; ok {
+			result := t.client.runningResult(pkg, release, arch, uuid)
+			emit(TriggerEvent{Kind: EventSkipped, Index: i, URL: triggerURL, Result: result})
+			results = append(results, result)
+			continue
+		}
+
+		emit(TriggerEvent{Kind: EventTriggering, Index: i, URL: triggerURL})
+		result, err := t.client.triggerTest(ctx, triggerURL)
+		var runErr *AlreadyRunningError
+		switch {
+		case err == nil:
+			emit(TriggerEvent{Kind: EventTriggered, Index: i, URL: triggerURL, Result: result})
+			results = append(results, result)
+		case errors.As(err, &runErr):
+			if runErr.Arch != "" {
+				arch = runErr.Arch
+			}
+			uuid, err := t.client.FindRunningTest(pkg, release, arch)
+			result := t.client.runningResult(pkg, release, arch, uuid)
+			if err != nil {
+				result.ResultURL = ""
+				emit(TriggerEvent{Kind: EventAlreadyRunning, Index: i, URL: triggerURL, Result: result, Err: err})
+				continue
+			}
+			emit(TriggerEvent{Kind: EventAlreadyRunning, Index: i, URL: triggerURL, Result: result})
+			results = append(results, result)
+		default:
+			return results, &TriggerError{URL: triggerURL, Result: result, Err: err}
+		}
+	}
+	return results, nil
+}
+
+// Wait waits for the given results concurrently, as WaitForMultipleFunc
+// does, reporting their progress as EventStatus and EventDone events. The
+// returned statuses are in the order of results, nil for results without a
+// UUID (such as PPA tests, which cannot be tracked) and for tests that could
+// not be waited for; the error then joins the errors of those tests.
+func (t *Triggerer) Wait(ctx context.Context, results []*TriggerResult, opts TriggerOptions) ([]*TestStatus, error) {
+	pollInterval := opts.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = time.Minute
+	}
+
+	var items []WaitItem
+	var indexes []int
+	for i, result := range results {
+		if result.UUID != "" {
+			items = append(items, WaitItem{Package: result.Package, UUID: result.UUID})
+			indexes = append(indexes, i)
+		}
+	}
+
+	var onUpdate func(int, *TestStatus)
+	var onDone func(int, *TestStatus, error)
+	if opts.OnEvent != nil {
+		onUpdate = func(i int, status *TestStatus) {
+			opts.OnEvent(TriggerEvent{Kind: EventStatus, Index: indexes[i], Total: len(results), Result: results[indexes[i]], Status: status})
+		}
+		onDone = func(i int, status *TestStatus, err error) {
+			opts.OnEvent(TriggerEvent{Kind: EventDone, Index: indexes[i], Total: len(results), Result: results[indexes[i]], Status: status, Err: err})
+		}
+	}
+
+	waited, err := t.client.waitForMultiple(ctx, items, pollInterval, opts.Timeout, onUpdate, onDone)
+	statuses := make([]*TestStatus, len(results))
+	for i, status := range waited {
+		statuses[indexes[i]] = status
+	}
+	return statuses, err
+}
+
+// runningResult describes a test that was already running, so that it can
+// be monitored like one that was just triggered
+func (c *Client) runningResult(pkg, release, arch, uuid string) *TriggerResult {
+	return &TriggerResult{
+		UUID:       uuid,
+		ResultURL:  fmt.Sprintf("%s/run/%s", c.baseURL, uuid),
+		HistoryURL: fmt.Sprintf("%s/packages/%s/%s/%s", c.baseURL, pkg, release, arch),
+		Package:    pkg,
+		Release:    release,
+		Arch:       arch,
+	}
+}
+
+// triggerTarget returns the package, release and architecture a trigger URL
+// requests, with triggerlinkgenerator.AllArchitectures for no architecture
+func triggerTarget(triggerURL string) (pkg, release, arch string) {
+	u, err := url.Parse(triggerURL)
+	if err != nil {
+		return "", "", triggerlinkgenerator.AllArchitectures
+	}
+	params := u.Query()
+	arch = params.Get("arch")
+	if arch == "" {
+		arch = triggerlinkgenerator.AllArchitectures
+	}
+	return params.Get("package"), params.Get("release"), arch
+}
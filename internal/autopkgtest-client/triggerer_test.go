@@ -0,0 +1,181 @@
+package autopkgtestclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+)
+
+const mockAlreadyRunningResponse = `Logout testuser
+
+You submitted an invalid request:
+
+Test already running:`
+
+// newTriggerServer accepts test requests, except that s390x and i386 are
+// reported as already running and arm64 asks for a login. Running tests are
+// listed as in newRunningServer.
+func newTriggerServer(t *testing.T) (*Client, func(arch string) string, *[]string) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/request.cgi":
+			arch := r.URL.Query().Get("arch")
+			requested = append(requested, arch)
+			switch arch {
+			case "s390x", "i386":
+				w.Write([]byte(mockAlreadyRunningResponse))
+			case "arm64":
+				w.Write([]byte("Please login to continue"))
+			default:
+				w.Write([]byte("Test request submitted.\nUUID\n    ae232d9f-08bd-4e36-90b7-7e3811776a64\n"))
+			}
+		case strings.HasPrefix(r.URL.Path, "/packages/"):
+			w.Write([]byte(mockPackageRunningPage))
+		case r.URL.Path == "/running":
+			w.Write([]byte(mockAllRunningPage))
+		default:
+			w.Write([]byte(`Test In progress...`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	triggerURL := func(arch string) string {
+		return server.URL + "/request.cgi?release=noble&package=ovn&arch=" + arch + "&trigger=migration-reference%2F0"
+	}
+	return client, triggerURL, &requested
+}
+
+func TestTriggererTriggerURLs_SkipRunning(t *testing.T) {
+	client, triggerURL, requested := newTriggerServer(t)
+
+	var kinds []TriggerEventKind
+	opts := TriggerOptions{
+		SkipRunning: true,
+		OnEvent: func(event TriggerEvent) {
+			kinds = append(kinds, event.Kind)
+		},
+	}
+	urls := []string{triggerURL("amd64"), triggerURL("ppc64el"), triggerURL("armhf")}
+	results, err := NewTriggerer(client, nil).TriggerURLs(context.Background(), urls, opts)
+	if err != nil {
+		t.Fatalf("TriggerURLs() failed: %v", err)
+	}
+
+	// ppc64el is running, so it is not submitted but monitored instead
+	if !slices.Equal(*requested, []string{"amd64", "armhf"}) {
+		t.Errorf("Expected only amd64 and armhf to be submitted, got %v", *requested)
+	}
+	expectedKinds := []TriggerEventKind{EventTriggering, EventTriggered, EventSkipped, EventTriggering, EventTriggered}
+	if !slices.Equal(kinds, expectedKinds) {
+		t.Errorf("Expected events %v, got %v", expectedKinds, kinds)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if results[1].UUID != "11111111-1111-1111-1111-111111111111" || results[1].Arch != "ppc64el" {
+		t.Errorf("Expected the running ppc64el test, got %+v", results[1])
+	}
+	if !strings.HasSuffix(results[1].ResultURL, "/run/11111111-1111-1111-1111-111111111111") {
+		t.Errorf("Expected the run page as result URL, got %s", results[1].ResultURL)
+	}
+}
+
+func TestTriggererTriggerURLs_AlreadyRunning(t *testing.T) {
+	client, triggerURL, requested := newTriggerServer(t)
+
+	var running []TriggerEvent
+	opts := TriggerOptions{
+		OnEvent: func(event TriggerEvent) {
+			if event.Kind == EventAlreadyRunning {
+				running = append(running, event)
+			}
+		},
+	}
+	urls := []string{triggerURL("s390x"), triggerURL("i386"), triggerURL("arm64"), triggerURL("amd64")}
+	results, err := NewTriggerer(client, nil).TriggerURLs(context.Background(), urls, opts)
+
+	// arm64 needs a login, which stops the run
+	var triggerErr *TriggerError
+	if !errors.As(err, &triggerErr) || !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("Expected a *TriggerError wrapping ErrAuthRequired, got: %v", err)
+	}
+	if triggerErr.URL != urls[2] {
+		t.Errorf("Expected the arm64 URL in the error, got %s", triggerErr.URL)
+	}
+	if !slices.Equal(*requested, []string{"s390x", "i386", "arm64"}) {
+		t.Errorf("Expected the run to stop at arm64, got %v", *requested)
+	}
+
+	// The running s390x test is found; the i386 one is not and is left out
+	if len(running) != 2 {
+		t.Fatalf("Expected 2 already running events, got %d", len(running))
+	}
+	if running[0].Err != nil || running[0].Result.UUID != "22222222-2222-2222-2222-222222222222" {
+		t.Errorf("Expected the running s390x test to be found, got %+v", running[0])
+	}
+	if running[1].Err == nil || running[1].Result.UUID != "" || running[1].Result.Arch != "i386" {
+		t.Errorf("Expected the running i386 test not to be found, got %+v", running[1])
+	}
+	if len(results) != 1 || results[0].Arch != "s390x" {
+		t.Errorf("Expected only the s390x test in the results, got %+v", results)
+	}
+}
+
+func TestTriggererTriggerURLs_Cancelled(t *testing.T) {
+	client, triggerURL, requested := newTriggerServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewTriggerer(client, nil).TriggerURLs(ctx, []string{triggerURL("amd64")}, TriggerOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+	if len(*requested) != 0 {
+		t.Errorf("Expected nothing to be submitted, got %v", *requested)
+	}
+}
+
+func TestTriggererWait(t *testing.T) {
+	client := newWaitServer(t)
+
+	// The PPA test has no UUID and cannot be waited for
+	results := []*TriggerResult{
+		{UUID: "fast", Package: "ovn"},
+		{Package: "ovn"},
+		{UUID: "failed", Package: "ovn"},
+	}
+	var done []int
+	opts := TriggerOptions{
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+		OnEvent: func(event TriggerEvent) {
+			if event.Kind == EventDone {
+				done = append(done, event.Index)
+			}
+		},
+	}
+	statuses, err := NewTriggerer(client, nil).Wait(context.Background(), results, opts)
+	if err != nil {
+		t.Fatalf("Wait() failed: %v", err)
+	}
+
+	slices.Sort(done)
+	if !slices.Equal(done, []int{0, 2}) {
+		t.Errorf("Expected done events for results 0 and 2, got %v", done)
+	}
+	if len(statuses) != 3 || statuses[0].Status != autopkgtest.StatusPass || statuses[1] != nil || statuses[2].Status != autopkgtest.StatusFail {
+		t.Errorf("Expected pass, nil and fail in result order, got %+v", statuses)
+	}
+}
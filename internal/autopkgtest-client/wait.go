@@ -0,0 +1,81 @@
+package autopkgtestclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WaitItem identifies a test for WaitForMultiple to wait for
+type WaitItem struct {
+	Package string
+	UUID    string
+}
+
+// WaitForMultiple waits for all the given tests concurrently, so that a slow
+// test does not hold up the others. timeout applies to the whole batch, and
+// as with WaitForCompletion, <= 0 times out as soon as a test is found not
+// complete. The returned statuses are in the order of items, nil for tests
+// that could not be waited for; the error then joins the errors of those
+// tests (each wrapping e.g. ErrTimeout).
+func (c *Client) WaitForMultiple(items []WaitItem, pollInterval, timeout time.Duration) ([]*TestStatus, error) {
+	return c.WaitForMultipleFunc(items, pollInterval, timeout, nil, nil)
+}
+
+// WaitForMultipleFunc is WaitForMultiple with onUpdate called with the
+// status of items[i] fetched on every poll, as with WaitForCompletionFunc,
+// and onDone called as soon as items[i] completes or fails, with its final
+// status or error. Calls to the callbacks are serialized, so they need no
+// locking of their own. Either callback may be nil.
+func (c *Client) WaitForMultipleFunc(items []WaitItem, pollInterval, timeout time.Duration, onUpdate func(i int, status *TestStatus), onDone func(i int, status *TestStatus, err error)) ([]*TestStatus, error) {
+	return c.waitForMultiple(context.Background(), items, pollInterval, immediateTimeout(timeout), onUpdate, onDone)
+}
+
+// waitForMultiple implements WaitForMultipleFunc, also stopping with
+// ctx.Err() when ctx is done. A timeout <= 0 waits without a time limit.
+func (c *Client) waitForMultiple(ctx context.Context, items []WaitItem, pollInterval, timeout time.Duration, onUpdate func(i int, status *TestStatus), onDone func(i int, status *TestStatus, err error)) ([]*TestStatus, error) {
+	deadline := time.Now().Add(timeout)
+	statuses := make([]*TestStatus, len(items))
+	errs := make([]error, len(items))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var update func(*TestStatus)
+			if onUpdate != nil {
+				update = func(status *TestStatus) {
+					mu.Lock()
+					defer mu.Unlock()
+					onUpdate(i, status)
+				}
+			}
+
+			// The batch timeout is shared: a test started after it passed
+			// still gets a (tiny) time limit rather than none
+			remaining := time.Duration(0)
+			if timeout > 0 {
+				remaining = max(time.Until(deadline), time.Nanosecond)
+			}
+			status, err := c.waitForCompletion(ctx, item.UUID, pollInterval, remaining, update)
+
+			mu.Lock()
+			defer mu.Unlock()
+			statuses[i] = status
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", item.UUID, err)
+			}
+			if onDone != nil {
+				onDone(i, status, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return statuses, errors.Join(errs...)
+}
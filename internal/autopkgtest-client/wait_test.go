@@ -0,0 +1,92 @@
+package autopkgtestclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+)
+
+// newWaitServer reports the run "fast" as passed, "failed" as failed and any
+// other run as in progress
+func newWaitServer(t *testing.T) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/run/fast":
+			w.Write([]byte(`<table><tr><th>Result</th><td>pass</td></tr></table>`))
+		case "/run/failed":
+			w.Write([]byte(`<table><tr><th>Result</th><td>fail</td></tr></table>`))
+		default:
+			w.Write([]byte(`Test In progress...`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	return client
+}
+
+func TestWaitForMultiple(t *testing.T) {
+	client := newWaitServer(t)
+
+	items := []WaitItem{{Package: "ovn", UUID: "fast"}, {Package: "ovn", UUID: "failed"}}
+	statuses, err := client.WaitForMultiple(items, 10*time.Millisecond, time.Second)
+	if err != nil {
+		t.Fatalf("WaitForMultiple() failed: %v", err)
+	}
+
+	if len(statuses) != 2 || statuses[0].Status != autopkgtest.StatusPass || statuses[1].Status != autopkgtest.StatusFail {
+		t.Errorf("Expected pass and fail in item order, got %+v", statuses)
+	}
+}
+
+func TestWaitForMultipleFunc_ReportsEachAsItCompletes(t *testing.T) {
+	client := newWaitServer(t)
+
+	items := []WaitItem{{Package: "ovn", UUID: "slow"}, {Package: "ovn", UUID: "fast"}}
+	var done []int
+	var doneAt []time.Time
+	start := time.Now()
+	statuses, err := client.WaitForMultipleFunc(items, 10*time.Millisecond, 200*time.Millisecond, nil, func(i int, status *TestStatus, err error) {
+		done = append(done, i)
+		doneAt = append(doneAt, time.Now())
+	})
+
+	if !slices.Equal(done, []int{1, 0}) {
+		t.Fatalf("Expected the fast test to be reported first, got order %v", done)
+	}
+	if doneAt[0].Sub(start) >= 200*time.Millisecond {
+		t.Errorf("Expected the fast test to be reported before the timeout, took %v", doneAt[0].Sub(start))
+	}
+
+	// The slow test times out; the batch timeout is shared, not per test
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected error to wrap ErrTimeout, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the batch to stop at the timeout, took %v", elapsed)
+	}
+	if statuses[0] != nil || statuses[1] == nil || statuses[1].Status != autopkgtest.StatusPass {
+		t.Errorf("Expected no status for the slow test and pass for the fast one, got %+v", statuses)
+	}
+}
+
+func TestWaitForMultiple_ZeroTimeout(t *testing.T) {
+	client := newWaitServer(t)
+
+	items := []WaitItem{{Package: "ovn", UUID: "slow"}, {Package: "ovn", UUID: "fast"}}
+	statuses, err := client.WaitForMultiple(items, time.Minute, 0)
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected error to wrap ErrTimeout, got: %v", err)
+	}
+	if statuses[0] != nil || statuses[1] == nil || statuses[1].Status != autopkgtest.StatusPass {
+		t.Errorf("Expected the slow test to time out at once and the fast one to pass, got %+v", statuses)
+	}
+}
@@ -0,0 +1,76 @@
+package autopkgtestclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+)
+
+// CompletionEvent reports a change in the state of a test watched by
+// WatchCompletion
+type CompletionEvent struct {
+	UUID    string // UUID of the run (a new one after a tmpfail retry)
+	Package string
+	Status  autopkgtest.Status
+	Done    bool        // Last event for this test: it completed or failed
+	Err     error       // Why the test could not be watched to completion, if Done
+	Final   *TestStatus // Final status of the test, if Done without Err
+}
+
+// WatchCompletion polls the given tests concurrently and sends an event on
+// the returned channel whenever one of them changes status and when it
+// completes, with its final status in Final. Polling errors are sent as a
+// Done event with Err set. The channel is closed once every test is done, or
+// when ctx is cancelled, which is how to stop watching early; there is no
+// timeout otherwise. The poll interval follows WithPollStrategy, and tmpfail
+// runs are re-triggered with WithTmpfailRetry as in WaitForCompletion.
+func (c *Client) WatchCompletion(ctx context.Context, items []WaitItem, pollInterval time.Duration) <-chan CompletionEvent {
+	events := make(chan CompletionEvent)
+
+	send := func(event CompletionEvent) {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, item := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// A re-triggered run starts over with a new UUID, which is a
+			// change even if its status is the same
+			var last autopkgtest.Status
+			var lastUUID string
+			status, err := c.waitForCompletion(ctx, item.UUID, pollInterval, 0, func(update *TestStatus) {
+				if (update.Status == last && update.UUID == lastUUID) || isFinalStatus(update.Status) {
+					return
+				}
+				last, lastUUID = update.Status, update.UUID
+				send(CompletionEvent{UUID: update.UUID, Package: item.Package, Status: update.Status})
+			})
+			if ctx.Err() != nil {
+				return
+			}
+
+			event := CompletionEvent{UUID: item.UUID, Package: item.Package, Status: last, Done: true, Err: err}
+			if status != nil {
+				event.UUID = status.UUID
+				event.Status = status.Status
+				event.Final = status
+			}
+			send(event)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+
+	return events
+}
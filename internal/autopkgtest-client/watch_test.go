@@ -0,0 +1,67 @@
+package autopkgtestclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+)
+
+func TestWatchCompletion(t *testing.T) {
+	client := newWaitServer(t)
+
+	items := []WaitItem{{Package: "ovn", UUID: "fast"}, {Package: "systemd", UUID: "failed"}}
+	done := make(map[string]CompletionEvent)
+	for event := range client.WatchCompletion(context.Background(), items, 10*time.Millisecond) {
+		if !event.Done {
+			t.Errorf("Expected only completion events for finished runs, got %+v", event)
+			continue
+		}
+		done[event.UUID] = event
+	}
+
+	if event := done["fast"]; event.Package != "ovn" || event.Status != autopkgtest.StatusPass || event.Err != nil || event.Final == nil {
+		t.Errorf("Expected fast to pass, got %+v", event)
+	}
+	if event := done["failed"]; event.Package != "systemd" || event.Status != autopkgtest.StatusFail {
+		t.Errorf("Expected failed to fail, got %+v", event)
+	}
+}
+
+func TestWatchCompletion_Cancel(t *testing.T) {
+	client := newWaitServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := client.WatchCompletion(ctx, []WaitItem{{Package: "ovn", UUID: "slow"}}, 10*time.Millisecond)
+
+	// The running status is reported once, not on every poll
+	event := <-events
+	if event.Done || event.UUID != "slow" || event.Status != autopkgtest.StatusRunning {
+		t.Errorf("Expected a running event for slow, got %+v", event)
+	}
+
+	cancel()
+	select {
+	case event, ok := <-events:
+		if ok {
+			t.Errorf("Expected the channel to be closed after cancelling, got %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the channel to be closed after cancelling")
+	}
+}
+
+func TestWaitForCompletionContext(t *testing.T) {
+	client := newWaitServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// No timeout of its own: only the context stops waiting
+	_, err := client.waitForCompletion(ctx, "slow", 10*time.Millisecond, 0, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package autopkgtestclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+)
+
+// CompletionNotification is the JSON payload PostWebhook sends when a test
+// completes
+type CompletionNotification struct {
+	Package string             `json:"package"`
+	Release string             `json:"release"`
+	Arch    string             `json:"arch"`
+	UUID    string             `json:"uuid"`
+	Status  autopkgtest.Status `json:"status"`
+	LogURL  string             `json:"log_url"`
+}
+
+// NewCompletionNotification describes the completion of the test triggered
+// as result, which finished with status
+func NewCompletionNotification(result *TriggerResult, status *TestStatus) CompletionNotification {
+	return CompletionNotification{
+		Package: result.Package,
+		Release: result.Release,
+		Arch:    result.Arch,
+		UUID:    status.UUID,
+		Status:  status.Status,
+		LogURL:  status.LogURL,
+	}
+}
+
+// PostWebhook POSTs n as JSON to webhookURL. Any status other than 2xx is
+// returned as an error. The client's extra headers are not sent, since the
+// webhook is usually another service.
+func (c *Client) PostWebhook(webhookURL string, n CompletionNotification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	c.logger.Debug("webhook posted", "url", webhookURL, "status", resp.StatusCode)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
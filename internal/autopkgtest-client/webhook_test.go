@@ -0,0 +1,70 @@
+package autopkgtestclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPostWebhook(t *testing.T) {
+	var got CompletionNotification
+	var contentType, extraHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST, got %s", r.Method)
+		}
+		contentType = r.Header.Get("Content-Type")
+		extraHeader = r.Header.Get("X-Gateway-Token")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithHeader("X-Gateway-Token", "secret"))
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	result := &TriggerResult{UUID: "12345678-1234-1234-1234-123456789abc", Package: "ovn", Release: "noble", Arch: "amd64"}
+	status := &TestStatus{UUID: result.UUID, Status: "fail", LogURL: "https://autopkgtest.ubuntu.com/run/" + result.UUID}
+	if err := client.PostWebhook(server.URL, NewCompletionNotification(result, status)); err != nil {
+		t.Fatalf("PostWebhook() failed: %v", err)
+	}
+
+	expected := CompletionNotification{
+		Package: "ovn",
+		Release: "noble",
+		Arch:    "amd64",
+		UUID:    result.UUID,
+		Status:  "fail",
+		LogURL:  status.LogURL,
+	}
+	if got != expected {
+		t.Errorf("Expected payload %+v, got %+v", expected, got)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Expected application/json content type, got %q", contentType)
+	}
+	if extraHeader != "" {
+		t.Errorf("Expected client headers not to be sent to the webhook, got %q", extraHeader)
+	}
+}
+
+func TestPostWebhook_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	if err := client.PostWebhook(server.URL, CompletionNotification{}); err == nil {
+		t.Error("Expected error for 502 response")
+	}
+}
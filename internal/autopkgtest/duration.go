@@ -0,0 +1,40 @@
+package autopkgtest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// durationRegex matches a whole duration as autopkgtest shows it
+	durationRegex = regexp.MustCompile(`^(?:\d+\s*[hms]\s*)+$`)
+	// durationTokenRegex matches one token of a duration, e.g. "20m"
+	durationTokenRegex = regexp.MustCompile(`(\d+)\s*([hms])`)
+)
+
+// ParseDuration parses a duration in autopkgtest's format, made of hour,
+// minute and second tokens such as "1h 20m 25s", "58m 40s" or "2h". Spaces
+// between tokens are optional. An empty string is an error.
+func ParseDuration(s string) (time.Duration, error) {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	if !durationRegex.MatchString(trimmed) {
+		return 0, fmt.Errorf("invalid duration %q (expected e.g. 1h 20m 25s)", s)
+	}
+
+	units := map[string]time.Duration{"h": time.Hour, "m": time.Minute, "s": time.Second}
+	var total time.Duration
+	for _, m := range durationTokenRegex.FindAllStringSubmatch(trimmed, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+		total += time.Duration(n) * units[m[2]]
+	}
+	return total, nil
+}
@@ -0,0 +1,47 @@
+package autopkgtest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "1h 20m 25s", want: time.Hour + 20*time.Minute + 25*time.Second},
+		{in: "58m 40s", want: 58*time.Minute + 40*time.Second},
+		{in: "2h", want: 2 * time.Hour},
+		{in: "45s", want: 45 * time.Second},
+		{in: "1h20m", want: time.Hour + 20*time.Minute},
+		{in: "1h20m25s", want: time.Hour + 20*time.Minute + 25*time.Second},
+		{in: " 5 m 3 s ", want: 5*time.Minute + 3*time.Second},
+		{in: "0s", want: 0},
+		{in: "", wantErr: true},
+		{in: "   ", wantErr: true},
+		{in: "fast", wantErr: true},
+		{in: "20", wantErr: true},
+		{in: "1h 20", wantErr: true},
+		{in: "1h and 2m", wantErr: true},
+		{in: "1d 2h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDuration(%q): expected error, got %v", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDuration(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDuration(%q): expected %v, got %v", tt.in, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Package autopkgtest holds the vocabulary shared by the scraper and the
+// client: the test statuses and durations shown by autopkgtest.ubuntu.com.
+package autopkgtest
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Status is the canonical name of a test status, as returned by ParseStatus.
+// Pages show it with decorations, e.g. "✔ pass".
+type Status string
+
+const (
+	StatusPass       Status = "pass"
+	StatusFail       Status = "fail"
+	StatusRegression Status = "regression"
+	StatusNeutral    Status = "neutral"
+	StatusTmpfail    Status = "tmpfail"
+	StatusRunning    Status = "running"
+	StatusQueued     Status = "queued"
+	StatusUnknown    Status = "unknown"
+
+	// StatusNoData is the status of a results matrix cell that shows no
+	// result, e.g. a package that is not tested on that
+	// release/architecture. ParseStatus never returns it.
+	StatusNoData Status = "nodata"
+)
+
+// KnownStatuses lists the statuses ParseStatus recognizes
+var KnownStatuses = []Status{
+	StatusPass, StatusFail, StatusRegression, StatusNeutral,
+	StatusTmpfail, StatusRunning, StatusQueued,
+}
+
+// ParseStatus returns the Status named by raw, ignoring case and the
+// decorations autopkgtest.ubuntu.com puts around it: "✔ pass", "PASS" and
+// "pass" are all StatusPass, "😐neutral" is StatusNeutral. The first word of
+// raw must be exactly the status name, so neither "bypass" nor "fail-early"
+// is mistaken for a known status. Anything else, including StatusNoData, is
+// StatusUnknown.
+func ParseStatus(raw string) Status {
+	isDecoration := func(r rune) bool { return !unicode.IsLetter(r) }
+	name := strings.TrimLeftFunc(strings.ToLower(raw), isDecoration)
+	if fields := strings.Fields(name); len(fields) > 0 {
+		name = strings.TrimRightFunc(fields[0], isDecoration)
+	}
+	for _, status := range KnownStatuses {
+		if name == string(status) {
+			return status
+		}
+	}
+	return StatusUnknown
+}
@@ -0,0 +1,35 @@
+package autopkgtest
+
+import "testing"
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want Status
+	}{
+		{raw: "pass", want: StatusPass},
+		{raw: "✔ pass", want: StatusPass},
+		{raw: "PASS", want: StatusPass},
+		{raw: "😐neutral", want: StatusNeutral},
+		{raw: "😐 neutral", want: StatusNeutral},
+		{raw: "✘ REGRESSION", want: StatusRegression},
+		{raw: "fail", want: StatusFail},
+		{raw: "✖ FAIL", want: StatusFail},
+		{raw: " ⚠ tmpfail ", want: StatusTmpfail},
+		{raw: "Running", want: StatusRunning},
+		{raw: "queued", want: StatusQueued},
+		{raw: "bypass", want: StatusUnknown},
+		{raw: "pass-failed", want: StatusUnknown},
+		{raw: "passed", want: StatusUnknown},
+		{raw: "pass (retried)", want: StatusPass},
+		{raw: "failed to start", want: StatusUnknown},
+		{raw: "fail-early", want: StatusUnknown},
+		{raw: string(StatusNoData), want: StatusUnknown},
+		{raw: "", want: StatusUnknown},
+	}
+	for _, tt := range tests {
+		if got := ParseStatus(tt.raw); got != tt.want {
+			t.Errorf("ParseStatus(%q): expected %q, got %q", tt.raw, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Package httpbody reads the bodies of the pages served by
+// autopkgtest.ubuntu.com for the scraper and the client.
+package httpbody
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrResponseTooLarge is returned (wrapped) when a page served by the server
+// is larger than the limit it is read with
+var ErrResponseTooLarge = errors.New("response too large")
+
+// DefaultMaxResponseBytes is the largest page body read by default
+const DefaultMaxResponseBytes = 10 << 20
+
+// Read reads the body of resp, decompressed by Decode, failing with
+// ErrResponseTooLarge rather than reading more than limit decompressed bytes
+// (see ReadAll)
+func Read(resp *http.Response, limit int64) ([]byte, error) {
+	r, err := Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return ReadAll(r, limit)
+}
+
+// ReadAll reads r to the end, failing with ErrResponseTooLarge rather than
+// reading more than limit bytes, so that a broken or malicious server cannot
+// exhaust memory. A limit of zero or less means DefaultMaxResponseBytes.
+func ReadAll(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		limit = DefaultMaxResponseBytes
+	}
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("%w (limit: %d bytes)", ErrResponseTooLarge, limit)
+	}
+	return body, nil
+}
+
+// Decode returns the body of resp decompressed according to its
+// Content-Encoding. The default transport already does this (and drops the
+// header) for the gzip encoding it asks for, but a custom transport or a
+// proxy forcing compression can hand over compressed bodies.
+func Decode(resp *http.Response) (io.Reader, error) {
+	switch encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+		return resp.Body, nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress response: %w", err)
+		}
+		return zr, nil
+	case "deflate":
+		// deflate is meant to be zlib-wrapped, but some servers send a raw
+		// deflate stream
+		br := bufio.NewReader(resp.Body)
+		if header, err := br.Peek(2); err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			zr, err := zlib.NewReader(br)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decompress response: %w", err)
+			}
+			return zr, nil
+		}
+		return flate.NewReader(br), nil
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding %q", encoding)
+	}
+}
@@ -0,0 +1,110 @@
+package httpbody
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestReadAll(t *testing.T) {
+	body := strings.Repeat("x", 100)
+
+	if _, err := ReadAll(strings.NewReader(body), 99); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("Expected error to wrap ErrResponseTooLarge, got: %v", err)
+	}
+
+	// A body of exactly the limit is read
+	got, err := ReadAll(strings.NewReader(body), 100)
+	if err != nil {
+		t.Fatalf("ReadAll() failed: %v", err)
+	}
+	if string(got) != body {
+		t.Errorf("Expected the whole body, got %d bytes", len(got))
+	}
+
+	// Zero or less is the default limit
+	for _, limit := range []int64{0, -1} {
+		if _, err := ReadAll(strings.NewReader(body), limit); err != nil {
+			t.Errorf("ReadAll() with limit %d failed: %v", limit, err)
+		}
+	}
+	big := strings.NewReader(strings.Repeat("x", DefaultMaxResponseBytes+1))
+	if _, err := ReadAll(big, 0); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("Expected the default limit to apply, got: %v", err)
+	}
+}
+
+func TestRead(t *testing.T) {
+	const body = "Test request submitted.\nUUID\n    ae232d9f-08bd-4e36-90b7-7e3811776a64\n"
+	for _, encoding := range []string{"", "identity", "gzip", "x-gzip", "deflate", "raw-deflate"} {
+		t.Run(encoding, func(t *testing.T) {
+			resp := newResponse(strings.TrimPrefix(encoding, "raw-"), compressBody(t, encoding, body))
+			got, err := Read(resp, 0)
+			if err != nil {
+				t.Fatalf("Read() failed: %v", err)
+			}
+			if string(got) != body {
+				t.Errorf("Expected the decompressed body, got %q", got)
+			}
+		})
+	}
+}
+
+func TestRead_LimitAppliesAfterDecompression(t *testing.T) {
+	// Compresses to far less than the limit
+	body := strings.Repeat("x", 1000)
+	resp := newResponse("gzip", compressBody(t, "gzip", body))
+	if _, err := Read(resp, 999); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("Expected error to wrap ErrResponseTooLarge, got: %v", err)
+	}
+}
+
+func TestRead_UnsupportedContentEncoding(t *testing.T) {
+	resp := newResponse("br", []byte("<html></html>"))
+	if _, err := Read(resp, 0); err == nil || !strings.Contains(err.Error(), `unsupported Content-Encoding "br"`) {
+		t.Errorf("Expected an unsupported Content-Encoding error, got: %v", err)
+	}
+}
+
+// newResponse returns a response with the given body and Content-Encoding
+func newResponse(encoding string, body []byte) *http.Response {
+	resp := &http.Response{Header: make(http.Header), Body: io.NopCloser(bytes.NewReader(body))}
+	if encoding != "" {
+		resp.Header.Set("Content-Encoding", encoding)
+	}
+	return resp
+}
+
+// compressBody encodes body as a server would with the given
+// Content-Encoding; "raw-deflate" is a deflate stream without zlib wrapping
+func compressBody(t *testing.T, encoding, body string) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip", "x-gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			t.Fatalf("flate.NewWriter() failed: %v", err)
+		}
+		w = fw
+	default:
+		return []byte(body)
+	}
+	if _, err := w.Write([]byte(body)); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,105 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CachingScraper is a Scraper that keeps package results in memory and
+// serves them again for TTL after they were fetched, e.g. for dashboards
+// polling the same packages. It is safe for concurrent use. Cached results
+// are shared between callers and must not be modified. Errors are never
+// cached. WaitForPass and the other methods of the embedded Scraper are not
+// cached.
+type CachingScraper struct {
+	*Scraper
+	TTL time.Duration // How long fetched results are served from the cache
+
+	mu      sync.Mutex
+	entries map[cacheKey]cacheEntry
+}
+
+// cacheKey identifies cached results: the same package fetched with
+// different filters is cached separately
+type cacheKey struct {
+	pkg    string
+	filter Filter
+}
+
+// cacheEntry holds cached results and when they were fetched
+type cacheEntry struct {
+	results   *PackageResults
+	fetchedAt time.Time
+}
+
+// NewCachingScraper creates a caching scraper fetching through s
+func NewCachingScraper(s *Scraper, ttl time.Duration) *CachingScraper {
+	return &CachingScraper{
+		Scraper: s,
+		TTL:     ttl,
+		entries: make(map[cacheKey]cacheEntry),
+	}
+}
+
+// FetchPackageResults returns the cached results for a package, fetching
+// them if they are missing or older than TTL
+func (c *CachingScraper) FetchPackageResults(packageName string) (*PackageResults, error) {
+	return c.FetchPackageResultsFiltered(packageName, nil)
+}
+
+// FetchPackageResultsFiltered is like FetchPackageResults with optional
+// filtering
+func (c *CachingScraper) FetchPackageResultsFiltered(packageName string, filter *Filter) (*PackageResults, error) {
+	return c.FetchPackageResultsContext(context.Background(), packageName, filter)
+}
+
+// FetchPackageResultsContext is like FetchPackageResultsFiltered but aborts
+// the request when ctx is cancelled or its deadline expires. Cached results
+// are returned regardless of ctx.
+func (c *CachingScraper) FetchPackageResultsContext(ctx context.Context, packageName string, filter *Filter) (*PackageResults, error) {
+	key := cacheKey{pkg: packageName}
+	if filter != nil {
+		key.filter = *filter
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < c.TTL {
+		return entry.results, nil
+	}
+
+	// The lock is not held while fetching so that a slow package does not
+	// block the others; concurrent misses for the same key may both fetch
+	results, err := c.Scraper.FetchPackageResultsContext(ctx, packageName, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{results: results, fetchedAt: time.Now()}
+	c.mu.Unlock()
+
+	return results, nil
+}
+
+// FetchMultiplePackages is like Scraper.FetchMultiplePackages but serves
+// each package from the cache when possible
+func (c *CachingScraper) FetchMultiplePackages(packages []string, filter *Filter, concurrency int) (map[string]*PackageResults, map[string]error) {
+	return fetchMultiple(packages, concurrency, func(pkg string) (*PackageResults, error) {
+		return c.FetchPackageResultsFiltered(pkg, filter)
+	})
+}
+
+// Invalidate drops the cached results of packageName for every filter, so
+// that the next fetch goes to the server
+func (c *CachingScraper) Invalidate(packageName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if key.pkg == packageName {
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package scraper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newCountingServer serves mockHTMLWithErrors for /packages/ovn and 404 for
+// anything else, counting every request
+func newCountingServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/packages/ovn" {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockHTMLWithErrors))
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestCachingScraperServesFromCache(t *testing.T) {
+	server, requests := newCountingServer(t)
+	s := NewScraper()
+	s.BaseURL = server.URL
+	c := NewCachingScraper(s, time.Hour)
+
+	first, err := c.FetchPackageResults("ovn")
+	if err != nil {
+		t.Fatalf("FetchPackageResults failed: %v", err)
+	}
+	second, err := c.FetchPackageResultsFiltered("ovn", nil)
+	if err != nil {
+		t.Fatalf("FetchPackageResultsFiltered failed: %v", err)
+	}
+
+	if first != second {
+		t.Error("Expected the cached results to be returned")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected 1 request, got %d", got)
+	}
+
+	// A different filter is cached separately
+	filtered, err := c.FetchPackageResultsFiltered("ovn", &Filter{Release: "noble"})
+	if err != nil {
+		t.Fatalf("FetchPackageResultsFiltered failed: %v", err)
+	}
+	if len(filtered.Tests) != 2 {
+		t.Errorf("Expected 2 noble results, got %d", len(filtered.Tests))
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Expected 2 requests, got %d", got)
+	}
+}
+
+func TestCachingScraperExpires(t *testing.T) {
+	server, requests := newCountingServer(t)
+	s := NewScraper()
+	s.BaseURL = server.URL
+	c := NewCachingScraper(s, 10*time.Millisecond)
+
+	if _, err := c.FetchPackageResults("ovn"); err != nil {
+		t.Fatalf("FetchPackageResults failed: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := c.FetchPackageResults("ovn"); err != nil {
+		t.Fatalf("FetchPackageResults failed: %v", err)
+	}
+
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Expected 2 requests after the TTL expired, got %d", got)
+	}
+}
+
+func TestCachingScraperInvalidate(t *testing.T) {
+	server, requests := newCountingServer(t)
+	s := NewScraper()
+	s.BaseURL = server.URL
+	c := NewCachingScraper(s, time.Hour)
+
+	if _, err := c.FetchPackageResults("ovn"); err != nil {
+		t.Fatalf("FetchPackageResults failed: %v", err)
+	}
+	if _, err := c.FetchPackageResultsFiltered("ovn", &Filter{Architecture: "amd64"}); err != nil {
+		t.Fatalf("FetchPackageResultsFiltered failed: %v", err)
+	}
+
+	c.Invalidate("ovn")
+
+	if _, err := c.FetchPackageResults("ovn"); err != nil {
+		t.Fatalf("FetchPackageResults failed: %v", err)
+	}
+	if _, err := c.FetchPackageResultsFiltered("ovn", &Filter{Architecture: "amd64"}); err != nil {
+		t.Fatalf("FetchPackageResultsFiltered failed: %v", err)
+	}
+
+	if got := requests.Load(); got != 4 {
+		t.Errorf("Expected 4 requests after invalidating, got %d", got)
+	}
+}
+
+func TestCachingScraperDoesNotCacheErrors(t *testing.T) {
+	server, requests := newCountingServer(t)
+	s := NewScraper()
+	s.BaseURL = server.URL
+	c := NewCachingScraper(s, time.Hour)
+
+	for range 2 {
+		if _, err := c.FetchPackageResults("nonexistent"); err == nil {
+			t.Fatal("Expected error for nonexistent package")
+		}
+	}
+
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Expected 2 requests, got %d", got)
+	}
+}
+
+func TestCachingScraperConcurrent(t *testing.T) {
+	server, requests := newCountingServer(t)
+	s := NewScraper()
+	s.BaseURL = server.URL
+	c := NewCachingScraper(s, time.Hour)
+
+	// Warm the cache, then read it from many goroutines while invalidating
+	if _, err := c.FetchPackageResults("ovn"); err != nil {
+		t.Fatalf("FetchPackageResults failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%10 == 0 {
+				c.Invalidate("ovn")
+			}
+			if _, err := c.FetchPackageResults("ovn"); err != nil {
+				t.Errorf("FetchPackageResults failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := requests.Load(); got >= 21 {
+		t.Errorf("Expected cached reads to avoid requests, got %d requests", got)
+	}
+
+	results, errs := c.FetchMultiplePackages([]string{"ovn", "nonexistent"}, nil, 2)
+	if results["ovn"] == nil || errs["nonexistent"] == nil {
+		t.Errorf("Expected results for ovn and an error for nonexistent, got %v, %v", results, errs)
+	}
+}
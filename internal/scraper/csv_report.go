@@ -0,0 +1,64 @@
+package scraper
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvHeader is the header row written by WriteCSV and WriteTSV
+var csvHeader = []string{"package", "release", "arch", "status", "duration", "trigger", "logurl"}
+
+// tsvEscaper replaces the characters that would break TSV columns or rows
+var tsvEscaper = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+
+// reportRow returns the CSV/TSV columns of test
+func reportRow(test TestResult) []string {
+	return []string{test.Package, test.Release, test.Architecture, test.Status, test.Duration, test.Trigger, test.LogURL}
+}
+
+// WriteCSV writes the test results to w as CSV: a header row followed by one
+// row per test, in the order of r.Tests. Cells without a result are left out.
+func (r *PackageResults) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, test := range r.Tests {
+		if !isTested(test) {
+			continue
+		}
+		if err := cw.Write(reportRow(test)); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// WriteTSV writes the test results to w as tab-separated values, with the
+// same columns and rows as WriteCSV. Fields are not quoted: tabs and newlines in them
+// are replaced by spaces, so that every line splits on tabs into exactly one
+// field per column (e.g. with awk -F'\t' or cut).
+func (r *PackageResults) WriteTSV(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, strings.Join(csvHeader, "\t")); err != nil {
+		return fmt.Errorf("failed to write TSV header: %w", err)
+	}
+	for _, test := range r.Tests {
+		if !isTested(test) {
+			continue
+		}
+		row := reportRow(test)
+		for i, field := range row {
+			row[i] = tsvEscaper.Replace(field)
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(row, "\t")); err != nil {
+			return fmt.Errorf("failed to write TSV row: %w", err)
+		}
+	}
+	return nil
+}
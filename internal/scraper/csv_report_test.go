@@ -0,0 +1,87 @@
+package scraper
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+)
+
+func TestWriteCSV(t *testing.T) {
+	results := &PackageResults{
+		Package: "ovn",
+		Tests: []TestResult{
+			{Package: "ovn", Release: "noble", Architecture: "amd64", Status: "pass", Duration: "1h 2m"},
+			{
+				Package:      "ovn",
+				Release:      "noble",
+				Architecture: "arm64",
+				Status:       "fail",
+				Trigger:      "ovn/25.09.0-3, openssl/3.5.4-1ubuntu1",
+				LogURL:       "https://autopkgtest.ubuntu.com/results/log.gz",
+			},
+			{Package: "ovn", Release: "noble", Architecture: "riscv64", Status: string(autopkgtest.StatusNoData)},
+		},
+	}
+
+	var out strings.Builder
+	if err := results.WriteCSV(&out); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+
+	if !strings.HasPrefix(out.String(), "package,release,arch,status,duration,trigger,logurl\n") {
+		t.Errorf("Expected header row, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), `"ovn/25.09.0-3, openssl/3.5.4-1ubuntu1"`) {
+		t.Errorf("Expected trigger containing a comma to be quoted, got:\n%s", out.String())
+	}
+
+	records, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("Output is not valid CSV: %v", err)
+	}
+	// The riscv64 cell without a result is left out
+	if len(records) != 3 {
+		t.Fatalf("Expected header and 2 rows, got %d", len(records))
+	}
+	want := []string{"ovn", "noble", "arm64", "fail", "", "ovn/25.09.0-3, openssl/3.5.4-1ubuntu1", "https://autopkgtest.ubuntu.com/results/log.gz"}
+	if strings.Join(records[2], "|") != strings.Join(want, "|") {
+		t.Errorf("Expected row %v, got %v", want, records[2])
+	}
+}
+
+func TestWriteTSV(t *testing.T) {
+	results := &PackageResults{
+		Package: "ovn",
+		Tests: []TestResult{
+			{Package: "ovn", Release: "noble", Architecture: "amd64", Status: "pass", Duration: "1h 2m"},
+			{
+				Package:      "ovn",
+				Release:      "noble",
+				Architecture: "arm64",
+				Status:       "fail",
+				Trigger:      "ovn/25.09.0-3, openssl/3.5.4-1ubuntu1\tsystemd/259-1",
+				LogURL:       "https://autopkgtest.ubuntu.com/results/log.gz",
+			},
+			{Package: "ovn", Release: "noble", Architecture: "riscv64", Status: string(autopkgtest.StatusNoData)},
+		},
+	}
+
+	var out strings.Builder
+	if err := results.WriteTSV(&out); err != nil {
+		t.Fatalf("WriteTSV failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected header and 2 rows, got:\n%s", out.String())
+	}
+	if lines[0] != "package\trelease\tarch\tstatus\tduration\ttrigger\tlogurl" {
+		t.Errorf("Expected header row, got %q", lines[0])
+	}
+	want := []string{"ovn", "noble", "arm64", "fail", "", "ovn/25.09.0-3, openssl/3.5.4-1ubuntu1 systemd/259-1", "https://autopkgtest.ubuntu.com/results/log.gz"}
+	if got := strings.Split(lines[2], "\t"); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected row %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,51 @@
+package scraper
+
+import (
+	"cmp"
+	"slices"
+)
+
+// ResultDiff is a release/arch cell whose status differs between two
+// result matrices. A status is empty when the cell is missing from that
+// matrix.
+type ResultDiff struct {
+	Release      string `json:"release"`
+	Architecture string `json:"architecture"`
+	StatusA      string `json:"status_a"`
+	StatusB      string `json:"status_b"`
+}
+
+// DiffResults compares the tests of a and b cell by cell and returns the
+// release/arch cells whose status differs, including cells present in only
+// one of them, sorted by release then architecture. Cells without data count
+// as missing.
+func DiffResults(a, b *PackageResults) []ResultDiff {
+	statuses := func(r *PackageResults) map[[2]string]string {
+		cells := make(map[[2]string]string)
+		for _, test := range r.Tests {
+			if !isTested(test) {
+				continue
+			}
+			cells[[2]string{test.Release, test.Architecture}] = test.Status
+		}
+		return cells
+	}
+	cellsA, cellsB := statuses(a), statuses(b)
+
+	var diffs []ResultDiff
+	for cell, statusA := range cellsA {
+		if statusB := cellsB[cell]; statusA != statusB {
+			diffs = append(diffs, ResultDiff{Release: cell[0], Architecture: cell[1], StatusA: statusA, StatusB: statusB})
+		}
+	}
+	for cell, statusB := range cellsB {
+		if _, ok := cellsA[cell]; !ok {
+			diffs = append(diffs, ResultDiff{Release: cell[0], Architecture: cell[1], StatusB: statusB})
+		}
+	}
+
+	slices.SortFunc(diffs, func(x, y ResultDiff) int {
+		return cmp.Or(cmp.Compare(x.Release, y.Release), cmp.Compare(x.Architecture, y.Architecture))
+	})
+	return diffs
+}
@@ -0,0 +1,45 @@
+package scraper
+
+import "testing"
+
+func TestDiffResults(t *testing.T) {
+	a := &PackageResults{
+		Package: "ovn",
+		Tests: []TestResult{
+			{Release: "noble", Architecture: "amd64", Status: "pass"},
+			{Release: "noble", Architecture: "arm64", Status: "fail"},
+			{Release: "jammy", Architecture: "amd64", Status: "pass"},
+			{Release: "jammy", Architecture: "s390x", Status: "pass"},
+		},
+	}
+	b := &PackageResults{
+		Package: "openvswitch",
+		Tests: []TestResult{
+			{Release: "noble", Architecture: "amd64", Status: "pass"},
+			{Release: "noble", Architecture: "arm64", Status: "pass"},
+			{Release: "jammy", Architecture: "amd64", Status: "regression"},
+			{Release: "questing", Architecture: "amd64", Status: "neutral"},
+		},
+	}
+
+	diffs := DiffResults(a, b)
+
+	want := []ResultDiff{
+		{Release: "jammy", Architecture: "amd64", StatusA: "pass", StatusB: "regression"},
+		{Release: "jammy", Architecture: "s390x", StatusA: "pass", StatusB: ""},
+		{Release: "noble", Architecture: "arm64", StatusA: "fail", StatusB: "pass"},
+		{Release: "questing", Architecture: "amd64", StatusA: "", StatusB: "neutral"},
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("Expected %d diffs, got %d: %+v", len(want), len(diffs), diffs)
+	}
+	for i := range want {
+		if diffs[i] != want[i] {
+			t.Errorf("Diff %d: expected %+v, got %+v", i, want[i], diffs[i])
+		}
+	}
+
+	if diffs := DiffResults(a, a); len(diffs) != 0 {
+		t.Errorf("Expected no diffs between identical results, got %+v", diffs)
+	}
+}
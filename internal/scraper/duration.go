@@ -0,0 +1,56 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+)
+
+// ErrNoDurations is returned by ExpectedDuration when no past run of the test
+// has a duration
+var ErrNoDurations = errors.New("no past runs with a duration")
+
+// MedianDuration returns the median duration of the runs in history, and how
+// many runs it is computed from. tmpfail runs, which are infrastructure
+// failures that usually end early, and runs without a parsable duration are
+// skipped.
+func MedianDuration(history []TestResult) (time.Duration, int) {
+	var durations []time.Duration
+	for _, run := range history {
+		if autopkgtest.ParseStatus(run.Status) == autopkgtest.StatusTmpfail {
+			continue
+		}
+		if d, err := autopkgtest.ParseDuration(run.Duration); err == nil {
+			durations = append(durations, d)
+		}
+	}
+	if len(durations) == 0 {
+		return 0, 0
+	}
+
+	slices.Sort(durations)
+	mid := len(durations) / 2
+	if len(durations)%2 == 0 {
+		return (durations[mid-1] + durations[mid]) / 2, len(durations)
+	}
+	return durations[mid], len(durations)
+}
+
+// ExpectedDuration fetches the history of a package on one release and
+// architecture and returns the median duration of its past runs, e.g. to
+// show how long a newly triggered test should take. It returns
+// ErrNoDurations if none of the runs has a duration.
+func (s *Scraper) ExpectedDuration(packageName, release, arch string) (time.Duration, error) {
+	history, err := s.FetchTestHistory(packageName, release, arch)
+	if err != nil {
+		return 0, err
+	}
+	median, runs := MedianDuration(history)
+	if runs == 0 {
+		return 0, fmt.Errorf("%w for %s on %s/%s", ErrNoDurations, packageName, release, arch)
+	}
+	return median, nil
+}
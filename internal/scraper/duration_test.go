@@ -0,0 +1,64 @@
+package scraper
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMedianDuration(t *testing.T) {
+	history := []TestResult{
+		{Status: "pass", Duration: "10m"},
+		{Status: "fail", Duration: "30m"},
+		{Status: "tmpfail", Duration: "1m"},
+		{Status: "pass", Duration: ""},
+		{Status: "pass", Duration: "20m"},
+		{Status: "pass", Duration: "1h"},
+	}
+
+	median, runs := MedianDuration(history)
+	if runs != 4 {
+		t.Errorf("Expected 4 runs, got %d", runs)
+	}
+	if median != 25*time.Minute {
+		t.Errorf("Expected median 25m, got %v", median)
+	}
+
+	if median, runs := MedianDuration(history[:1]); median != 10*time.Minute || runs != 1 {
+		t.Errorf("Expected 10m from 1 run, got %v from %d", median, runs)
+	}
+	if _, runs := MedianDuration(nil); runs != 0 {
+		t.Errorf("Expected 0 runs for empty history, got %d", runs)
+	}
+}
+
+func TestExpectedDuration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/packages/ovn/noble/amd64" {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`<table><tr><th>Result</th></tr><tr><td>pass</td></tr></table>`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockHistoryPageFull))
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	// The tmpfail run is skipped: median of 1h 02m 11s and 1h 20m 25s
+	got, err := s.ExpectedDuration("ovn", "noble", "amd64")
+	if err != nil {
+		t.Fatalf("ExpectedDuration failed: %v", err)
+	}
+	if want := time.Hour + 11*time.Minute + 18*time.Second; got != want {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if _, err := s.ExpectedDuration("ovn", "noble", "arm64"); !errors.Is(err, ErrNoDurations) {
+		t.Errorf("Expected ErrNoDurations, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package scraper
+
+import (
+	"strings"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+)
+
+const (
+	// DefaultFlakinessWindow is the number of recent runs DetectFlaky looks at
+	DefaultFlakinessWindow = 10
+
+	// flakyFlips is the number of pass/fail flips from which a test is flaky;
+	// a single flip is a regression (or a fix), not flakiness
+	flakyFlips = 2
+)
+
+// FlakinessReport summarizes how a test behaved over its recent runs
+type FlakinessReport struct {
+	Runs         int    `json:"runs"`          // Runs with a pass/fail verdict that were analyzed
+	Flips        int    `json:"flips"`         // Changes between passing and failing
+	Flaky        bool   `json:"flaky"`         // Flips reached the flakiness threshold
+	StableStatus string `json:"stable_status"` // Most recent status seen on two consecutive runs, empty if none
+}
+
+// AnalyzeFlakiness looks at the last window runs of history (newest first, as
+// returned by FetchTestHistory) and counts how often the test flipped between
+// passing and failing. tmpfail runs are skipped: they are infrastructure
+// failures and say nothing about the test itself. A window <= 0 analyzes the
+// whole history.
+func AnalyzeFlakiness(history []TestResult, window int) FlakinessReport {
+	var verdicts []TestResult
+	for _, run := range history {
+		if strings.TrimSpace(run.Status) == "" || autopkgtest.ParseStatus(run.Status) == autopkgtest.StatusTmpfail {
+			continue
+		}
+		verdicts = append(verdicts, run)
+		if window > 0 && len(verdicts) == window {
+			break
+		}
+	}
+
+	report := FlakinessReport{Runs: len(verdicts)}
+	for i := 1; i < len(verdicts); i++ {
+		if isPassingStatus(verdicts[i].Status) != isPassingStatus(verdicts[i-1].Status) {
+			report.Flips++
+		} else if report.StableStatus == "" {
+			report.StableStatus = verdicts[i-1].Status
+		}
+	}
+	report.Flaky = report.Flips >= flakyFlips
+
+	return report
+}
+
+// DetectFlaky reports whether the test flipped between pass and fail at
+// least twice in its last DefaultFlakinessWindow runs
+func DetectFlaky(history []TestResult) bool {
+	return AnalyzeFlakiness(history, DefaultFlakinessWindow).Flaky
+}
@@ -0,0 +1,59 @@
+package scraper
+
+import "testing"
+
+func historyOf(statuses ...string) []TestResult {
+	var history []TestResult
+	for _, status := range statuses {
+		history = append(history, TestResult{Package: "ovn", Release: "noble", Architecture: "amd64", Status: status})
+	}
+	return history
+}
+
+func TestAnalyzeFlakiness(t *testing.T) {
+	tests := []struct {
+		name       string
+		history    []TestResult
+		window     int
+		wantRuns   int
+		wantFlips  int
+		wantFlaky  bool
+		wantStable string
+	}{
+		{"always passing", historyOf("pass", "pass", "pass"), 10, 3, 0, false, "pass"},
+		{"regression", historyOf("fail", "fail", "pass", "pass"), 10, 4, 1, false, "fail"},
+		{"alternating", historyOf("pass", "fail", "pass", "fail", "fail"), 10, 5, 3, true, "fail"},
+		{"tmpfail ignored", historyOf("pass", "tmpfail", "pass", "tmpfail"), 10, 2, 0, false, "pass"},
+		{"neutral counts as passing", historyOf("neutral", "pass"), 10, 2, 0, false, "neutral"},
+		{"flips outside window", historyOf("pass", "pass", "fail", "pass", "fail"), 2, 2, 0, false, "pass"},
+		{"no stable status", historyOf("pass", "fail"), 0, 2, 1, false, ""},
+		{"empty history", nil, 10, 0, 0, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := AnalyzeFlakiness(tt.history, tt.window)
+			if report.Runs != tt.wantRuns {
+				t.Errorf("Expected %d runs, got %d", tt.wantRuns, report.Runs)
+			}
+			if report.Flips != tt.wantFlips {
+				t.Errorf("Expected %d flips, got %d", tt.wantFlips, report.Flips)
+			}
+			if report.Flaky != tt.wantFlaky {
+				t.Errorf("Expected flaky=%v, got %v", tt.wantFlaky, report.Flaky)
+			}
+			if report.StableStatus != tt.wantStable {
+				t.Errorf("Expected stable status %q, got %q", tt.wantStable, report.StableStatus)
+			}
+		})
+	}
+}
+
+func TestDetectFlaky(t *testing.T) {
+	if !DetectFlaky(historyOf("fail", "pass", "fail", "pass")) {
+		t.Error("Expected alternating history to be flaky")
+	}
+	if DetectFlaky(historyOf("fail", "fail", "pass", "pass")) {
+		t.Error("Expected a single regression not to be flaky")
+	}
+}
@@ -0,0 +1,160 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"github.com/canonical/autopkgtest-automation/internal/httpbody"
+)
+
+// FetchTestHistory fetches the run history of a package on one release and
+// architecture (/packages/<pkg>/<release>/<arch>) and returns every run
+// listed there, newest first. Each run has its status, date (LastRun),
+// duration, triggers and log link filled in when the page shows them.
+func (s *Scraper) FetchTestHistory(packageName, release, arch string) ([]TestResult, error) {
+	historyURL := fmt.Sprintf("%s/packages/%s/%s/%s", s.BaseURL, packageName, release, arch)
+
+	resp, err := s.get(context.Background(), historyURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch test history: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := httpbody.Read(resp, s.MaxResponseBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return parseHistory(string(body), historyURL, packageName, release, arch)
+}
+
+// FetchTestHistorySince is FetchTestHistory limited to the runs at or after
+// since, e.g. to check whether a test failed in the last 30 days. Runs whose
+// date is not shown on the page are left out, since they cannot be placed.
+func (s *Scraper) FetchTestHistorySince(packageName, release, arch string, since time.Time) ([]TestResult, error) {
+	history, err := s.FetchTestHistory(packageName, release, arch)
+	if err != nil {
+		return nil, err
+	}
+
+	// History is sorted newest first, with undated runs last
+	for i, run := range history {
+		if run.LastRun.Before(since) {
+			return history[:i], nil
+		}
+	}
+	return history, nil
+}
+
+// parseHistory extracts the runs from a history page. Columns are located by
+// their header (Triggers, Date, Duration, Result), so their order does not
+// matter; the log link may be in any cell of the row.
+func parseHistory(htmlContent, pageURL, packageName, release, arch string) ([]TestResult, error) {
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid history URL: %w", err)
+	}
+
+	var rows [][]*html.Node
+	collectRows(doc, &rows)
+
+	columns := map[string]int{}
+	var history []TestResult
+	for _, cells := range rows {
+		if cells[0].Data == "th" {
+			columns = map[string]int{}
+			for i, cell := range cells {
+				columns[strings.ToLower(strings.TrimSpace(getNodeText(cell)))] = i
+			}
+			continue
+		}
+
+		resultCol, ok := columns["result"]
+		if !ok || resultCol >= len(cells) {
+			continue
+		}
+
+		run := TestResult{
+			Package:      packageName,
+			Release:      release,
+			Architecture: arch,
+			Status:       extractStatusFromCell(cells[resultCol]),
+		}
+		if run.Status == "" {
+			continue
+		}
+		if i, ok := columns["triggers"]; ok && i < len(cells) {
+			run.Trigger = strings.Join(cellFields(cells[i]), " ")
+		}
+		if i, ok := columns["duration"]; ok && i < len(cells) {
+			run.Duration = strings.TrimSpace(getNodeText(cells[i]))
+		}
+		if i, ok := columns["date"]; ok && i < len(cells) {
+			run.LastRun = parseRunDate(getNodeText(cells[i]))
+		}
+		for _, cell := range cells {
+			if href := findLogLink(cell); href != "" {
+				if ref, err := url.Parse(href); err == nil {
+					run.LogURL = base.ResolveReference(ref).String()
+				}
+				break
+			}
+		}
+
+		history = append(history, run)
+	}
+
+	// The server lists the newest run first; make sure of it when dates are known
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].LastRun.After(history[j].LastRun)
+	})
+
+	return history, nil
+}
+
+// collectRows appends the <td>/<th> cells of every non-empty <tr> under n
+func collectRows(n *html.Node, rows *[][]*html.Node) {
+	if n.Type == html.ElementNode && n.Data == "tr" {
+		var cells []*html.Node
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && (c.Data == "td" || c.Data == "th") {
+				cells = append(cells, c)
+			}
+		}
+		if len(cells) > 0 {
+			*rows = append(*rows, cells)
+		}
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		collectRows(c, rows)
+	}
+}
+
+// cellFields returns the whitespace-separated words of every text node under
+// n; unlike getNodeText, words split only by markup such as <br> stay apart
+func cellFields(n *html.Node) []string {
+	var fields []string
+	if n.Type == html.TextNode {
+		fields = strings.Fields(n.Data)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		fields = append(fields, cellFields(c)...)
+	}
+	return fields
+}
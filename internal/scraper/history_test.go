@@ -0,0 +1,155 @@
+package scraper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const mockHistoryPageFull = `
+<!DOCTYPE html>
+<html>
+<body>
+<h2>ovn/noble/amd64</h2>
+<table class="table">
+  <tr><th>Version</th><th>Triggers</th><th>Date</th><th>Duration</th><th>Testbed</th><th>Result</th><th></th></tr>
+  <tr>
+    <td>25.09.0-2</td>
+    <td>ovn/25.09.0-2</td>
+    <td>2026-01-20 10:00:00 UTC</td>
+    <td>1h 02m 11s</td>
+    <td>noble-amd64-1</td>
+    <td class="pass">pass</td>
+    <td><a href="/results/autopkgtest-noble/noble/amd64/o/ovn/20260120_100000_aaaaa@/log.gz">log</a></td>
+  </tr>
+  <tr>
+    <td>25.09.0-3</td>
+    <td>ovn/25.09.0-3<br>openssl/3.5.4-1ubuntu1</td>
+    <td>2026-02-02 15:37:43 UTC</td>
+    <td>1h 20m 25s</td>
+    <td>noble-amd64-2</td>
+    <td class="fail">fail</td>
+    <td><a href="/results/autopkgtest-noble/noble/amd64/o/ovn/20260202_153743_38f00@/log.gz">log</a></td>
+  </tr>
+  <tr>
+    <td>25.09.0-1</td>
+    <td>ovn/25.09.0-1</td>
+    <td>2026-01-05 08:30:00 UTC</td>
+    <td>58m 40s</td>
+    <td>noble-amd64-1</td>
+    <td class="tmpfail">tmpfail</td>
+    <td></td>
+  </tr>
+</table>
+</body>
+</html>
+`
+
+func TestFetchTestHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/packages/ovn/noble/amd64" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockHistoryPageFull))
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	history, err := s.FetchTestHistory("ovn", "noble", "amd64")
+	if err != nil {
+		t.Fatalf("FetchTestHistory failed: %v", err)
+	}
+
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 runs, got %d", len(history))
+	}
+
+	// Newest first
+	wantStatuses := []string{"fail", "pass", "tmpfail"}
+	for i, want := range wantStatuses {
+		if history[i].Status != want {
+			t.Errorf("Run %d: expected status %q, got %q", i, want, history[i].Status)
+		}
+	}
+
+	latest := history[0]
+	if latest.Package != "ovn" || latest.Release != "noble" || latest.Architecture != "amd64" {
+		t.Errorf("Expected ovn/noble/amd64, got %s/%s/%s", latest.Package, latest.Release, latest.Architecture)
+	}
+	if latest.Trigger != "ovn/25.09.0-3 openssl/3.5.4-1ubuntu1" {
+		t.Errorf("Expected both triggers, got %q", latest.Trigger)
+	}
+	if latest.Duration != "1h 20m 25s" {
+		t.Errorf("Expected duration '1h 20m 25s', got %q", latest.Duration)
+	}
+	if want := time.Date(2026, 2, 2, 15, 37, 43, 0, time.UTC); !latest.LastRun.Equal(want) {
+		t.Errorf("Expected date %v, got %v", want, latest.LastRun)
+	}
+	if want := server.URL + "/results/autopkgtest-noble/noble/amd64/o/ovn/20260202_153743_38f00@/log.gz"; latest.LogURL != want {
+		t.Errorf("Expected log URL %q, got %q", want, latest.LogURL)
+	}
+
+	if history[2].LogURL != "" {
+		t.Errorf("Expected no log URL for run without a log, got %q", history[2].LogURL)
+	}
+}
+
+func TestFetchTestHistorySince(t *testing.T) {
+	undated := strings.Replace(mockHistoryPageFull, "2026-01-05 08:30:00 UTC", "unknown", 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(undated))
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	tests := []struct {
+		name  string
+		since time.Time
+		want  []string
+	}{
+		{name: "between runs", since: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC), want: []string{"fail", "pass"}},
+		{name: "exact run date included", since: time.Date(2026, 2, 2, 15, 37, 43, 0, time.UTC), want: []string{"fail"}},
+		{name: "after every run", since: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), want: nil},
+		{name: "undated runs excluded", since: time.Time{}.Add(time.Second), want: []string{"fail", "pass"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history, err := s.FetchTestHistorySince("ovn", "noble", "amd64", tt.since)
+			if err != nil {
+				t.Fatalf("FetchTestHistorySince failed: %v", err)
+			}
+			var statuses []string
+			for _, run := range history {
+				statuses = append(statuses, run.Status)
+			}
+			if !slices.Equal(statuses, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, statuses)
+			}
+		})
+	}
+}
+
+func TestFetchTestHistory_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	if _, err := s.FetchTestHistory("ovn", "noble", "amd64"); err == nil {
+		t.Error("Expected error for missing history page")
+	}
+}
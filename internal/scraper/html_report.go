@@ -0,0 +1,117 @@
+package scraper
+
+import (
+	"fmt"
+	"html/template"
+	"slices"
+	"strings"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+)
+
+// htmlReportTemplate is a self-contained page (inline styles only) so that
+// it can be pasted into an email
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>autopkgtest results for {{.Package}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: center; }
+th { background: #f0f0f0; }
+td.pass { background: #c8f7c5; }
+td.neutral { background: #e0e0e0; }
+td.fail, td.regression { background: #f7c5c5; }
+td.tmpfail { background: #f7e6c5; }
+td.other { background: #fff; }
+</style>
+</head>
+<body>
+<h1>autopkgtest results for {{.Package}}</h1>
+<p>{{len .Errors}} failing of {{len .Tests}} test(s)</p>
+<table>
+<tr><th>Release</th>{{range .Archs}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr><th>{{.Release}}</th>{{range .Cells}}<td class="{{.Class}}">{{if .Link}}<a href="{{.Link}}">{{.Status}}</a>{{else}}{{.Status}}{{end}}</td>{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// htmlCell is a single release/arch cell of the HTML report
+type htmlCell struct {
+	Status string
+	Class  string
+	Link   string // Results page, set for failures only
+}
+
+// htmlRow is a release row of the HTML report
+type htmlRow struct {
+	Release string
+	Cells   []htmlCell
+}
+
+// RenderHTML formats all test results as a self-contained HTML page with a
+// release × architecture table. Statuses are colored and failures link to
+// their results page. Columns follow archOrder, as in RenderTable.
+func (r *PackageResults) RenderHTML(archOrder []string) (string, error) {
+	var releases, archs []string
+	var tested []TestResult
+	tests := make(map[[2]string]TestResult)
+	for _, test := range r.Tests {
+		if !isTested(test) {
+			continue
+		}
+		tested = append(tested, test)
+		if !slices.Contains(releases, test.Release) {
+			releases = append(releases, test.Release)
+		}
+		if !slices.Contains(archs, test.Architecture) {
+			archs = append(archs, test.Architecture)
+		}
+		tests[[2]string{test.Release, test.Architecture}] = test
+	}
+	archs = OrderArchitectures(archs, archOrder)
+
+	var rows []htmlRow
+	for _, release := range releases {
+		row := htmlRow{Release: release}
+		for _, arch := range archs {
+			test, ok := tests[[2]string{release, arch}]
+			if !ok {
+				row.Cells = append(row.Cells, htmlCell{Status: "-", Class: "other"})
+				continue
+			}
+			cell := htmlCell{Status: test.Status, Class: statusClass(test.Status)}
+			if !isPassingStatus(test.Status) {
+				cell.Link = test.LogURL
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+		rows = append(rows, row)
+	}
+
+	var out strings.Builder
+	err := htmlReportTemplate.Execute(&out, struct {
+		Package string
+		Tests   []TestResult
+		Errors  []TestResult
+		Archs   []string
+		Rows    []htmlRow
+	}{r.Package, tested, r.Errors, archs, rows})
+	if err != nil {
+		return "", fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return out.String(), nil
+}
+
+// statusClass maps a status to the CSS class used to color its cell
+func statusClass(status string) string {
+	switch s := autopkgtest.ParseStatus(status); s {
+	case autopkgtest.StatusPass, autopkgtest.StatusNeutral, autopkgtest.StatusFail, autopkgtest.StatusRegression, autopkgtest.StatusTmpfail:
+		return string(s)
+	default:
+		return "other"
+	}
+}
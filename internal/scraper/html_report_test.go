@@ -0,0 +1,43 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderHTML(t *testing.T) {
+	results := &PackageResults{
+		Package: "evil<script>alert(1)</script>",
+		Tests: []TestResult{
+			{Release: "noble", Architecture: "amd64", Status: "fail", LogURL: "https://autopkgtest.ubuntu.com/packages/o/ovn/noble/amd64"},
+			{Release: "noble", Architecture: "arm64", Status: "pass", LogURL: "https://autopkgtest.ubuntu.com/packages/o/ovn/noble/arm64"},
+			{Release: "jammy", Architecture: "amd64", Status: "neutral"},
+		},
+	}
+	results.Errors = []TestResult{results.Tests[0]}
+
+	page, err := results.RenderHTML([]string{"arm64"})
+	if err != nil {
+		t.Fatalf("RenderHTML failed: %v", err)
+	}
+
+	if strings.Contains(page, "<script>") {
+		t.Errorf("Expected package name to be escaped, got:\n%s", page)
+	}
+	if !strings.Contains(page, "evil&lt;script&gt;") {
+		t.Errorf("Expected escaped package name in page, got:\n%s", page)
+	}
+
+	wantCells := []string{
+		`<tr><th>Release</th><th>arm64</th><th>amd64</th></tr>`,
+		`<td class="fail"><a href="https://autopkgtest.ubuntu.com/packages/o/ovn/noble/amd64">fail</a></td>`,
+		`<td class="pass">pass</td>`,
+		`<td class="neutral">neutral</td>`,
+		`<td class="other">-</td>`,
+	}
+	for _, want := range wantCells {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected page to contain %q, got:\n%s", want, page)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/canonical/autopkgtest-automation/internal/httpbody"
+)
+
+// LogDownload describes the outcome of downloading the log for one test
+type LogDownload struct {
+	Test    TestResult
+	Path    string // Local file the log was (or would have been) written to
+	Skipped bool   // True when the file already existed and was not downloaded again
+	Err     error  // Non-nil if the download failed
+}
+
+// DownloadFailureLogs downloads the latest log artifact of every error in
+// results into dir, naming each file <release>_<arch>.log.gz. Files that
+// already exist are skipped. A failure to download one log does not stop
+// the others; it is reported in the corresponding LogDownload.
+func (s *Scraper) DownloadFailureLogs(results *PackageResults, dir string) ([]LogDownload, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	var downloads []LogDownload
+	for _, test := range results.Errors {
+		name := fmt.Sprintf("%s_%s.log.gz", test.Release, test.Architecture)
+		download := LogDownload{
+			Test: test,
+			Path: filepath.Join(dir, name),
+		}
+
+		if _, err := os.Stat(download.Path); err == nil {
+			download.Skipped = true
+			downloads = append(downloads, download)
+			continue
+		}
+
+		if test.LogURL == "" {
+			download.Err = fmt.Errorf("no results page for %s/%s", test.Release, test.Architecture)
+		} else if logURL, err := s.FindLogURL(test.LogURL); err != nil {
+			download.Err = err
+		} else {
+			download.Err = s.downloadFile(logURL, download.Path)
+		}
+
+		downloads = append(downloads, download)
+	}
+
+	return downloads, nil
+}
+
+// FindLogURL fetches a release/arch results history page and returns the
+// absolute URL of the most recent log artifact (the first log.gz link)
+func (s *Scraper) FindLogURL(historyURL string) (string, error) {
+	resp, err := s.get(context.Background(), historyURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch results page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := httpbody.Read(resp, s.MaxResponseBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to read results page: %w", err)
+	}
+
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	href := findLogLink(doc)
+	if href == "" {
+		return "", fmt.Errorf("no log found on %s", historyURL)
+	}
+
+	base, err := url.Parse(historyURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid results page URL: %w", err)
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("invalid log URL %q: %w", href, err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// findLogLink returns the href of the first <a> whose target is a log.gz file
+func findLogLink(n *html.Node) string {
+	if n.Type == html.ElementNode && n.Data == "a" {
+		for _, attr := range n.Attr {
+			if attr.Key == "href" && strings.HasSuffix(attr.Val, "log.gz") {
+				return attr.Val
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if href := findLogLink(c); href != "" {
+			return href
+		}
+	}
+	return ""
+}
+
+// downloadFile writes the body of url to path. The data is written to a
+// temporary file first so that an interrupted download is not mistaken for
+// a complete one on the next run.
+func (s *Scraper) downloadFile(url, path string) error {
+	resp, err := s.get(context.Background(), url)
+	if err != nil {
+		return fmt.Errorf("failed to download log: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download log: unexpected status code: %d", resp.StatusCode)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create log file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write log file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write log file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,133 @@
+package scraper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const mockHistoryPage = `
+<!DOCTYPE html>
+<html>
+<body>
+<table class="table">
+  <tr><th>Version</th><th>Triggers</th><th>Date</th><th>Result</th><th></th></tr>
+  <tr>
+    <td>25.09.0-3</td>
+    <td>ovn/25.09.0-3</td>
+    <td>2026-02-02 15:37:43 UTC</td>
+    <td class="fail">fail</td>
+    <td><a href="/results/autopkgtest-noble/noble/amd64/o/ovn/20260202_153743_38f00@/log.gz">log</a></td>
+  </tr>
+  <tr>
+    <td>25.09.0-2</td>
+    <td>ovn/25.09.0-2</td>
+    <td>2026-01-20 10:00:00 UTC</td>
+    <td class="pass">pass</td>
+    <td><a href="/results/autopkgtest-noble/noble/amd64/o/ovn/20260120_100000_aaaaa@/log.gz">log</a></td>
+  </tr>
+</table>
+</body>
+</html>
+`
+
+func TestDownloadFailureLogs(t *testing.T) {
+	logRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/packages/ovn":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockHTMLWithErrors))
+		case "/ovn/noble/amd64":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockHistoryPage))
+		case "/results/autopkgtest-noble/noble/amd64/o/ovn/20260202_153743_38f00@/log.gz":
+			logRequests++
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("latest log"))
+		default:
+			// jammy/arm64 history page is unavailable
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	results, err := s.FetchPackageResults("ovn")
+	if err != nil {
+		t.Fatalf("FetchPackageResults failed: %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "logs")
+	downloads, err := s.DownloadFailureLogs(results, dir)
+	if err != nil {
+		t.Fatalf("DownloadFailureLogs failed: %v", err)
+	}
+
+	if len(downloads) != 2 {
+		t.Fatalf("Expected 2 downloads, got %d", len(downloads))
+	}
+
+	byName := map[string]LogDownload{}
+	for _, d := range downloads {
+		byName[filepath.Base(d.Path)] = d
+	}
+
+	noble, ok := byName["noble_amd64.log.gz"]
+	if !ok {
+		t.Fatal("Missing download for noble/amd64")
+	}
+	if noble.Err != nil {
+		t.Errorf("Expected noble/amd64 download to succeed, got: %v", noble.Err)
+	}
+	data, err := os.ReadFile(noble.Path)
+	if err != nil {
+		t.Fatalf("Failed to read downloaded log: %v", err)
+	}
+	if string(data) != "latest log" {
+		t.Errorf("Expected the most recent log to be downloaded, got %q", string(data))
+	}
+
+	// A failing download must not stop the others, and must not leave a file
+	jammy, ok := byName["jammy_arm64.log.gz"]
+	if !ok {
+		t.Fatal("Missing download for jammy/arm64")
+	}
+	if jammy.Err == nil {
+		t.Error("Expected jammy/arm64 download to fail")
+	}
+	if _, err := os.Stat(jammy.Path); !os.IsNotExist(err) {
+		t.Errorf("Expected no file for failed download, stat returned: %v", err)
+	}
+
+	// A second run skips logs that were already downloaded
+	downloads, err = s.DownloadFailureLogs(results, dir)
+	if err != nil {
+		t.Fatalf("DownloadFailureLogs failed: %v", err)
+	}
+	for _, d := range downloads {
+		if filepath.Base(d.Path) == "noble_amd64.log.gz" && !d.Skipped {
+			t.Error("Expected existing noble/amd64 log to be skipped")
+		}
+	}
+	if logRequests != 1 {
+		t.Errorf("Expected log to be downloaded once, got %d requests", logRequests)
+	}
+}
+
+func TestFindLogURL_NoLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockHTMLEmpty))
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	if _, err := s.FindLogURL(server.URL + "/ovn/noble/amd64"); err == nil {
+		t.Error("Expected error when page has no log link")
+	}
+}
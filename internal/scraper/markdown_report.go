@@ -0,0 +1,84 @@
+package scraper
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// markdownEscaper escapes the characters that would break a Markdown table
+// cell or link text
+var markdownEscaper = strings.NewReplacer("|", `\|`, "[", `\[`, "]", `\]`, "\n", " ")
+
+// WriteMarkdown writes all test results to w as a Markdown table laid out
+// like the results matrix, with one row per architecture and one column per
+// release, e.g. for pasting into a bug report. Rows follow archOrder, as the
+// columns of RenderTable do. Statuses link to their results page when known,
+// and the results counted in r.Errors are in bold.
+func (r *PackageResults) WriteMarkdown(w io.Writer, archOrder []string) error {
+	var releases, archs []string
+	tests := make(map[[2]string]TestResult)
+	tested := 0
+	for _, test := range r.Tests {
+		if !isTested(test) {
+			continue
+		}
+		tested++
+		if !slices.Contains(releases, test.Release) {
+			releases = append(releases, test.Release)
+		}
+		if !slices.Contains(archs, test.Architecture) {
+			archs = append(archs, test.Architecture)
+		}
+		tests[[2]string{test.Release, test.Architecture}] = test
+	}
+	archs = OrderArchitectures(archs, archOrder)
+	failing := make(map[[2]string]bool)
+	for _, err := range r.Errors {
+		failing[[2]string{err.Release, err.Architecture}] = true
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "**autopkgtest results for %s**: %d failing of %d test(s)\n\n", markdownEscaper.Replace(r.Package), len(r.Errors), tested)
+	if tested > 0 {
+		header := []string{"arch"}
+		separator := []string{"---"}
+		for _, release := range releases {
+			header = append(header, markdownEscaper.Replace(release))
+			separator = append(separator, "---")
+		}
+		fmt.Fprintf(&out, "| %s |\n| %s |\n", strings.Join(header, " | "), strings.Join(separator, " | "))
+
+		for _, arch := range archs {
+			row := []string{markdownEscaper.Replace(arch)}
+			for _, release := range releases {
+				test, ok := tests[[2]string{release, arch}]
+				if !ok {
+					row = append(row, "-")
+					continue
+				}
+				row = append(row, markdownCell(test, failing[[2]string{release, arch}]))
+			}
+			fmt.Fprintf(&out, "| %s |\n", strings.Join(row, " | "))
+		}
+	}
+
+	if _, err := io.WriteString(w, out.String()); err != nil {
+		return fmt.Errorf("failed to write Markdown: %w", err)
+	}
+	return nil
+}
+
+// markdownCell formats the status of test, linked to its results page if
+// known and in bold if it is failing
+func markdownCell(test TestResult, failing bool) string {
+	cell := markdownEscaper.Replace(test.Status)
+	if test.LogURL != "" {
+		cell = fmt.Sprintf("[%s](%s)", cell, strings.NewReplacer(" ", "%20", ")", "%29").Replace(test.LogURL))
+	}
+	if failing {
+		cell = "**" + cell + "**"
+	}
+	return cell
+}
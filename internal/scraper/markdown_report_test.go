@@ -0,0 +1,75 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	results := &PackageResults{
+		Package: "ovn",
+		Tests: []TestResult{
+			{Release: "noble", Architecture: "amd64", Status: "fail", LogURL: "https://autopkgtest.ubuntu.com/packages/o/ovn/noble/amd64"},
+			{Release: "noble", Architecture: "arm64", Status: "pass", LogURL: "https://autopkgtest.ubuntu.com/packages/o/ovn/noble/arm64"},
+			{Release: "jammy", Architecture: "amd64", Status: "neutral"},
+			{Release: "jammy", Architecture: "arm64", Status: string(autopkgtest.StatusNoData)},
+		},
+	}
+	results.Errors = []TestResult{results.Tests[0]}
+
+	var out strings.Builder
+	if err := results.WriteMarkdown(&out, nil); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+
+	// Releases are columns and architectures rows, as on the results page
+	want := "**autopkgtest results for ovn**: 1 failing of 3 test(s)\n\n" +
+		"| arch | noble | jammy |\n" +
+		"| --- | --- | --- |\n" +
+		"| amd64 | **[fail](https://autopkgtest.ubuntu.com/packages/o/ovn/noble/amd64)** | neutral |\n" +
+		"| arm64 | [pass](https://autopkgtest.ubuntu.com/packages/o/ovn/noble/arm64) | - |\n"
+	if out.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, out.String())
+	}
+}
+
+func TestWriteMarkdown_ArchOrder(t *testing.T) {
+	results := &PackageResults{
+		Package: "ovn",
+		Tests: []TestResult{
+			{Release: "noble", Architecture: "amd64", Status: "pass"},
+			{Release: "noble", Architecture: "arm64", Status: "pass"},
+			{Release: "noble", Architecture: "s390x", Status: "pass"},
+		},
+	}
+
+	var out strings.Builder
+	if err := results.WriteMarkdown(&out, []string{"s390x", "amd64"}); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+
+	// Unlisted architectures follow in their original order
+	want := "| s390x | pass |\n| amd64 | pass |\n| arm64 | pass |\n"
+	if !strings.HasSuffix(out.String(), want) {
+		t.Errorf("Expected rows in -arch-order order, got:\n%s", out.String())
+	}
+}
+
+func TestWriteMarkdown_Escaping(t *testing.T) {
+	results := &PackageResults{
+		Package: "odd|pkg",
+		Tests: []TestResult{
+			{Release: "noble", Architecture: "amd64", Status: "fail|ed", LogURL: "https://example.com/a b)"},
+		},
+	}
+
+	var out strings.Builder
+	if err := results.WriteMarkdown(&out, nil); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `odd\|pkg`) || !strings.Contains(out.String(), `[fail\|ed](https://example.com/a%20b%29)`) {
+		t.Errorf("Expected pipes and link characters to be escaped, got:\n%s", out.String())
+	}
+}
@@ -1,51 +1,240 @@
 package scraper
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
+	"maps"
 	"net/http"
+	"net/url"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
 
 	"golang.org/x/net/html"
+	"golang.org/x/net/http/httpproxy"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+	"github.com/canonical/autopkgtest-automation/internal/httpbody"
 )
 
 // TestResult represents a single autopkgtest result
 type TestResult struct {
-	Package      string
-	Release      string // Ubuntu release (focal, jammy, noble, etc.)
-	Architecture string
-	Status       string
-	Duration     string
-	Trigger      string
-	LogURL       string
+	Package      string    `json:"package"`
+	Release      string    `json:"release"` // Ubuntu release (focal, jammy, noble, etc.)
+	Architecture string    `json:"architecture"`
+	Status       string    `json:"status"`
+	Category     string    `json:"category,omitempty"`    // CSS class of the matrix cell, e.g. "regression" or "fail"
+	InProgress   bool      `json:"in_progress,omitempty"` // A run is in progress; Status is usually that of the previous run
+	Duration     string    `json:"duration,omitempty"`
+	LastRun      time.Time `json:"last_run,omitzero"` // When the test last ran (zero if unknown)
+	Trigger      string    `json:"trigger,omitempty"`
+	LogURL       string    `json:"log_url,omitempty"`
 }
 
 // PackageResults contains all test results for a package
 type PackageResults struct {
-	Package string
+	Package       string       `json:"package"`
+	Tests         []TestResult `json:"tests"`
+	Errors        []TestResult `json:"errors"`
+	Releases      []string     `json:"releases"`           // Releases present in the matrix, before filtering
+	Architectures []string     `json:"architectures"`      // Architectures present in the matrix, before filtering
+	Warnings      []string     `json:"warnings,omitempty"` // Non-fatal problems (e.g., a filter value with no results)
+}
+
+// ErrorGroup is a set of errors that share the same status and trigger
+type ErrorGroup struct {
+	Status  string
+	Trigger string
 	Tests   []TestResult
-	Errors  []TestResult
 }
 
+// ErrAuthRequired is returned when the server refuses a request with 403,
+// which it does for invalid or expired sessions on some endpoints
+var ErrAuthRequired = errors.New("authentication required")
+
+// ErrTimeout is returned (wrapped) by WaitForPass when the results do not
+// pass before the timeout
+var ErrTimeout = errors.New("timeout reached")
+
 // Filter represents filter criteria for test results
 type Filter struct {
 	Release      string // Filter by specific release (e.g., "noble", "jammy")
 	Architecture string // Filter by specific architecture (e.g., "amd64", "arm64")
+	Status       string // Filter by status name, or a comma-separated list (e.g., "regression", "fail,regression")
+	Trigger      string // Filter by a trigger the test ran with, or a comma-separated list (e.g., "ovn/25.09.0-3")
 }
 
 // Scraper handles fetching and parsing autopkgtest results
 type Scraper struct {
 	BaseURL string
-	Client  *http.Client
+	Client  *http.Client // Uses HTTP_PROXY, HTTPS_PROXY and NO_PROXY from the environment unless WithProxy or WithTransport is given
+	Headers http.Header  // Extra headers sent on every request (e.g., for corporate gateways)
+	Retry   RetryConfig  // Retry policy for transient server errors when fetching results
+	FailOn  []string     // Statuses counted as errors (empty: every status except pass and neutral)
+
+	// MaxResponseBytes is the largest page body read, so that a broken or
+	// malicious server cannot exhaust memory (zero or less:
+	// httpbody.DefaultMaxResponseBytes). Downloaded logs are written to disk
+	// and not limited.
+	MaxResponseBytes int64
+
+	resultHooks []func(*PackageResults)
+
+	// Last package pages fetched with an ETag or Last-Modified, by URL, for
+	// conditional requests
+	pagesMu sync.Mutex
+	pages   map[string]cachedPage
+}
+
+// cachedPage is a package page body and the validators it was served with
+type cachedPage struct {
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+// RetryConfig controls how fetches are retried when the server returns a
+// transient error
+type RetryConfig struct {
+	MaxRetries        int           // Retries after the first attempt (0 disables retrying)
+	BaseDelay         time.Duration // Delay before the first retry, doubled for each further retry
+	RetryableStatuses []int         // HTTP status codes that are retried
+}
+
+// DefaultRetryConfig returns the retry policy used by NewScraper: up to 3
+// retries of 502, 503 and 504 responses, starting with a 1s delay
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries:        3,
+		BaseDelay:         time.Second,
+		RetryableStatuses: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+// Option configures the Scraper
+type Option func(*Scraper)
+
+// WithHeader adds a header that is sent on every request. It may be given
+// multiple times, including for the same key.
+func WithHeader(key, value string) Option {
+	return func(s *Scraper) {
+		s.Headers.Add(key, value)
+	}
+}
+
+// WithResultHook registers a callback that receives the parsed results
+// (after filtering and error collection) before they are returned, so callers
+// can enrich or annotate them. Hooks run in the order they were registered.
+func WithResultHook(hook func(*PackageResults)) Option {
+	return func(s *Scraper) {
+		s.resultHooks = append(s.resultHooks, hook)
+	}
+}
+
+// WithRetry sets the retry policy for transient server errors
+func WithRetry(cfg RetryConfig) Option {
+	return func(s *Scraper) {
+		s.Retry = cfg
+	}
+}
+
+// WithFailOn sets the statuses that are counted as errors, replacing the
+// default of every status except pass and neutral
+func WithFailOn(statuses ...string) Option {
+	return func(s *Scraper) {
+		s.FailOn = statuses
+	}
+}
+
+// WithMaxResponseBytes sets the largest page body read; larger responses
+// fail with httpbody.ErrResponseTooLarge. A limit of zero or less restores
+// httpbody.DefaultMaxResponseBytes.
+func WithMaxResponseBytes(limit int64) Option {
+	return func(s *Scraper) {
+		s.MaxResponseBytes = limit
+	}
+}
+
+// WithTransport sets the transport used for requests, e.g. one with a custom
+// TLS configuration
+func WithTransport(rt http.RoundTripper) Option {
+	return func(s *Scraper) {
+		s.Client.Transport = rt
+	}
+}
+
+// WithProxy sends every request through the proxy at proxyURL (e.g.
+// "http://proxy.example:3128") instead of the one from the environment,
+// except for requests to the hosts in noProxy, a comma-separated list in the
+// format of NO_PROXY (e.g. "localhost,.internal"). Requests to localhost are
+// never proxied. An invalid proxyURL is reported by the first request.
+func WithProxy(proxyURL, noProxy string) Option {
+	return func(s *Scraper) {
+		proxyFunc := (&httpproxy.Config{HTTPProxy: proxyURL, HTTPSProxy: proxyURL, NoProxy: noProxy}).ProxyFunc()
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+		s.Client.Transport = transport
+	}
 }
 
 // NewScraper creates a new scraper instance
-func NewScraper() *Scraper {
-	return &Scraper{
+func NewScraper(opts ...Option) *Scraper {
+	s := &Scraper{
 		BaseURL: "https://autopkgtest.ubuntu.com",
 		Client:  &http.Client{},
+		Headers: http.Header{},
+		Retry:   DefaultRetryConfig(),
+
+		MaxResponseBytes: httpbody.DefaultMaxResponseBytes,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// get performs a GET request with the configured extra headers. A 403
+// response is returned as ErrAuthRequired.
+func (s *Scraper) get(ctx context.Context, url string) (*http.Response, error) {
+	return s.getWithHeaders(ctx, url, nil)
+}
+
+// getWithHeaders is like get, adding header to the request
+func (s *Scraper) getWithHeaders(ctx context.Context, url string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range s.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
 	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusForbidden {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s returned 403 Forbidden", ErrAuthRequired, req.URL.Path)
+	}
+	return resp, nil
 }
 
 // FetchPackageResults fetches and parses autopkgtest results for a package
@@ -55,24 +244,290 @@ func (s *Scraper) FetchPackageResults(packageName string) (*PackageResults, erro
 
 // FetchPackageResultsFiltered fetches and parses autopkgtest results for a package with optional filtering
 func (s *Scraper) FetchPackageResultsFiltered(packageName string, filter *Filter) (*PackageResults, error) {
+	return s.FetchPackageResultsContext(context.Background(), packageName, filter)
+}
+
+// FetchPackageResultsContext is like FetchPackageResultsFiltered but aborts
+// the request when ctx is cancelled or its deadline expires
+func (s *Scraper) FetchPackageResultsContext(ctx context.Context, packageName string, filter *Filter) (*PackageResults, error) {
+	url := fmt.Sprintf("%s/packages/%s", s.BaseURL, packageName)
+
+	body, err := s.fetchWithRetry(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ParseHTML(string(body), packageName, filter)
+}
+
+// PackageExists reports whether autopkgtest.ubuntu.com has a page for
+// packageName. Unlike an empty results matrix, a missing package is answered
+// with 404 Not Found, for which false is returned with a nil error. Network
+// failures and other status codes are returned as errors.
+func (s *Scraper) PackageExists(packageName string) (bool, error) {
 	url := fmt.Sprintf("%s/packages/%s", s.BaseURL, packageName)
 
-	resp, err := s.Client.Get(url)
+	resp, err := s.get(context.Background(), url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch package results: %w", err)
+		return false, fmt.Errorf("failed to fetch package page: %w", err)
 	}
 	defer resp.Body.Close()
 
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+// TestedArchitectures returns the architectures packageName is tested on in
+// release, that is those whose cell of the results matrix is not empty, in
+// the order of the matrix
+func (s *Scraper) TestedArchitectures(packageName, release string) ([]string, error) {
+	results, err := s.FetchPackageResultsFiltered(packageName, &Filter{Release: release})
+	if err != nil {
+		return nil, err
+	}
+
+	var archs []string
+	for _, test := range results.Tests {
+		if isTested(test) && !slices.Contains(archs, test.Architecture) {
+			archs = append(archs, test.Architecture)
+		}
+	}
+	return archs, nil
+}
+
+// fetchWithRetry fetches url, retrying with exponential backoff while the
+// server answers with one of s.Retry.RetryableStatuses. When every attempt
+// fails, the returned error wraps the failure of each attempt.
+func (s *Scraper) fetchWithRetry(ctx context.Context, url string) ([]byte, error) {
+	var attemptErrs []error
+	delay := s.Retry.BaseDelay
+
+	for attempt := 0; ; attempt++ {
+		body, status, err := s.fetchOnce(ctx, url)
+		if err == nil {
+			return body, nil
+		}
+		attemptErrs = append(attemptErrs, fmt.Errorf("attempt %d: %w", attempt+1, err))
+
+		if !slices.Contains(s.Retry.RetryableStatuses, status) {
+			if attempt == 0 {
+				return nil, err
+			}
+			break
+		}
+		if attempt >= s.Retry.MaxRetries {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			attemptErrs = append(attemptErrs, ctx.Err())
+			return nil, fmt.Errorf("giving up after %d attempt(s): %w", attempt+1, errors.Join(attemptErrs...))
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+
+	return nil, fmt.Errorf("giving up after %d attempt(s): %w", len(attemptErrs), errors.Join(attemptErrs...))
+}
+
+// fetchOnce performs a single fetch of url. On failure it also returns the
+// HTTP status code, or 0 if no response was received. If url was fetched
+// before with an ETag or Last-Modified, the request is conditional and a
+// 304 Not Modified reuses the previous body.
+func (s *Scraper) fetchOnce(ctx context.Context, url string) ([]byte, int, error) {
+	s.pagesMu.Lock()
+	page, cached := s.pages[url]
+	s.pagesMu.Unlock()
+
+	header := http.Header{}
+	if cached {
+		if page.etag != "" {
+			header.Set("If-None-Match", page.etag)
+		}
+		if page.lastModified != "" {
+			header.Set("If-Modified-Since", page.lastModified)
+		}
+	}
+
+	resp, err := s.getWithHeaders(ctx, url, header)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch package results: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		if cached {
+			return page.body, 0, nil
+		}
+		// Nothing to reuse: fall back to a full, unconditional GET
+		resp.Body.Close()
+		resp, err = s.get(ctx, url)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to fetch package results: %w", err)
+		}
+		defer resp.Body.Close()
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, resp.StatusCode, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := httpbody.Read(resp, s.MaxResponseBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, 0, fmt.Errorf("failed to read response body: %w", err)
 	}
+	s.storePage(url, resp.Header, body)
+	return body, 0, nil
+}
 
-	return s.ParseHTML(string(body), packageName, filter)
+// storePage remembers body for conditional requests to url if the response
+// carried an ETag or Last-Modified, and forgets url otherwise
+func (s *Scraper) storePage(url string, header http.Header, body []byte) {
+	page := cachedPage{
+		etag:         header.Get("ETag"),
+		lastModified: header.Get("Last-Modified"),
+		body:         body,
+	}
+
+	s.pagesMu.Lock()
+	defer s.pagesMu.Unlock()
+	if page.etag == "" && page.lastModified == "" {
+		delete(s.pages, url)
+		return
+	}
+	if s.pages == nil {
+		s.pages = make(map[string]cachedPage)
+	}
+	s.pages[url] = page
+}
+
+// defaultConcurrency is the number of workers FetchMultiplePackages uses
+// when given a non-positive concurrency
+const defaultConcurrency = 4
+
+// FetchMultiplePackages fetches the results of several packages in parallel,
+// using at most concurrency simultaneous requests (defaultConcurrency if
+// concurrency <= 0). A failure for one package does not stop the others:
+// each package appears either in the results map or in the errors map.
+// Result hooks may run concurrently and must be safe for that.
+func (s *Scraper) FetchMultiplePackages(packages []string, filter *Filter, concurrency int) (map[string]*PackageResults, map[string]error) {
+	return fetchMultiple(packages, concurrency, func(pkg string) (*PackageResults, error) {
+		return s.FetchPackageResultsFiltered(pkg, filter)
+	})
+}
+
+// fetchMultiple runs fetch for each package on at most concurrency workers
+func fetchMultiple(packages []string, concurrency int, fetch func(string) (*PackageResults, error)) (map[string]*PackageResults, map[string]error) {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	results := make(map[string]*PackageResults)
+	errs := make(map[string]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	jobs := make(chan string)
+	for range min(concurrency, len(packages)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pkg := range jobs {
+				res, err := fetch(pkg)
+				mu.Lock()
+				if err != nil {
+					errs[pkg] = err
+				} else {
+					results[pkg] = res
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, pkg := range packages {
+		jobs <- pkg
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, errs
+}
+
+// WaitForPass re-scrapes the package page until every result matching filter
+// passes, or timeout elapses. The filter should select the cell(s) of interest
+// (e.g., Release "noble" and Architecture "amd64"). The most recent results are
+// returned alongside an error wrapping ErrTimeout.
+func (s *Scraper) WaitForPass(packageName string, filter *Filter, pollInterval, timeout time.Duration) (*PackageResults, error) {
+	isPassing := func(results *PackageResults) bool {
+		return slices.ContainsFunc(results.Tests, isTested) && len(results.Errors) == 0
+	}
+
+	// Check immediately before starting the polling loop
+	results, err := s.FetchPackageResultsFiltered(packageName, filter)
+	if err != nil {
+		return nil, err
+	}
+	if isPassing(results) {
+		return results, nil
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	timeoutTimer := time.NewTimer(timeout)
+	defer timeoutTimer.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			results, err = s.FetchPackageResultsFiltered(packageName, filter)
+			if err != nil {
+				return nil, err
+			}
+			if isPassing(results) {
+				return results, nil
+			}
+
+		case <-timeoutTimer.C:
+			tested := results.TestedCount()
+			if tested == 0 {
+				return results, fmt.Errorf("%w after %v (no matching results)", ErrTimeout, timeout)
+			}
+			return results, fmt.Errorf("%w after %v (%d of %d still not passing)", ErrTimeout, timeout, len(results.Errors), tested)
+		}
+	}
+}
+
+// FetchRetryingTmpfail fetches the results of packageName like
+// FetchPackageResultsFiltered, but while tmpfail is the only error status it
+// waits delay and fetches them again, up to retries times, since tmpfails
+// often clear on their own. Any other error status stops retrying at once.
+// onRetry, if not nil, is called with the retry number and the results
+// before each wait. The last results fetched are returned.
+func (s *Scraper) FetchRetryingTmpfail(packageName string, filter *Filter, retries int, delay time.Duration, onRetry func(retry int, results *PackageResults)) (*PackageResults, error) {
+	onlyTmpfails := func(results *PackageResults) bool {
+		return len(results.Errors) > 0 && !slices.ContainsFunc(results.Errors, func(test TestResult) bool {
+			return !strings.EqualFold(test.Status, string(autopkgtest.StatusTmpfail))
+		})
+	}
+
+	results, err := s.FetchPackageResultsFiltered(packageName, filter)
+	for retry := 1; err == nil && retry <= retries && onlyTmpfails(results); retry++ {
+		if onRetry != nil {
+			onRetry(retry, results)
+		}
+		time.Sleep(delay)
+		results, err = s.FetchPackageResultsFiltered(packageName, filter)
+	}
+	return results, err
 }
 
 // ParseHTML parses the HTML content and extracts test results
@@ -91,6 +546,12 @@ func (s *Scraper) ParseHTML(htmlContent string, packageName string, filter *Filt
 	// Find the results table and parse it
 	table := findResultsTable(doc)
 	if table == nil {
+		// A package without results says so; any other page without the
+		// matrix most likely means its layout changed
+		if !noResultsPageRegex.MatchString(getNodeText(doc)) {
+			results.Warnings = append(results.Warnings, fmt.Sprintf("no results table found on the page of %s", packageName))
+		}
+		s.runResultHooks(results)
 		return results, nil
 	}
 
@@ -102,19 +563,81 @@ func (s *Scraper) ParseHTML(htmlContent string, packageName string, filter *Filt
 		s.parseDataRow(row, releases, results)
 	}
 
-	// Apply filters if provided
+	// Record what the matrix contains before filtering so that filters
+	// matching nothing can be told apart from filters matching only passes
+	for _, test := range results.Tests {
+		if !isTested(test) {
+			continue
+		}
+		if !slices.Contains(results.Releases, test.Release) {
+			results.Releases = append(results.Releases, test.Release)
+		}
+		if !slices.Contains(results.Architectures, test.Architecture) {
+			results.Architectures = append(results.Architectures, test.Architecture)
+		}
+	}
+
+	s.filterAndCollectErrors(results, filter)
+
+	s.runResultHooks(results)
+
+	return results, nil
+}
+
+// filterAndCollectErrors applies filter (if any) to results.Tests and sets
+// results.Errors to the remaining tests with an error status
+func (s *Scraper) filterAndCollectErrors(results *PackageResults, filter *Filter) {
 	if filter != nil {
 		results.Tests = applyFilter(results.Tests, filter)
+		results.Warnings = append(results.Warnings, missingFilterWarnings(results, filter)...)
 	}
 
 	// Collect errors (tests with non-passing status)
+	results.Errors = []TestResult{}
 	for _, test := range results.Tests {
-		if !isPassingStatus(test.Status) {
+		if s.isErrorStatus(test.Status) {
 			results.Errors = append(results.Errors, test)
 		}
 	}
+	results.Errors = dedupeErrors(results.Errors)
+}
 
-	return results, nil
+// dedupeErrors removes errors that share the same release, architecture,
+// status and trigger (e.g. cells counted twice by a malformed page) and sorts
+// the rest by those same fields, so that reports are stable across runs
+func dedupeErrors(errors []TestResult) []TestResult {
+	seen := make(map[[4]string]bool)
+	deduped := []TestResult{}
+	for _, err := range errors {
+		key := [4]string{err.Release, err.Architecture, err.Status, err.Trigger}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		deduped = append(deduped, err)
+	}
+
+	sort.SliceStable(deduped, func(i, j int) bool {
+		a, b := deduped[i], deduped[j]
+		if a.Release != b.Release {
+			return a.Release < b.Release
+		}
+		if a.Architecture != b.Architecture {
+			return a.Architecture < b.Architecture
+		}
+		if a.Status != b.Status {
+			return a.Status < b.Status
+		}
+		return a.Trigger < b.Trigger
+	})
+	return deduped
+}
+
+// runResultHooks passes results to every registered result hook
+func (s *Scraper) runResultHooks(results *PackageResults) {
+	for _, hook := range s.resultHooks {
+		hook(results)
+	}
 }
 
 // applyFilter filters test results based on the provided criteria
@@ -131,12 +654,46 @@ func applyFilter(tests []TestResult, filter *Filter) []TestResult {
 		if filter.Architecture != "" && !matchesArchitecture(test.Architecture, filter.Architecture) {
 			continue
 		}
+		if filter.Status != "" && !matchesStatus(test.Status, filter.Status) {
+			continue
+		}
+		if filter.Trigger != "" && !matchesTrigger(test.Trigger, filter.Trigger) {
+			continue
+		}
 		filtered = append(filtered, test)
 	}
 
 	return filtered
 }
 
+// missingFilterWarnings returns a warning for each filtered release or
+// architecture that does not appear in the package's matrix at all
+func missingFilterWarnings(results *PackageResults, filter *Filter) []string {
+	var warnings []string
+	if filter.Release != "" && !containsFold(results.Releases, filter.Release) {
+		warnings = append(warnings, fmt.Sprintf("release %s has no results for package %s", filter.Release, results.Package))
+	}
+	if filter.Architecture != "" {
+		for _, arch := range strings.Split(filter.Architecture, ",") {
+			arch = strings.TrimSpace(arch)
+			if arch != "" && !containsFold(results.Architectures, arch) {
+				warnings = append(warnings, fmt.Sprintf("architecture %s has no results for package %s", arch, results.Package))
+			}
+		}
+	}
+	return warnings
+}
+
+// containsFold reports whether values contains s, ignoring case
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
 // matchesArchitecture checks whether arch matches the filter, which may be a
 // single architecture ("amd64") or a comma-separated list ("amd64,arm64").
 func matchesArchitecture(arch, filter string) bool {
@@ -148,39 +705,222 @@ func matchesArchitecture(arch, filter string) bool {
 	return false
 }
 
-// isPassingStatus checks if a status indicates a passing test
+// matchesStatus checks whether status matches the filter, which may be a
+// single status name ("regression") or a comma-separated list
+// ("fail,regression"). Decorations such as "✔ " are ignored.
+func matchesStatus(status, filter string) bool {
+	name := statusName(status)
+	if name == "" {
+		return false
+	}
+	for _, f := range strings.Split(filter, ",") {
+		if strings.EqualFold(strings.TrimSpace(f), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesTrigger checks whether one of the space-separated triggers of a
+// test is in the filter, which may be a single trigger ("ovn/25.09.0-3") or a
+// comma-separated list. Tests without a known trigger never match.
+func matchesTrigger(triggers, filter string) bool {
+	for _, trigger := range strings.Fields(triggers) {
+		for _, f := range strings.Split(filter, ",") {
+			if strings.TrimSpace(f) == trigger {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// statusName returns the bare, lowercase status name, without decorations
+// such as "✔ " in front of it. Statuses autopkgtest.ParseStatus does not
+// know keep their last word, so that filters can still name them.
+func statusName(status string) string {
+	if parsed := autopkgtest.ParseStatus(status); parsed != autopkgtest.StatusUnknown {
+		return string(parsed)
+	}
+	fields := strings.Fields(status)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToLower(fields[len(fields)-1])
+}
+
+// isTested reports whether test has an actual result, i.e. its cell was not
+// empty
+func isTested(test TestResult) bool {
+	return test.Status != string(autopkgtest.StatusNoData)
+}
+
+// isPassingStatus checks if a status indicates a passing test, i.e. is one of
+// passingStatuses once parsed. Unknown statuses are never passing.
 func isPassingStatus(status string) bool {
-	normalizedStatus := strings.ToLower(strings.TrimSpace(status))
-	return normalizedStatus == "pass" || normalizedStatus == "✔ pass" ||
-		normalizedStatus == "neutral" || normalizedStatus == "😐 neutral" ||
-		strings.Contains(normalizedStatus, "pass")
+	return slices.Contains(passingStatuses, autopkgtest.ParseStatus(status))
+}
+
+// isErrorStatus reports whether status counts as an error: one of s.FailOn
+// if set, otherwise any non-passing status
+func (s *Scraper) isErrorStatus(status string) bool {
+	if status == string(autopkgtest.StatusNoData) {
+		return false
+	}
+	if len(s.FailOn) == 0 {
+		return !isPassingStatus(status)
+	}
+
+	name := statusName(status)
+	if name == "" {
+		return false
+	}
+	for _, failOn := range s.FailOn {
+		if strings.EqualFold(strings.TrimSpace(failOn), name) {
+			return true
+		}
+	}
+	return false
 }
 
 // ---------------------------------------------------------------------------
 // Table discovery
 // ---------------------------------------------------------------------------
 
+// releaseHeaderRegex matches a release column header: a single lowercase
+// codename such as "noble" or "resolute"
+var releaseHeaderRegex = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// architectureLabelRegex matches an architecture row label such as "amd64",
+// "ppc64el" or "s390x"
+var architectureLabelRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// noResultsPageRegex matches the text of a page telling that a package is
+// not found or has no results, as opposed to a page whose matrix could not
+// be recognized
+var noResultsPageRegex = regexp.MustCompile(`(?i)\b(not found|no results)\b`)
+
 // findResultsTable locates the autopkgtest results table in the parsed HTML
-// document. It looks for a <table> with a CSS class containing "table".
+// document. The table is recognized by its release/architecture matrix
+// structure alone (see isMatrixTable), so neither CSS classes nor a list of
+// known releases is needed. Children are searched before their parents, so
+// when the matrix is nested inside layout tables the innermost qualifying
+// table is returned.
 func findResultsTable(n *html.Node) *html.Node {
-	if n.Type == html.ElementNode && n.Data == "table" {
-		if hasClass(n, "table") {
-			return n
-		}
-	}
-
 	for child := n.FirstChild; child != nil; child = child.NextSibling {
 		if result := findResultsTable(child); result != nil {
 			return result
 		}
 	}
+
+	if n.Type == html.ElementNode && n.Data == "table" && isMatrixTable(n) {
+		return n
+	}
 	return nil
 }
 
-// hasClass checks whether an HTML node has a given CSS class.
-func hasClass(n *html.Node, class string) bool {
-	for _, attr := range n.Attr {
-		if attr.Key == "class" && strings.Contains(attr.Val, class) {
+// isMatrixTable reports whether table is laid out as a results matrix: a
+// header row with an empty corner cell followed by release codenames, and at
+// least one data row made of an architecture label followed by plain status
+// cells (cells holding a nested table indicate a layout table rather than the
+// matrix itself).
+func isMatrixTable(table *html.Node) bool {
+	if !isReleaseHeader(findHeaderRow(table)) {
+		return false
+	}
+
+	_, dataRows := extractTableStructure(table)
+	for _, row := range dataRows {
+		cells := rowCells(row)
+		if len(cells) < 2 || !architectureLabelRegex.MatchString(strings.TrimSpace(getNodeText(cells[0]))) {
+			continue
+		}
+		nested := false
+		for _, cell := range cells {
+			if containsElement(cell, "table") {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			return true
+		}
+	}
+	return false
+}
+
+// isReleaseHeader reports whether tr starts with an empty corner cell and
+// every other non-empty cell looks like a release codename
+func isReleaseHeader(tr *html.Node) bool {
+	if tr == nil {
+		return false
+	}
+	cells := rowCells(tr)
+	if len(cells) < 2 || strings.TrimSpace(getNodeText(cells[0])) != "" {
+		return false
+	}
+
+	releases := 0
+	for _, cell := range cells[1:] {
+		text := strings.TrimSpace(getNodeText(cell))
+		if text == "" {
+			continue
+		}
+		if !releaseHeaderRegex.MatchString(text) {
+			return false
+		}
+		releases++
+	}
+	return releases > 0
+}
+
+// findHeaderRow returns the header <tr> of table, as chosen by
+// extractTableStructure: the first row of a <thead>, or else the first row
+// made only of <th> cells. It returns nil when there is none.
+func findHeaderRow(table *html.Node) *html.Node {
+	for child := table.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		switch child.Data {
+		case "thead":
+			for tr := child.FirstChild; tr != nil; tr = tr.NextSibling {
+				if tr.Type == html.ElementNode && tr.Data == "tr" {
+					return tr
+				}
+			}
+			return nil
+		case "tbody":
+			if tr := findHeaderRow(child); tr != nil {
+				return tr
+			}
+		case "tr":
+			if isHeaderRow(child) {
+				return child
+			}
+		}
+	}
+	return nil
+}
+
+// rowCells returns the <td> and <th> children of tr
+func rowCells(tr *html.Node) []*html.Node {
+	var cells []*html.Node
+	for child := tr.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && (child.Data == "td" || child.Data == "th") {
+			cells = append(cells, child)
+		}
+	}
+	return cells
+}
+
+// containsElement reports whether any descendant of n is an element named tag.
+func containsElement(n *html.Node, tag string) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == tag {
+			return true
+		}
+		if containsElement(c, tag) {
 			return true
 		}
 	}
@@ -286,7 +1026,9 @@ func extractReleaseNames(node *html.Node) []string {
 // ---------------------------------------------------------------------------
 
 // parseDataRow extracts the architecture name and per-release statuses from a
-// single data row and appends the results to results.Tests.
+// single data row and appends the results to results.Tests. Anything that
+// does not fit the expected layout is reported in results.Warnings, so that
+// changes to the results page can be caught (see -strict).
 //
 // The expected row layout is:
 //
@@ -316,9 +1058,16 @@ func (s *Scraper) parseDataRow(tr *html.Node, releases []string, results *Packag
 		dataCells = append(dataCells, child)
 	}
 
-	if architecture == "" || len(dataCells) == 0 {
+	if len(dataCells) == 0 {
+		return
+	}
+	if architecture == "" {
+		results.Warnings = append(results.Warnings, fmt.Sprintf("skipped a results row without an architecture header (%d cells)", len(dataCells)))
 		return
 	}
+	if len(dataCells) != len(releases) {
+		results.Warnings = append(results.Warnings, fmt.Sprintf("%s row has %d result cells for %d releases", architecture, len(dataCells), len(releases)))
+	}
 
 	for i, cell := range dataCells {
 		if i >= len(releases) {
@@ -326,18 +1075,36 @@ func (s *Scraper) parseDataRow(tr *html.Node, releases []string, results *Packag
 		}
 
 		status := extractStatusFromCell(cell)
-		if status == "" {
+		if status == "" || status == "-" {
+			// The first run of a test shows as an empty cell with an
+			// in-progress indicator
+			results.Tests = append(results.Tests, TestResult{
+				Package:      results.Package,
+				Architecture: architecture,
+				Release:      releases[i],
+				Status:       string(autopkgtest.StatusNoData),
+				InProgress:   isInProgress(cell),
+			})
 			continue
 		}
 
+		if autopkgtest.ParseStatus(status) == autopkgtest.StatusUnknown {
+			results.Warnings = append(results.Warnings, fmt.Sprintf("unknown status %q for %s/%s", status, releases[i], architecture))
+		}
 		test := TestResult{
 			Package:      results.Package,
 			Architecture: architecture,
 			Release:      releases[i],
 			Status:       status,
+			Category:     extractCategory(cell),
+			InProgress:   isInProgress(cell),
 		}
+		test.Duration, test.LastRun, test.Trigger = extractCellDetails(cell)
 
 		if link := extractLink(cell); link != "" {
+			if test.Trigger == "" {
+				test.Trigger = triggerFromLink(link)
+			}
 			if !strings.HasPrefix(link, "http") {
 				test.LogURL = fmt.Sprintf("%s/%s", s.BaseURL, link)
 			} else {
@@ -365,6 +1132,135 @@ func extractStatusFromCell(cell *html.Node) string {
 	return strings.TrimSpace(text)
 }
 
+// extractCategory returns the CSS class of a cell, which tells e.g. a
+// regression (the trigger broke a passing test) from a test that always
+// failed even when its text is the same. A class naming a status is
+// preferred when the cell has several; "" if it has none.
+func extractCategory(cell *html.Node) string {
+	classes := strings.Fields(strings.ToLower(getAttr(cell, "class")))
+	for _, class := range classes {
+		if autopkgtest.ParseStatus(class) != autopkgtest.StatusUnknown {
+			return class
+		}
+	}
+	if len(classes) > 0 {
+		return classes[0]
+	}
+	return ""
+}
+
+// inProgressClasses are the CSS classes marking a cell, or an icon in it,
+// whose package has a run in progress
+var inProgressClasses = []string{"running", "in-progress", "inprogress", "spinner", "fa-spinner", "fa-spin"}
+
+// isInProgress reports whether a cell shows that a new run is in progress:
+// the cell or an element in it has one of inProgressClasses (e.g. a spinner
+// icon), links to the running page, or has a tooltip saying so
+func isInProgress(cell *html.Node) bool {
+	var found bool
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if found || n.Type != html.ElementNode {
+			return
+		}
+		for _, class := range strings.Fields(strings.ToLower(getAttr(n, "class"))) {
+			if slices.Contains(inProgressClasses, class) {
+				found = true
+				return
+			}
+		}
+		if n.Data == "a" && strings.Contains(getAttr(n, "href"), "/running") {
+			found = true
+			return
+		}
+		if strings.Contains(strings.ToLower(getAttr(n, "title")), "in progress") {
+			found = true
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(cell)
+	return found
+}
+
+var (
+	// durationRegex matches the duration in a cell tooltip, e.g. "duration: 1h 20m 25s"
+	durationRegex = regexp.MustCompile(`(?i)duration:?\s*((?:\d+\s*[hms]\s*)+)`)
+	// lastRunRegex matches the run date in a cell tooltip, e.g. "2026-02-02 15:37:43 UTC"
+	lastRunRegex = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}`)
+	// triggersRegex matches the triggers in a cell tooltip, e.g.
+	// "triggers: ovn/25.09.0-3 openssl/3.5.4-1ubuntu1"
+	triggersRegex = regexp.MustCompile(`(?i)triggers?:?\s*((?:[a-z0-9][a-z0-9.+-]*/[a-z0-9.+:~-]+\s*)+)`)
+)
+
+// extractCellDetails returns the duration, last run date and space-separated
+// triggers shown in the tooltip (title attribute) of a cell or its link, when
+// present
+func extractCellDetails(cell *html.Node) (string, time.Time, string) {
+	var titles []string
+	if title := getAttr(cell, "title"); title != "" {
+		titles = append(titles, title)
+	}
+	for c := cell.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "a" {
+			if title := getAttr(c, "title"); title != "" {
+				titles = append(titles, title)
+			}
+		}
+	}
+
+	var duration, trigger string
+	var lastRun time.Time
+	for _, title := range titles {
+		if m := durationRegex.FindStringSubmatch(title); m != nil && duration == "" {
+			duration = strings.TrimSpace(m[1])
+		}
+		if lastRun.IsZero() {
+			lastRun = parseRunDate(title)
+		}
+		if m := triggersRegex.FindStringSubmatch(title); m != nil && trigger == "" {
+			trigger = strings.Join(strings.Fields(m[1]), " ")
+		}
+	}
+	return duration, lastRun, trigger
+}
+
+// triggerFromLink returns the space-separated trigger parameters of a cell's
+// link (e.g. "ovn/noble/amd64?trigger=ovn%2F25.09.0-3"), or "" if it has none
+func triggerFromLink(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.Join(u.Query()["trigger"], " ")
+}
+
+// parseRunDate returns the first date and time (UTC) found in text, or the
+// zero time if there is none
+func parseRunDate(text string) time.Time {
+	m := lastRunRegex.FindString(text)
+	if m == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse("2006-01-02 15:04:05", strings.Replace(m, "T", " ", 1))
+	if err != nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+// getAttr returns the value of attribute key on n, or "" if absent
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
 // extractLink returns the href value of the first <a> child of node.
 func extractLink(n *html.Node) string {
 	for c := n.FirstChild; c != nil; c = c.NextSibling {
@@ -401,35 +1297,262 @@ func getNodeText(n *html.Node) string {
 // Reporting
 // ---------------------------------------------------------------------------
 
-// ReportErrors formats and returns a string with all errors found
+// ReportErrors formats and returns a string with all errors found, without
+// duplicates and sorted by release and architecture
 func (r *PackageResults) ReportErrors() string {
-	if len(r.Errors) == 0 {
+	errors := dedupeErrors(r.Errors)
+	if len(errors) == 0 {
 		return fmt.Sprintf("No errors found for package: %s", r.Package)
 	}
 
 	var report strings.Builder
-	report.WriteString(fmt.Sprintf("Found %d errors for package: %s\n\n", len(r.Errors), r.Package))
+	report.WriteString(fmt.Sprintf("Found %d errors for package: %s\n\n", len(errors), r.Package))
 
-	for i, err := range r.Errors {
-		report.WriteString(fmt.Sprintf("Error %d:\n", i+1))
-		report.WriteString(fmt.Sprintf("\tStatus: %s\n", err.Status))
-		if len(err.Release) > 0 {
-			report.WriteString(fmt.Sprintf("\tRelease: %s\n", err.Release))
+	for i, err := range errors {
+		writeError(&report, i+1, err)
+		report.WriteString("\n")
+	}
+
+	return report.String()
+}
+
+// ReportErrorsMulti formats the errors of several packages as one report,
+// grouped by package in alphabetical order and followed by the number of
+// errors per package and in total
+func ReportErrorsMulti(results map[string]*PackageResults) string {
+	var packages []string
+	for _, pkg := range slices.Sorted(maps.Keys(results)) {
+		if results[pkg] != nil {
+			packages = append(packages, pkg)
 		}
-		if len(err.Architecture) > 0 {
-			report.WriteString(fmt.Sprintf("\tArchitecture: %s\n", err.Architecture))
+	}
+
+	var report strings.Builder
+	counts := make(map[string]int, len(packages))
+	total, failing := 0, 0
+	for _, pkg := range packages {
+		errors := dedupeErrors(results[pkg].Errors)
+		counts[pkg] = len(errors)
+		total += len(errors)
+		if len(errors) == 0 {
+			continue
 		}
-		if len(err.Duration) > 0 {
-			report.WriteString(fmt.Sprintf("\tDuration: %s\n", err.Duration))
+		failing++
+
+		report.WriteString(fmt.Sprintf("=== %s: %d errors ===\n\n", pkg, len(errors)))
+		for i, err := range errors {
+			writeError(&report, i+1, err)
+			report.WriteString("\n")
 		}
-		if len(err.Trigger) > 0 {
-			report.WriteString(fmt.Sprintf("\tTrigger: %s\n", err.Trigger))
+	}
+
+	report.WriteString("Summary:\n")
+	for _, pkg := range packages {
+		report.WriteString(fmt.Sprintf("\t%s: %d errors\n", pkg, counts[pkg]))
+	}
+	report.WriteString(fmt.Sprintf("Found %d errors in %d of %d packages\n", total, failing, len(packages)))
+
+	return report.String()
+}
+
+// writeError writes the details of the n-th error of a report
+func writeError(report *strings.Builder, n int, err TestResult) {
+	report.WriteString(fmt.Sprintf("Error %d:\n", n))
+	report.WriteString(fmt.Sprintf("\tStatus: %s\n", err.Status))
+	if err.Category != "" && err.Category != statusName(err.Status) {
+		report.WriteString(fmt.Sprintf("\tCategory: %s\n", err.Category))
+	}
+	if err.InProgress {
+		report.WriteString("\tRunning: a new run is in progress, the status above may be stale\n")
+	}
+	if len(err.Release) > 0 {
+		report.WriteString(fmt.Sprintf("\tRelease: %s\n", err.Release))
+	}
+	if len(err.Architecture) > 0 {
+		report.WriteString(fmt.Sprintf("\tArchitecture: %s\n", err.Architecture))
+	}
+	if len(err.Duration) > 0 {
+		report.WriteString(fmt.Sprintf("\tDuration: %s\n", err.Duration))
+	}
+	if !err.LastRun.IsZero() {
+		report.WriteString(fmt.Sprintf("\tLast run: %s\n", err.LastRun.Format("2006-01-02 15:04:05 MST")))
+	}
+	if len(err.Trigger) > 0 {
+		report.WriteString(fmt.Sprintf("\tTrigger: %s\n", err.Trigger))
+	}
+	if len(err.LogURL) > 0 {
+		report.WriteString(fmt.Sprintf("\tDetails: %s\n", err.LogURL))
+	}
+}
+
+// CollapseErrors groups errors with identical (status, trigger) pairs.
+// Groups are returned in the order in which they were first seen.
+func (r *PackageResults) CollapseErrors() []ErrorGroup {
+	var groups []ErrorGroup
+	index := make(map[[2]string]int)
+	for _, err := range r.Errors {
+		key := [2]string{strings.ToLower(err.Status), err.Trigger}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, ErrorGroup{Status: err.Status, Trigger: err.Trigger})
 		}
-		if len(err.LogURL) > 0 {
-			report.WriteString(fmt.Sprintf("\tDetails: %s\n", err.LogURL))
+		groups[i].Tests = append(groups[i].Tests, err)
+	}
+	return groups
+}
+
+// ReportCollapsedErrors formats errors grouped by status and trigger, listing
+// the affected release/architecture pairs once per group
+func (r *PackageResults) ReportCollapsedErrors() string {
+	if len(r.Errors) == 0 {
+		return fmt.Sprintf("No errors found for package: %s", r.Package)
+	}
+
+	groups := r.CollapseErrors()
+
+	var report strings.Builder
+	report.WriteString(fmt.Sprintf("Found %d errors in %d groups for package: %s\n\n", len(r.Errors), len(groups), r.Package))
+
+	for i, group := range groups {
+		report.WriteString(fmt.Sprintf("Group %d:\n", i+1))
+		report.WriteString(fmt.Sprintf("\tStatus: %s\n", group.Status))
+		if len(group.Trigger) > 0 {
+			report.WriteString(fmt.Sprintf("\tTrigger: %s\n", group.Trigger))
+		}
+		affected := make([]string, 0, len(group.Tests))
+		for _, test := range group.Tests {
+			affected = append(affected, fmt.Sprintf("%s/%s", test.Release, test.Architecture))
 		}
+		report.WriteString(fmt.Sprintf("\tAffected: %s\n", strings.Join(affected, ", ")))
 		report.WriteString("\n")
 	}
 
 	return report.String()
 }
+
+// RenderTable formats all test results as a matrix with one row per release
+// and one column per architecture. Columns follow archOrder; architectures not
+// listed there are appended in the order the server returned them.
+func (r *PackageResults) RenderTable(archOrder []string) string {
+	var releases, archs []string
+	statuses := make(map[[2]string]string)
+	for _, test := range r.Tests {
+		if !isTested(test) {
+			continue
+		}
+		if !slices.Contains(releases, test.Release) {
+			releases = append(releases, test.Release)
+		}
+		if !slices.Contains(archs, test.Architecture) {
+			archs = append(archs, test.Architecture)
+		}
+		statuses[[2]string{test.Release, test.Architecture}] = test.Status
+	}
+	archs = OrderArchitectures(archs, archOrder)
+
+	var out strings.Builder
+	w := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "RELEASE\t%s\n", strings.Join(archs, "\t"))
+	for _, release := range releases {
+		row := []string{release}
+		for _, arch := range archs {
+			status, ok := statuses[[2]string{release, arch}]
+			if !ok {
+				status = "-"
+			}
+			row = append(row, status)
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+
+	return out.String()
+}
+
+// OrderArchitectures returns archs sorted so that those listed in order come
+// first, in that order, followed by the remaining ones in their original
+// order. Matching is case-insensitive.
+func OrderArchitectures(archs, order []string) []string {
+	ordered := make([]string, 0, len(archs))
+	for _, want := range order {
+		for _, arch := range archs {
+			if strings.EqualFold(strings.TrimSpace(want), arch) && !slices.Contains(ordered, arch) {
+				ordered = append(ordered, arch)
+			}
+		}
+	}
+	for _, arch := range archs {
+		if !slices.Contains(ordered, arch) {
+			ordered = append(ordered, arch)
+		}
+	}
+	return ordered
+}
+
+// FailingReleases returns the sorted, de-duplicated list of releases that have
+// at least one error
+func (r *PackageResults) FailingReleases() []string {
+	seen := make(map[string]bool)
+	var releases []string
+	for _, err := range r.Errors {
+		if err.Release == "" || seen[err.Release] {
+			continue
+		}
+		seen[err.Release] = true
+		releases = append(releases, err.Release)
+	}
+	sort.Strings(releases)
+	return releases
+}
+
+// TestedCount returns the number of tests with an actual result, i.e. not
+// counting the cells without one
+func (r *PackageResults) TestedCount() int {
+	tested := 0
+	for _, test := range r.Tests {
+		if isTested(test) {
+			tested++
+		}
+	}
+	return tested
+}
+
+// Summary returns the number of tests per status across r.Tests. Statuses
+// are normalized to their lowercase name without decorations, so "✔ pass"
+// and "pass" are both counted as "pass". Cells without a result are not
+// counted.
+func (r *PackageResults) Summary() map[string]int {
+	counts := make(map[string]int)
+	for _, test := range r.Tests {
+		if !isTested(test) {
+			continue
+		}
+		name := statusName(test.Status)
+		if name == "" {
+			continue
+		}
+		counts[name]++
+	}
+	return counts
+}
+
+// Triggers returns the sorted, de-duplicated list of triggers seen across all
+// tests. A test run with several triggers (space-separated) contributes each
+// of them. Tests without a trigger are ignored.
+func (r *PackageResults) Triggers() []string {
+	seen := make(map[string]bool)
+	var triggers []string
+	for _, test := range r.Tests {
+		for _, trigger := range strings.Fields(test.Trigger) {
+			if seen[trigger] {
+				continue
+			}
+			seen[trigger] = true
+			triggers = append(triggers, trigger)
+		}
+	}
+	sort.Strings(triggers)
+	return triggers
+}
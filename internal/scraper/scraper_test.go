@@ -1,10 +1,20 @@
 package scraper
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+	"github.com/canonical/autopkgtest-automation/internal/httpbody"
 )
 
 // Mock HTML response simulating autopkgtest results page
@@ -129,6 +139,70 @@ const mockHTMLWithTbody = `
 </html>
 `
 
+const mockHTMLNestedTables = `
+<!DOCTYPE html>
+<html>
+<head><title>autopkgtest results for ovn</title></head>
+<body>
+<table class="table layout">
+  <tr>
+    <td>Navigation: noble jammy amd64</td>
+    <td>
+      <table class="table-wrapper">
+        <tr>
+          <td>
+            <table class="table" style="width: auto">
+              <tr>
+                <th></th>
+                <th>jammy</th><th>noble</th>
+              </tr>
+              <tr>
+                <th>amd64</th>
+                <td class="pass"><a href="ovn/jammy/amd64">pass</a></td>
+                <td class="fail"><a href="ovn/noble/amd64">fail</a></td>
+              </tr>
+              <tr>
+                <th>arm64</th>
+                <td class="pass"><a href="ovn/jammy/arm64">pass</a></td>
+                <td class="pass"><a href="ovn/noble/arm64">pass</a></td>
+              </tr>
+            </table>
+          </td>
+        </tr>
+      </table>
+    </td>
+  </tr>
+</table>
+</body>
+</html>
+`
+
+// mockHTMLMultiClassCells has matrix cells with more than one CSS class,
+// the status class not always coming first
+const mockHTMLMultiClassCells = `
+<!DOCTYPE html>
+<html>
+<body>
+<table class="table">
+  <tr>
+    <th></th>
+    <th>jammy</th><th>noble</th>
+  </tr>
+  <tr>
+    <th>amd64</th>
+    <td class="result pass"><a href="ovn/jammy/amd64">pass</a></td>
+    <td class="result fail"><a href="ovn/noble/amd64">fail</a></td>
+  </tr>
+  <tr>
+    <th>arm64</th>
+    <td class="Regression highlight"><a href="ovn/jammy/arm64">regression</a></td>
+    <td class="pass"><a href="ovn/noble/arm64">pass</a></td>
+  </tr>
+</table>
+</body>
+</html>
+`
+
 const mockHTMLEmpty = `
 <!DOCTYPE html>
 <html>
@@ -241,6 +315,30 @@ func TestParseHTMLEmpty(t *testing.T) {
 	}
 }
 
+func TestParseHTMLNoResultsTable(t *testing.T) {
+	s := NewScraper()
+
+	// A page without the matrix that does not say the package has no
+	// results is a layout change
+	page := `<html><body><ul><li>noble amd64: pass</li></ul></body></html>`
+	results, err := s.ParseHTML(page, "ovn", nil)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+	want := []string{"no results table found on the page of ovn"}
+	if !slices.Equal(results.Warnings, want) {
+		t.Errorf("Expected warnings %q, got %q", want, results.Warnings)
+	}
+
+	results, err = s.ParseHTML(mockHTMLEmpty, "empty-pkg", nil)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+	if len(results.Warnings) != 0 {
+		t.Errorf("Expected no warnings for a page without results, got %q", results.Warnings)
+	}
+}
+
 func TestParseHTMLWithResolute(t *testing.T) {
 	s := NewScraper()
 	results, err := s.ParseHTML(mockHTMLWithResolute, "openvswitch", nil)
@@ -387,6 +485,12 @@ func TestReportErrors(t *testing.T) {
 				Release:      "jammy",
 				Architecture: "arm64",
 			},
+			{
+				Status:       "fail",
+				Category:     "always_failed",
+				Release:      "jammy",
+				Architecture: "s390x",
+			},
 		},
 	}
 
@@ -403,6 +507,7 @@ func TestReportErrors(t *testing.T) {
 		"amd64",
 		"arm64",
 		"noble",
+		"Category: always_failed",
 	}
 
 	for _, expected := range expectedStrings {
@@ -410,6 +515,9 @@ func TestReportErrors(t *testing.T) {
 			t.Errorf("Expected report to contain '%s'", expected)
 		}
 	}
+	if strings.Count(report, "Category:") != 1 {
+		t.Errorf("Expected only the category differing from its status to be shown, got:\n%s", report)
+	}
 }
 
 func TestReportErrorsEmpty(t *testing.T) {
@@ -425,6 +533,48 @@ func TestReportErrorsEmpty(t *testing.T) {
 	}
 }
 
+func TestReportErrorsMulti(t *testing.T) {
+	results := map[string]*PackageResults{
+		"systemd": {
+			Package: "systemd",
+			Errors: []TestResult{
+				{Status: "fail", Release: "noble", Architecture: "amd64"},
+				{Status: "regression", Release: "noble", Architecture: "arm64"},
+			},
+		},
+		"ovn": {
+			Package: "ovn",
+			Errors:  []TestResult{{Status: "fail", Release: "jammy", Architecture: "s390x"}},
+		},
+		"openvswitch": {Package: "openvswitch"},
+	}
+
+	report := ReportErrorsMulti(results)
+
+	// Packages are listed alphabetically, and only those with errors get a section
+	ovn := strings.Index(report, "=== ovn: 1 errors ===")
+	systemd := strings.Index(report, "=== systemd: 2 errors ===")
+	if ovn < 0 || systemd < 0 || ovn > systemd {
+		t.Errorf("Expected ovn then systemd sections, got:\n%s", report)
+	}
+	if strings.Contains(report, "=== openvswitch") {
+		t.Errorf("Expected no section for a package without errors, got:\n%s", report)
+	}
+
+	expectedStrings := []string{
+		"\topenvswitch: 0 errors\n",
+		"\tovn: 1 errors\n",
+		"\tsystemd: 2 errors\n",
+		"Found 3 errors in 2 of 3 packages",
+		"Architecture: s390x",
+	}
+	for _, expected := range expectedStrings {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected report to contain %q, got:\n%s", expected, report)
+		}
+	}
+}
+
 func TestFilterByRelease(t *testing.T) {
 	s := NewScraper()
 	filter := &Filter{Release: "noble"}
@@ -568,3 +718,1442 @@ func TestReleaseAlignmentAcrossAllColumns(t *testing.T) {
 		}
 	}
 }
+
+const mockHTMLWithNoData = `
+<table class="table">
+  <tr><th></th><th>jammy</th><th>noble</th></tr>
+  <tr>
+    <th>amd64</th>
+    <td class="pass"><a href="ovn/jammy/amd64">pass</a></td>
+    <td class="fail"><a href="ovn/noble/amd64">fail</a></td>
+  </tr>
+  <tr>
+    <th>riscv64</th>
+    <td></td>
+    <td class="nodata">-</td>
+  </tr>
+</table>
+`
+
+func TestParseHTMLNoDataCells(t *testing.T) {
+	s := NewScraper()
+	results, err := s.ParseHTML(mockHTMLWithNoData, "ovn", nil)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+
+	if len(results.Tests) != 4 {
+		t.Fatalf("Expected 4 results including cells without data, got %d", len(results.Tests))
+	}
+	for _, test := range results.Tests {
+		if test.Architecture == "riscv64" && test.Status != string(autopkgtest.StatusNoData) {
+			t.Errorf("Expected %s/riscv64 to have status %q, got %q", test.Release, string(autopkgtest.StatusNoData), test.Status)
+		}
+	}
+
+	// Only the real failure is an error
+	if len(results.Errors) != 1 || results.Errors[0].Release != "noble" || results.Errors[0].Architecture != "amd64" {
+		t.Errorf("Expected only noble/amd64 as an error, got %+v", results.Errors)
+	}
+
+	// An architecture without any data is not part of the matrix
+	if slices.Contains(results.Architectures, "riscv64") {
+		t.Errorf("Expected riscv64 not to be listed as a tested architecture, got %v", results.Architectures)
+	}
+
+	// Cells without data render as missing in the table
+	if table := results.RenderTable(nil); strings.Contains(table, "riscv64") || strings.Contains(table, string(autopkgtest.StatusNoData)) {
+		t.Errorf("Expected no riscv64 column in the table, got:\n%s", table)
+	}
+
+	// Even with a FailOn list they are never errors
+	strict := NewScraper(WithFailOn(string(autopkgtest.StatusNoData), "fail"))
+	results, err = strict.ParseHTML(mockHTMLWithNoData, "ovn", &Filter{Architecture: "riscv64"})
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+	if len(results.Errors) != 0 {
+		t.Errorf("Expected no errors for cells without data, got %+v", results.Errors)
+	}
+}
+
+func TestFailingReleases(t *testing.T) {
+	s := NewScraper()
+	results, err := s.ParseHTML(mockHTMLWithResolute, "openvswitch", nil)
+
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+
+	// noble and questing each fail on both architectures; each should be
+	// listed once, in sorted order
+	got := results.FailingReleases()
+	want := []string{"noble", "questing"}
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d failing releases, got %d: %v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected failing release %d to be %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+func TestFailingReleasesNoErrors(t *testing.T) {
+	s := NewScraper()
+	results, err := s.ParseHTML(mockHTMLWithoutErrors, "test-pkg", nil)
+
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+
+	if got := results.FailingReleases(); len(got) != 0 {
+		t.Errorf("Expected no failing releases, got %v", got)
+	}
+}
+
+func TestReportErrorsDeduplicatesAndSorts(t *testing.T) {
+	results := &PackageResults{
+		Package: "test-pkg",
+		Errors: []TestResult{
+			{Status: "fail", Release: "noble", Architecture: "arm64"},
+			{Status: "fail", Release: "jammy", Architecture: "amd64"},
+			{Status: "fail", Release: "noble", Architecture: "arm64"},
+			{Status: "fail", Release: "noble", Architecture: "amd64", Trigger: "systemd/259-1ubuntu3"},
+			{Status: "fail", Release: "jammy", Architecture: "amd64"},
+		},
+	}
+
+	report := results.ReportErrors()
+
+	if !strings.Contains(report, "Found 3 errors") {
+		t.Errorf("Expected duplicates to be removed, got:\n%s", report)
+	}
+
+	jammy := strings.Index(report, "Release: jammy")
+	nobleAmd64 := strings.Index(report, "Architecture: amd64\n\tTrigger: systemd")
+	nobleArm64 := strings.Index(report, "Architecture: arm64")
+	if jammy == -1 || nobleAmd64 == -1 || nobleArm64 == -1 {
+		t.Fatalf("Expected all three errors in report, got:\n%s", report)
+	}
+	if !(jammy < nobleAmd64 && nobleAmd64 < nobleArm64) {
+		t.Errorf("Expected errors sorted by release then architecture, got:\n%s", report)
+	}
+
+	// The report is the same regardless of the input order
+	reversed := &PackageResults{Package: "test-pkg"}
+	for i := len(results.Errors) - 1; i >= 0; i-- {
+		reversed.Errors = append(reversed.Errors, results.Errors[i])
+	}
+	if got := reversed.ReportErrors(); got != report {
+		t.Errorf("Expected stable report across input orders, got:\n%s\nwant:\n%s", got, report)
+	}
+}
+
+func TestPackageResultsJSON(t *testing.T) {
+	s := NewScraper()
+	results, err := s.ParseHTML(mockHTMLWithErrors, "ovn", nil)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+
+	var decoded PackageResults
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if decoded.Package != "ovn" || len(decoded.Tests) != len(results.Tests) || len(decoded.Errors) != len(results.Errors) {
+		t.Errorf("Expected round-tripped results to match, got %+v", decoded)
+	}
+
+	for _, key := range []string{`"package":"ovn"`, `"tests":[`, `"errors":[`, `"release":"noble"`, `"architecture":"amd64"`, `"status":"fail"`, `"log_url":`} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("Expected JSON to contain %s, got: %s", key, data)
+		}
+	}
+}
+
+const mockHTMLWithTooltips = `
+<table class="table">
+  <tr><th></th><th>noble</th><th>jammy</th></tr>
+  <tr>
+    <th>amd64</th>
+    <td class="fail" title="fail, duration: 1h 20m 25s, 2026-02-02 15:37:43 UTC"><a href="ovn/noble/amd64">fail</a></td>
+    <td class="pass"><a href="ovn/jammy/amd64" title="duration: 5m 3s&#10;last run 2026-01-20 10:00:00 UTC">pass</a></td>
+  </tr>
+  <tr>
+    <th>arm64</th>
+    <td class="pass"><a href="ovn/noble/arm64">pass</a></td>
+    <td class="fail"><a href="ovn/jammy/arm64">fail</a></td>
+  </tr>
+</table>
+`
+
+func TestParseHTMLCellTooltips(t *testing.T) {
+	s := NewScraper()
+	results, err := s.ParseHTML(mockHTMLWithTooltips, "ovn", nil)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+
+	byCell := map[string]TestResult{}
+	for _, test := range results.Tests {
+		byCell[test.Release+"/"+test.Architecture] = test
+	}
+
+	tests := []struct {
+		cell     string
+		duration string
+		lastRun  time.Time
+	}{
+		{"noble/amd64", "1h 20m 25s", time.Date(2026, 2, 2, 15, 37, 43, 0, time.UTC)},
+		{"jammy/amd64", "5m 3s", time.Date(2026, 1, 20, 10, 0, 0, 0, time.UTC)},
+		{"noble/arm64", "", time.Time{}},
+	}
+	for _, tt := range tests {
+		test, ok := byCell[tt.cell]
+		if !ok {
+			t.Errorf("Missing result for %s", tt.cell)
+			continue
+		}
+		if test.Duration != tt.duration {
+			t.Errorf("%s: expected duration %q, got %q", tt.cell, tt.duration, test.Duration)
+		}
+		if !test.LastRun.Equal(tt.lastRun) {
+			t.Errorf("%s: expected last run %v, got %v", tt.cell, tt.lastRun, test.LastRun)
+		}
+	}
+}
+
+func TestParseHTMLCellCategory(t *testing.T) {
+	page := `<html><body><table>
+  <tr><th></th><th>jammy</th><th>noble</th><th>resolute</th><th>questing</th></tr>
+  <tr>
+    <th>amd64</th>
+    <td class="regression"><a href="ovn/jammy/amd64">fail</a></td>
+    <td class="fail"><a href="ovn/noble/amd64">fail</a></td>
+    <td class="always_failed"><a href="ovn/resolute/amd64">fail</a></td>
+    <td><a href="ovn/questing/amd64">pass</a></td>
+  </tr>
+</table></body></html>`
+
+	s := NewScraper()
+	results, err := s.ParseHTML(page, "ovn", nil)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+
+	want := map[string]string{"jammy": "regression", "noble": "fail", "resolute": "always_failed", "questing": ""}
+	if len(results.Tests) != len(want) {
+		t.Fatalf("Expected %d results, got %d", len(want), len(results.Tests))
+	}
+	for _, test := range results.Tests {
+		if test.Category != want[test.Release] {
+			t.Errorf("%s: expected category %q, got %q", test.Release, want[test.Release], test.Category)
+		}
+	}
+}
+
+func TestParseHTMLCellInProgress(t *testing.T) {
+	page := `<html><body><table>
+  <tr><th></th><th>jammy</th><th>noble</th><th>resolute</th><th>questing</th><th>plucky</th></tr>
+  <tr>
+    <th>amd64</th>
+    <td class="pass"><a href="ovn/jammy/amd64">pass</a> <i class="fa fa-spinner fa-spin"></i></td>
+    <td class="fail running"><a href="ovn/noble/amd64">fail</a></td>
+    <td class="pass"><a href="ovn/resolute/amd64">pass</a> <a href="/running#ovn"><i class="icon"></i></a></td>
+    <td><i class="fa fa-spinner" title="Test in progress"></i></td>
+    <td class="pass"><a href="ovn/plucky/amd64">pass</a></td>
+  </tr>
+</table></body></html>`
+
+	s := NewScraper()
+	results, err := s.ParseHTML(page, "ovn", nil)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+
+	want := map[string]struct {
+		status     string
+		inProgress bool
+	}{
+		"jammy":    {"pass", true},
+		"noble":    {"fail", true},
+		"resolute": {"pass", true},
+		"questing": {string(autopkgtest.StatusNoData), true},
+		"plucky":   {"pass", false},
+	}
+	if len(results.Tests) != len(want) {
+		t.Fatalf("Expected %d results, got %d", len(want), len(results.Tests))
+	}
+	for _, test := range results.Tests {
+		w := want[test.Release]
+		if test.Status != w.status {
+			t.Errorf("%s: expected status %q, got %q", test.Release, w.status, test.Status)
+		}
+		if test.InProgress != w.inProgress {
+			t.Errorf("%s: expected InProgress %v, got %v", test.Release, w.inProgress, test.InProgress)
+		}
+	}
+}
+
+func TestParseHTMLLayoutWarnings(t *testing.T) {
+	page := `<html><body><table>
+  <tr><th></th><th>noble</th><th>jammy</th></tr>
+  <tr><th>amd64</th><td class="pass">pass</td><td class="fail">fail</td></tr>
+  <tr><td></td><td class="pass">pass</td><td class="pass">pass</td></tr>
+  <tr><th>arm64</th><td class="pass">pass</td></tr>
+  <tr><th>s390x</th><td class="pass">pass</td><td>bypass</td></tr>
+</table></body></html>`
+
+	s := NewScraper()
+	results, err := s.ParseHTML(page, "ovn", nil)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+
+	want := []string{
+		"skipped a results row without an architecture header (2 cells)",
+		"arm64 row has 1 result cells for 2 releases",
+		`unknown status "bypass" for jammy/s390x`,
+	}
+	if !slices.Equal(results.Warnings, want) {
+		t.Errorf("Expected warnings %q, got %q", want, results.Warnings)
+	}
+
+	// A well-formed page has no warnings
+	results, err = s.ParseHTML(mockHTMLWithErrors, "ovn", nil)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+	if len(results.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got %q", results.Warnings)
+	}
+}
+
+func TestParseHTMLMultiClassCells(t *testing.T) {
+	s := NewScraper()
+	results, err := s.ParseHTML(mockHTMLMultiClassCells, "ovn", nil)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+
+	// The status class is picked whatever its position, ignoring case
+	want := map[[2]string]string{
+		{"jammy", "amd64"}: "pass",
+		{"noble", "amd64"}: "fail",
+		{"jammy", "arm64"}: "regression",
+		{"noble", "arm64"}: "pass",
+	}
+	if len(results.Tests) != len(want) {
+		t.Fatalf("Expected %d results, got %d", len(want), len(results.Tests))
+	}
+	for _, test := range results.Tests {
+		if category := want[[2]string{test.Release, test.Architecture}]; test.Category != category {
+			t.Errorf("%s/%s: expected category %q, got %q", test.Release, test.Architecture, category, test.Category)
+		}
+	}
+	if len(results.Errors) != 2 {
+		t.Errorf("Expected 2 errors, got %d", len(results.Errors))
+	}
+}
+
+func TestParseHTMLStructuralTableDetection(t *testing.T) {
+	// No "table" class, a release the scraper has never heard of, and a
+	// history-style table before the matrix that must not be mistaken for it
+	html := `
+<table class="runs">
+  <tr><th>Version</th><th>Triggers</th><th>Result</th></tr>
+  <tr><td>1.0-1</td><td>ovn/1.0-1</td><td class="fail">fail</td></tr>
+</table>
+<table class="results-matrix">
+  <thead><tr><th></th><th>stonking</th><th>noble</th></tr></thead>
+  <tbody>
+    <tr><th>amd64</th><td class="pass"><a href="ovn/stonking/amd64">pass</a></td><td class="pass"><a href="ovn/noble/amd64">pass</a></td></tr>
+    <tr><th>riscv64</th><td class="fail"><a href="ovn/stonking/riscv64">fail</a></td><td class="pass"><a href="ovn/noble/riscv64">pass</a></td></tr>
+  </tbody>
+</table>
+`
+
+	s := NewScraper()
+	results, err := s.ParseHTML(html, "ovn", nil)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+
+	if len(results.Tests) != 4 {
+		t.Fatalf("Expected 4 tests from the matrix, got %d: %+v", len(results.Tests), results.Tests)
+	}
+	if len(results.Errors) != 1 || results.Errors[0].Release != "stonking" || results.Errors[0].Architecture != "riscv64" {
+		t.Errorf("Expected stonking/riscv64 failure, got %+v", results.Errors)
+	}
+}
+
+func TestIsMatrixTableRejectsNonMatrix(t *testing.T) {
+	tests := map[string]string{
+		"no corner cell":      `<table><tr><th>Release</th><th>noble</th></tr><tr><th>amd64</th><td>pass</td></tr></table>`,
+		"column headers":      `<table><tr><th></th><th>Version</th><th>Result</th></tr><tr><th>amd64</th><td>pass</td></tr></table>`,
+		"no architecture row": `<table><tr><th></th><th>noble</th></tr><tr><th>All Tests</th><td>pass</td></tr></table>`,
+	}
+
+	for name, html := range tests {
+		t.Run(name, func(t *testing.T) {
+			results, err := NewScraper().ParseHTML(html, "ovn", nil)
+			if err != nil {
+				t.Fatalf("ParseHTML failed: %v", err)
+			}
+			if len(results.Tests) != 0 {
+				t.Errorf("Expected table to be ignored, got %+v", results.Tests)
+			}
+		})
+	}
+}
+
+func TestWithFailOn(t *testing.T) {
+	html := `
+<table class="table">
+  <tr><th></th><th>noble</th><th>jammy</th></tr>
+  <tr><th>amd64</th><td class="fail">fail</td><td class="tmpfail">tmpfail</td></tr>
+  <tr><th>arm64</th><td class="neutral">😐 neutral</td><td class="pass">✔ pass</td></tr>
+</table>`
+
+	tests := []struct {
+		name   string
+		failOn []string
+		want   []string
+	}{
+		{name: "default", failOn: nil, want: []string{"jammy/amd64", "noble/amd64"}},
+		{name: "ignore tmpfail", failOn: []string{"fail", "regression"}, want: []string{"noble/amd64"}},
+		{name: "neutral is an error", failOn: []string{"FAIL", "neutral"}, want: []string{"noble/amd64", "noble/arm64"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScraper(WithFailOn(tt.failOn...))
+			results, err := s.ParseHTML(html, "ovn", nil)
+			if err != nil {
+				t.Fatalf("ParseHTML failed: %v", err)
+			}
+
+			var got []string
+			for _, e := range results.Errors {
+				got = append(got, e.Release+"/"+e.Architecture)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected errors %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTriggers(t *testing.T) {
+	results := &PackageResults{
+		Package: "test-pkg",
+		Tests: []TestResult{
+			{Status: "pass", Trigger: "systemd/259-1ubuntu3", Release: "noble", Architecture: "amd64"},
+			{Status: "fail", Trigger: "systemd/259-1ubuntu3 dhcpcd/1:10.3.0-7", Release: "noble", Architecture: "arm64"},
+			{Status: "pass", Release: "jammy", Architecture: "amd64"},
+			{Status: "pass", Trigger: "migration-reference/0", Release: "jammy", Architecture: "arm64"},
+			{Status: "fail", Trigger: "dhcpcd/1:10.3.0-7", Release: "questing", Architecture: "amd64"},
+		},
+	}
+
+	got := results.Triggers()
+	want := []string{"dhcpcd/1:10.3.0-7", "migration-reference/0", "systemd/259-1ubuntu3"}
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d triggers, got %d: %v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected trigger %d to be %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	results := &PackageResults{
+		Package: "test-pkg",
+		Tests: []TestResult{
+			{Status: "✔ pass", Release: "noble", Architecture: "amd64"},
+			{Status: "pass", Release: "noble", Architecture: "arm64"},
+			{Status: "😐 neutral", Release: "noble", Architecture: "s390x"},
+			{Status: "✘ regression", Release: "jammy", Architecture: "amd64"},
+			{Status: "FAIL", Release: "jammy", Architecture: "arm64"},
+			{Status: string(autopkgtest.StatusNoData), Release: "jammy", Architecture: "riscv64"},
+		},
+	}
+
+	got := results.Summary()
+	want := map[string]int{"pass": 2, "neutral": 1, "regression": 1, "fail": 1}
+	if !maps.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestCollapseErrors(t *testing.T) {
+	results := &PackageResults{
+		Package: "test-pkg",
+		Errors: []TestResult{
+			{Status: "fail", Trigger: "systemd/259-1ubuntu3", Release: "noble", Architecture: "amd64"},
+			{Status: "fail", Trigger: "systemd/259-1ubuntu3", Release: "noble", Architecture: "arm64"},
+			{Status: "tmpfail", Release: "jammy", Architecture: "amd64"},
+			{Status: "fail", Trigger: "systemd/259-1ubuntu3", Release: "noble", Architecture: "s390x"},
+			{Status: "fail", Trigger: "dhcpcd/1:10.3.0-7", Release: "noble", Architecture: "amd64"},
+		},
+	}
+
+	groups := results.CollapseErrors()
+	if len(groups) != 3 {
+		t.Fatalf("Expected 3 groups, got %d", len(groups))
+	}
+
+	first := groups[0]
+	if first.Status != "fail" || first.Trigger != "systemd/259-1ubuntu3" {
+		t.Errorf("Unexpected first group: status=%q trigger=%q", first.Status, first.Trigger)
+	}
+	if len(first.Tests) != 3 {
+		t.Errorf("Expected 3 tests in first group, got %d", len(first.Tests))
+	}
+
+	if groups[1].Status != "tmpfail" || len(groups[1].Tests) != 1 {
+		t.Errorf("Expected single tmpfail group second, got %+v", groups[1])
+	}
+	if groups[2].Trigger != "dhcpcd/1:10.3.0-7" || len(groups[2].Tests) != 1 {
+		t.Errorf("Expected single dhcpcd group third, got %+v", groups[2])
+	}
+
+	report := results.ReportCollapsedErrors()
+	if !strings.Contains(report, "noble/amd64, noble/arm64, noble/s390x") {
+		t.Errorf("Expected report to list affected release/arch pairs once, got:\n%s", report)
+	}
+	if strings.Count(report, "systemd/259-1ubuntu3") != 1 {
+		t.Errorf("Expected systemd trigger to appear once in report, got:\n%s", report)
+	}
+}
+
+func TestFetchPackageResultsContextDeadline(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hang until the client gives up or the test ends
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := s.FetchPackageResultsContext(ctx, "ovn", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected request to be aborted at the deadline, took %v", elapsed)
+	}
+}
+
+func TestFetchPackageResultsContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockHTMLWithErrors))
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	results, err := s.FetchPackageResultsContext(context.Background(), "ovn", &Filter{Release: "noble"})
+	if err != nil {
+		t.Fatalf("FetchPackageResultsContext failed: %v", err)
+	}
+	for _, test := range results.Tests {
+		if test.Release != "noble" {
+			t.Errorf("Expected only noble results, got %s", test.Release)
+		}
+	}
+}
+
+func TestFetchMultiplePackages(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		if r.URL.Path == "/packages/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockHTMLWithErrors))
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	packages := []string{"ovn", "openvswitch", "missing", "dpdk", "systemd"}
+	results, errs := s.FetchMultiplePackages(packages, nil, 2)
+
+	if len(results) != 4 {
+		t.Errorf("Expected 4 results, got %d", len(results))
+	}
+	if len(errs) != 1 || errs["missing"] == nil {
+		t.Errorf("Expected a single error for 'missing', got %v", errs)
+	}
+	if res := results["dpdk"]; res == nil || res.Package != "dpdk" {
+		t.Errorf("Expected results for dpdk, got %+v", res)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("Expected at most 2 concurrent requests, got %d", maxInFlight)
+	}
+}
+
+func TestFetchMultiplePackagesDefaultConcurrency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockHTMLWithoutErrors))
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	results, errs := s.FetchMultiplePackages([]string{"a", "b"}, nil, 0)
+	if len(results) != 2 || len(errs) != 0 {
+		t.Errorf("Expected 2 results and no errors, got %d and %v", len(results), errs)
+	}
+}
+
+func TestFetchRetriesTransientErrors(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockHTMLWithErrors))
+	}))
+	defer server.Close()
+
+	s := NewScraper(WithRetry(RetryConfig{
+		MaxRetries:        3,
+		BaseDelay:         time.Millisecond,
+		RetryableStatuses: []int{http.StatusServiceUnavailable},
+	}))
+	s.BaseURL = server.URL
+
+	results, err := s.FetchPackageResults("ovn")
+	if err != nil {
+		t.Fatalf("Expected fetch to succeed after retries, got: %v", err)
+	}
+	if len(results.Tests) == 0 {
+		t.Error("Expected results after retries")
+	}
+	if requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests)
+	}
+}
+
+func TestFetchRetryGivesUp(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.Retry.BaseDelay = time.Millisecond
+	s.BaseURL = server.URL
+
+	_, err := s.FetchPackageResults("ovn")
+	if err == nil {
+		t.Fatal("Expected error when every attempt fails")
+	}
+	if requests != 4 {
+		t.Errorf("Expected 1 attempt + 3 retries, got %d requests", requests)
+	}
+	for _, want := range []string{"attempt 1: unexpected status code: 502", "attempt 4: unexpected status code: 503"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got: %v", want, err)
+		}
+	}
+}
+
+func TestFetchDoesNotRetryNotFound(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	_, err := s.FetchPackageResults("missing")
+	if err == nil {
+		t.Fatal("Expected error for 404")
+	}
+	if requests != 1 {
+		t.Errorf("Expected 404 not to be retried, got %d requests", requests)
+	}
+}
+
+func TestFetchConditionalRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator string // Response header set by the server
+		value     string
+		condition string // Request header expected on later requests
+	}{
+		{name: "etag", validator: "ETag", value: `"v1"`, condition: "If-None-Match"},
+		{name: "last-modified", validator: "Last-Modified", value: "Wed, 14 Oct 2026 10:00:00 GMT", condition: "If-Modified-Since"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conditions []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conditions = append(conditions, r.Header.Get(tt.condition))
+				if r.Header.Get(tt.condition) == tt.value {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set(tt.validator, tt.value)
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(mockHTMLWithErrors))
+			}))
+			defer server.Close()
+
+			s := NewScraper()
+			s.BaseURL = server.URL
+
+			first, err := s.FetchPackageResults("ovn")
+			if err != nil {
+				t.Fatalf("First fetch failed: %v", err)
+			}
+			second, err := s.FetchPackageResultsFiltered("ovn", &Filter{Release: "noble"})
+			if err != nil {
+				t.Fatalf("Second fetch failed: %v", err)
+			}
+
+			if strings.Join(conditions, ",") != ","+tt.value {
+				t.Errorf("Expected %s only on the second request, got %q", tt.condition, conditions)
+			}
+			if len(first.Tests) != 6 {
+				t.Errorf("Expected 6 results from the full page, got %d", len(first.Tests))
+			}
+			// The reused page is parsed again with the new filter
+			if len(second.Tests) != 2 || len(second.Errors) != 1 {
+				t.Errorf("Expected 2 noble results with 1 error on 304, got %d results, %d errors", len(second.Tests), len(second.Errors))
+			}
+		})
+	}
+}
+
+func TestFetchNotModifiedWithoutCachedPage(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockHTMLWithErrors))
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	results, err := s.FetchPackageResults("ovn")
+	if err != nil {
+		t.Fatalf("FetchPackageResults failed: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected a full GET after the 304, got %d requests", requests)
+	}
+	if len(results.Tests) != 6 {
+		t.Errorf("Expected 6 results, got %d", len(results.Tests))
+	}
+}
+
+func TestPackageExists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/packages/ovn":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockHTMLWithErrors))
+		case "/packages/empty":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`<html><body><h1>No results found</h1></body></html>`))
+		case "/packages/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	tests := []struct {
+		pkg     string
+		want    bool
+		wantErr bool
+	}{
+		{pkg: "ovn", want: true},
+		{pkg: "empty", want: true},
+		{pkg: "nonexistent", want: false},
+		{pkg: "broken", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pkg, func(t *testing.T) {
+			exists, err := s.PackageExists(tt.pkg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PackageExists failed: %v", err)
+			}
+			if exists != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, exists)
+			}
+		})
+	}
+}
+
+func TestTestedArchitectures(t *testing.T) {
+	page := `<html><body><table>
+  <tr><th></th><th>noble</th><th>jammy</th></tr>
+  <tr><th>amd64</th><td class="pass"><a href="ovn/noble/amd64">pass</a></td><td class="pass"><a href="ovn/jammy/amd64">pass</a></td></tr>
+  <tr><th>riscv64</th><td></td><td class="fail"><a href="ovn/jammy/riscv64">fail</a></td></tr>
+  <tr><th>s390x</th><td class="fail"><a href="ovn/noble/s390x">fail</a></td><td></td></tr>
+</table></body></html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	archs, err := s.TestedArchitectures("ovn", "noble")
+	if err != nil {
+		t.Fatalf("TestedArchitectures failed: %v", err)
+	}
+	// riscv64 has no result on noble, only on jammy
+	if want := []string{"amd64", "s390x"}; !slices.Equal(archs, want) {
+		t.Errorf("Expected %v, got %v", want, archs)
+	}
+}
+
+func TestPackageExistsNetworkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	exists, err := s.PackageExists("ovn")
+	if err == nil {
+		t.Fatal("Expected network error")
+	}
+	if exists {
+		t.Error("Expected false on network error")
+	}
+}
+
+func TestFetchPackageResultsForbidden(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	_, err := s.FetchPackageResults("ovn")
+	if !errors.Is(err, ErrAuthRequired) {
+		t.Errorf("Expected ErrAuthRequired, got: %v", err)
+	}
+}
+
+func TestWithHeader(t *testing.T) {
+	var gotToken string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotToken = r.Header.Get("X-Gateway-Token")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockHTMLWithoutErrors))
+	}))
+	defer server.Close()
+
+	s := NewScraper(WithHeader("X-Gateway-Token", "secret"))
+	s.BaseURL = server.URL
+
+	if _, err := s.FetchPackageResults("test-pkg"); err != nil {
+		t.Fatalf("FetchPackageResults failed: %v", err)
+	}
+
+	if gotToken != "secret" {
+		t.Errorf("Expected X-Gateway-Token header 'secret', got %q", gotToken)
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	// The proxy receives the request for the real host in absolute form
+	var gotHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.URL.Host
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockHTMLWithoutErrors))
+	}))
+	defer proxy.Close()
+
+	s := NewScraper(WithProxy(proxy.URL, "internal.example,.corp.example"))
+	s.BaseURL = "http://autopkgtest.example"
+
+	if _, err := s.FetchPackageResults("test-pkg"); err != nil {
+		t.Fatalf("FetchPackageResults failed: %v", err)
+	}
+	if gotHost != "autopkgtest.example" {
+		t.Errorf("Expected the request for autopkgtest.example to go through the proxy, got host %q", gotHost)
+	}
+
+	transport, ok := s.Client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Expected an *http.Transport, got %T", s.Client.Transport)
+	}
+	for _, host := range []string{"internal.example", "mirror.corp.example"} {
+		req := httptest.NewRequest(http.MethodGet, "http://"+host+"/packages/ovn", nil)
+		if proxyURL, err := transport.Proxy(req); err != nil || proxyURL != nil {
+			t.Errorf("Expected %s to bypass the proxy, got %v (err: %v)", host, proxyURL, err)
+		}
+	}
+}
+
+func TestWithTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockHTMLWithoutErrors))
+	}))
+	defer server.Close()
+
+	var requests int
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	s := NewScraper(WithTransport(transport))
+	s.BaseURL = server.URL
+
+	if _, err := s.FetchPackageResults("test-pkg"); err != nil {
+		t.Fatalf("FetchPackageResults failed: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 request through the custom transport, got %d", requests)
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockHTMLWithoutErrors))
+	}))
+	defer server.Close()
+
+	s := NewScraper(WithMaxResponseBytes(int64(len(mockHTMLWithoutErrors) - 1)))
+	s.BaseURL = server.URL
+	if _, err := s.FetchPackageResults("test-pkg"); !errors.Is(err, httpbody.ErrResponseTooLarge) {
+		t.Errorf("Expected error to wrap httpbody.ErrResponseTooLarge, got: %v", err)
+	}
+
+	// A page of exactly the limit is read
+	s = NewScraper(WithMaxResponseBytes(int64(len(mockHTMLWithoutErrors))))
+	s.BaseURL = server.URL
+	if _, err := s.FetchPackageResults("test-pkg"); err != nil {
+		t.Errorf("FetchPackageResults failed: %v", err)
+	}
+
+	// Zero restores the default limit, as it does for the client
+	s = NewScraper(WithMaxResponseBytes(0))
+	s.BaseURL = server.URL
+	if _, err := s.FetchPackageResults("test-pkg"); err != nil {
+		t.Errorf("FetchPackageResults with the default limit failed: %v", err)
+	}
+}
+
+func TestParseHTMLWithNestedTables(t *testing.T) {
+	s := NewScraper()
+	results, err := s.ParseHTML(mockHTMLNestedTables, "ovn", nil)
+
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+
+	// 2 archs × 2 releases = 4, taken from the innermost matrix table only
+	if len(results.Tests) != 4 {
+		t.Fatalf("Expected 4 tests, got %d", len(results.Tests))
+	}
+
+	if len(results.Errors) != 1 {
+		t.Fatalf("Expected 1 error, got %d", len(results.Errors))
+	}
+
+	if results.Errors[0].Release != "noble" || results.Errors[0].Architecture != "amd64" {
+		t.Errorf("Expected noble/amd64 error, got %s/%s", results.Errors[0].Release, results.Errors[0].Architecture)
+	}
+}
+
+func TestRenderTableArchOrder(t *testing.T) {
+	s := NewScraper()
+	results, err := s.ParseHTML(mockHTMLWithErrors, "ovn", nil)
+
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+
+	table := results.RenderTable([]string{"arm64"})
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+
+	// Header + one row per release
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 lines, got %d:\n%s", len(lines), table)
+	}
+
+	header := strings.Fields(lines[0])
+	want := []string{"RELEASE", "arm64", "amd64"}
+	if strings.Join(header, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected header %v, got %v", want, header)
+	}
+
+	// noble row: arm64 pass, amd64 fail
+	noble := strings.Fields(lines[3])
+	if strings.Join(noble, " ") != "noble pass fail" {
+		t.Errorf("Expected noble row 'noble pass fail', got %v", noble)
+	}
+}
+
+func TestOrderArchitectures(t *testing.T) {
+	archs := []string{"amd64", "arm64", "ppc64el", "s390x"}
+
+	got := OrderArchitectures(archs, []string{"S390X", "amd64", "riscv64"})
+	want := []string{"s390x", "amd64", "arm64", "ppc64el"}
+
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestWaitForPass(t *testing.T) {
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusOK)
+		if requestCount <= 2 {
+			// noble/amd64 fails in mockHTMLWithErrors
+			w.Write([]byte(mockHTMLWithErrors))
+		} else {
+			w.Write([]byte(strings.Replace(mockHTMLWithErrors,
+				`<td class="fail">
+      <a href="ovn/noble/amd64">fail</a>`,
+				`<td class="pass">
+      <a href="ovn/noble/amd64">pass</a>`, 1)))
+		}
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	filter := &Filter{Release: "noble", Architecture: "amd64"}
+	results, err := s.WaitForPass("ovn", filter, 50*time.Millisecond, 2*time.Second)
+	if err != nil {
+		t.Fatalf("WaitForPass failed: %v", err)
+	}
+
+	if len(results.Tests) != 1 || results.Tests[0].Status != "pass" {
+		t.Errorf("Expected single passing noble/amd64 result, got %+v", results.Tests)
+	}
+
+	if requestCount < 3 {
+		t.Errorf("Expected multiple polling requests, got %d", requestCount)
+	}
+}
+
+func TestWaitForPass_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockHTMLWithErrors))
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	filter := &Filter{Release: "noble", Architecture: "amd64"}
+	results, err := s.WaitForPass("ovn", filter, 50*time.Millisecond, 200*time.Millisecond)
+	if err == nil {
+		t.Fatal("Expected timeout error")
+	}
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected error to wrap ErrTimeout, got: %v", err)
+	}
+
+	if results == nil || len(results.Errors) != 1 {
+		t.Errorf("Expected last results with 1 error to be returned, got %+v", results)
+	}
+}
+
+// tmpfailHTML is mockHTMLWithErrors with noble/amd64 in tmpfail, and with
+// jammy/arm64 passing unless keepRegression is set
+func tmpfailHTML(keepRegression bool) string {
+	page := strings.Replace(mockHTMLWithErrors, `<td class="fail">
+      <a href="ovn/noble/amd64">fail</a>`, `<td class="tmpfail">
+      <a href="ovn/noble/amd64">tmpfail</a>`, 1)
+	if !keepRegression {
+		page = strings.Replace(page, `<td class="regression">
+      <a href="ovn/jammy/arm64">regression</a>`, `<td class="pass">
+      <a href="ovn/jammy/arm64">pass</a>`, 1)
+	}
+	return page
+}
+
+func TestFetchRetryingTmpfail(t *testing.T) {
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if requestCount <= 2 {
+			w.Write([]byte(tmpfailHTML(false)))
+			return
+		}
+		w.Write([]byte(strings.Replace(tmpfailHTML(false), `<td class="tmpfail">
+      <a href="ovn/noble/amd64">tmpfail</a>`, `<td class="pass">
+      <a href="ovn/noble/amd64">pass</a>`, 1)))
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	var retries []int
+	results, err := s.FetchRetryingTmpfail("ovn", nil, 5, 10*time.Millisecond, func(retry int, results *PackageResults) {
+		retries = append(retries, retry)
+	})
+	if err != nil {
+		t.Fatalf("FetchRetryingTmpfail failed: %v", err)
+	}
+	if len(results.Errors) != 0 {
+		t.Errorf("Expected the tmpfail to have cleared, got %+v", results.Errors)
+	}
+	if !slices.Equal(retries, []int{1, 2}) || requestCount != 3 {
+		t.Errorf("Expected 2 retries and 3 requests, got %v and %d", retries, requestCount)
+	}
+}
+
+func TestFetchRetryingTmpfail_StopsOnOtherErrors(t *testing.T) {
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.Write([]byte(tmpfailHTML(requestCount > 1)))
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	// A regression showing up on a retry ends the retries
+	results, err := s.FetchRetryingTmpfail("ovn", nil, 5, 10*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("FetchRetryingTmpfail failed: %v", err)
+	}
+	if requestCount != 2 || len(results.Errors) != 2 {
+		t.Errorf("Expected 2 requests and both errors reported, got %d and %+v", requestCount, results.Errors)
+	}
+
+	// Retries run out while the tmpfail stays
+	requestCount = 0
+	stuck := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.Write([]byte(tmpfailHTML(false)))
+	}))
+	defer stuck.Close()
+
+	s.BaseURL = stuck.URL
+	results, err = s.FetchRetryingTmpfail("ovn", nil, 2, 10*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("FetchRetryingTmpfail failed: %v", err)
+	}
+	if requestCount != 3 || len(results.Errors) != 1 || results.Errors[0].Status != "tmpfail" {
+		t.Errorf("Expected 3 requests and the tmpfail reported, got %d and %+v", requestCount, results.Errors)
+	}
+}
+
+func TestFetchRetryingTmpfail_NoRetryWithOtherErrors(t *testing.T) {
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.Write([]byte(tmpfailHTML(true)))
+	}))
+	defer server.Close()
+
+	s := NewScraper()
+	s.BaseURL = server.URL
+
+	// The regression next to the tmpfail is reported without retrying
+	results, err := s.FetchRetryingTmpfail("ovn", nil, 5, 10*time.Millisecond, func(retry int, results *PackageResults) {
+		t.Errorf("Unexpected retry %d", retry)
+	})
+	if err != nil {
+		t.Fatalf("FetchRetryingTmpfail failed: %v", err)
+	}
+	if requestCount != 1 || len(results.Errors) != 2 {
+		t.Errorf("Expected 1 request and both errors reported, got %d and %+v", requestCount, results.Errors)
+	}
+}
+
+func TestFilterWarnsOnMissingArchitecture(t *testing.T) {
+	s := NewScraper()
+	filter := &Filter{Architecture: "amd64,s390x"}
+	results, err := s.ParseHTML(mockHTMLWithErrors, "ovn", filter)
+
+	if err != nil {
+		t.Fatalf("ParseHTML with filter failed: %v", err)
+	}
+
+	// amd64 exists and is returned; s390x is not in the matrix at all
+	if len(results.Tests) != 3 {
+		t.Errorf("Expected 3 results for amd64, got %d", len(results.Tests))
+	}
+
+	if len(results.Warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d: %v", len(results.Warnings), results.Warnings)
+	}
+	if !strings.Contains(results.Warnings[0], "architecture s390x has no results") {
+		t.Errorf("Unexpected warning: %s", results.Warnings[0])
+	}
+}
+
+func TestFilterWarnsOnMissingRelease(t *testing.T) {
+	s := NewScraper()
+	filter := &Filter{Release: "resolute"}
+	results, err := s.ParseHTML(mockHTMLWithErrors, "ovn", filter)
+
+	if err != nil {
+		t.Fatalf("ParseHTML with filter failed: %v", err)
+	}
+
+	if len(results.Tests) != 0 {
+		t.Errorf("Expected 0 results, got %d", len(results.Tests))
+	}
+
+	if len(results.Warnings) != 1 || !strings.Contains(results.Warnings[0], "release resolute has no results") {
+		t.Errorf("Expected missing release warning, got %v", results.Warnings)
+	}
+}
+
+func TestFilterNoWarningWhenPresentAndPassing(t *testing.T) {
+	s := NewScraper()
+	filter := &Filter{Release: "focal", Architecture: "arm64"}
+	results, err := s.ParseHTML(mockHTMLWithErrors, "ovn", filter)
+
+	if err != nil {
+		t.Fatalf("ParseHTML with filter failed: %v", err)
+	}
+
+	if len(results.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", results.Warnings)
+	}
+}
+
+func TestFilterByStatus(t *testing.T) {
+	html := `
+<table class="table">
+  <tr><th></th><th>noble</th><th>jammy</th></tr>
+  <tr><th>amd64</th><td class="fail">✘ fail</td><td class="regression">regression</td></tr>
+  <tr><th>arm64</th><td class="neutral">😐 neutral</td><td class="pass">✔ pass</td></tr>
+</table>`
+
+	tests := []struct {
+		name   string
+		status string
+		want   []string
+	}{
+		{name: "single status", status: "regression", want: []string{"jammy/amd64"}},
+		{name: "case insensitive", status: "REGRESSION", want: []string{"jammy/amd64"}},
+		{name: "emoji prefixed", status: "pass", want: []string{"jammy/arm64"}},
+		{name: "list", status: "fail, neutral", want: []string{"noble/amd64", "noble/arm64"}},
+		{name: "no match", status: "tmpfail", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScraper()
+			results, err := s.ParseHTML(html, "ovn", &Filter{Status: tt.status})
+			if err != nil {
+				t.Fatalf("ParseHTML failed: %v", err)
+			}
+
+			var got []string
+			for _, test := range results.Tests {
+				got = append(got, test.Release+"/"+test.Architecture)
+			}
+			slices.Sort(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected tests %v, got %v", tt.want, got)
+			}
+			if len(results.Warnings) != 0 {
+				t.Errorf("Expected no warnings, got %v", results.Warnings)
+			}
+		})
+	}
+}
+
+func TestFilterByTrigger(t *testing.T) {
+	html := `
+<table class="table">
+  <tr><th></th><th>noble</th><th>jammy</th></tr>
+  <tr>
+    <th>amd64</th>
+    <td class="fail" title="fail, triggers: ovn/25.09.0-3 openssl/3.5.4-1ubuntu1, duration: 1h 20m 25s"><a href="ovn/noble/amd64">fail</a></td>
+    <td class="pass"><a href="ovn/jammy/amd64?trigger=ovn%2F25.09.0-2" title="duration: 5m 3s">pass</a></td>
+  </tr>
+  <tr>
+    <th>arm64</th>
+    <td class="pass"><a href="ovn/noble/arm64" title="trigger: dhcpcd/1:10.3.0-7">pass</a></td>
+    <td class="pass"><a href="ovn/jammy/arm64">pass</a></td>
+  </tr>
+</table>`
+
+	s := NewScraper()
+	results, err := s.ParseHTML(html, "ovn", nil)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+
+	wantTriggers := map[string]string{
+		"noble/amd64": "ovn/25.09.0-3 openssl/3.5.4-1ubuntu1",
+		"jammy/amd64": "ovn/25.09.0-2",
+		"noble/arm64": "dhcpcd/1:10.3.0-7",
+		"jammy/arm64": "",
+	}
+	for _, test := range results.Tests {
+		cell := test.Release + "/" + test.Architecture
+		if test.Trigger != wantTriggers[cell] {
+			t.Errorf("%s: expected trigger %q, got %q", cell, wantTriggers[cell], test.Trigger)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		trigger string
+		want    []string
+	}{
+		{name: "one of several triggers", trigger: "openssl/3.5.4-1ubuntu1", want: []string{"noble/amd64"}},
+		{name: "from link", trigger: "ovn/25.09.0-2", want: []string{"jammy/amd64"}},
+		{name: "list", trigger: "ovn/25.09.0-3, ovn/25.09.0-2", want: []string{"jammy/amd64", "noble/amd64"}},
+		{name: "other version", trigger: "ovn/25.09.0-1", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := s.ParseHTML(html, "ovn", &Filter{Trigger: tt.trigger})
+			if err != nil {
+				t.Fatalf("ParseHTML failed: %v", err)
+			}
+
+			var got []string
+			for _, test := range results.Tests {
+				got = append(got, test.Release+"/"+test.Architecture)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Expected tests %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWithResultHook(t *testing.T) {
+	var calls []string
+	var seen *PackageResults
+
+	s := NewScraper(
+		WithResultHook(func(r *PackageResults) {
+			calls = append(calls, "first")
+			seen = r
+		}),
+		WithResultHook(func(r *PackageResults) {
+			calls = append(calls, "second")
+			// Annotate known failures
+			for i := range r.Errors {
+				r.Errors[i].Trigger = "known: LP#123456"
+			}
+		}),
+	)
+
+	results, err := s.ParseHTML(mockHTMLWithErrors, "ovn", nil)
+	if err != nil {
+		t.Fatalf("ParseHTML failed: %v", err)
+	}
+
+	if strings.Join(calls, ",") != "first,second" {
+		t.Errorf("Expected hooks to run in order once each, got %v", calls)
+	}
+
+	if seen != results {
+		t.Error("Expected hook to receive the returned results")
+	}
+	if len(seen.Tests) != 6 || len(seen.Errors) != 2 {
+		t.Errorf("Expected hook to see parsed results (6 tests, 2 errors), got %d tests, %d errors", len(seen.Tests), len(seen.Errors))
+	}
+
+	for _, e := range results.Errors {
+		if e.Trigger != "known: LP#123456" {
+			t.Errorf("Expected hook annotation on error, got trigger %q", e.Trigger)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// SaveResults writes r to path as JSON, so that the results can be reviewed
+// offline later with LoadResults (e.g. attached to a bug report)
+func SaveResults(r *PackageResults, path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode results: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+	return nil
+}
+
+// LoadResults reads results saved by SaveResults without any network access
+func LoadResults(path string) (*PackageResults, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results: %w", err)
+	}
+
+	var r PackageResults
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse results file %s: %w", path, err)
+	}
+	if r.Package == "" {
+		return nil, fmt.Errorf("results file %s has no package", path)
+	}
+	return &r, nil
+}
+
+// FilterResults returns a copy of results narrowed down by filter, with its
+// errors collected again according to s.FailOn, as FetchPackageResultsFiltered
+// would have returned them. results is not modified.
+func (s *Scraper) FilterResults(results *PackageResults, filter *Filter) *PackageResults {
+	filtered := *results
+	filtered.Tests = slices.Clone(results.Tests)
+	filtered.Warnings = slices.Clone(results.Warnings)
+	s.filterAndCollectErrors(&filtered, filter)
+	return &filtered
+}
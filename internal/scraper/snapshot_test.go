@@ -0,0 +1,99 @@
+package scraper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// snapshotResults is a small matrix with one regression on noble/amd64 and a
+// tmpfail on jammy/arm64
+func snapshotResults() *PackageResults {
+	lastRun := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []TestResult{
+		{Package: "ovn", Release: "noble", Architecture: "amd64", Status: "regression", LastRun: lastRun, Trigger: "ovn/25.09.0-3"},
+		{Package: "ovn", Release: "noble", Architecture: "arm64", Status: "pass", LastRun: lastRun},
+		{Package: "ovn", Release: "jammy", Architecture: "arm64", Status: "tmpfail", InProgress: true},
+	}
+	return &PackageResults{
+		Package:       "ovn",
+		Tests:         tests,
+		Errors:        []TestResult{tests[0], tests[2]},
+		Releases:      []string{"noble", "jammy"},
+		Architectures: []string{"amd64", "arm64"},
+	}
+}
+
+func TestSaveAndLoadResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ovn.json")
+	results := snapshotResults()
+	if err := SaveResults(results, path); err != nil {
+		t.Fatalf("SaveResults failed: %v", err)
+	}
+
+	loaded, err := LoadResults(path)
+	if err != nil {
+		t.Fatalf("LoadResults failed: %v", err)
+	}
+	if loaded.Package != "ovn" || !slices.Equal(loaded.Releases, results.Releases) || !slices.Equal(loaded.Architectures, results.Architectures) {
+		t.Errorf("Expected the saved package, releases and architectures, got %+v", loaded)
+	}
+	if len(loaded.Tests) != 3 || len(loaded.Errors) != 2 {
+		t.Fatalf("Expected 3 tests and 2 errors, got %d and %d", len(loaded.Tests), len(loaded.Errors))
+	}
+	if !loaded.Tests[0].LastRun.Equal(results.Tests[0].LastRun) || loaded.Tests[0].Trigger != "ovn/25.09.0-3" || !loaded.Tests[2].InProgress {
+		t.Errorf("Expected test details to round-trip, got %+v", loaded.Tests)
+	}
+}
+
+func TestLoadResults_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadResults(filepath.Join(dir, "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a not exist error for a missing file, got: %v", err)
+	}
+
+	for name, content := range map[string]string{
+		"not-json.json":   "ovn: regression",
+		"no-package.json": `{"tests": []}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if _, err := LoadResults(path); err == nil {
+			t.Errorf("Expected an error loading %s", name)
+		}
+	}
+}
+
+func TestFilterResults(t *testing.T) {
+	results := snapshotResults()
+
+	s := NewScraper(WithFailOn("regression"))
+	filtered := s.FilterResults(results, &Filter{Release: "noble"})
+	if len(filtered.Tests) != 2 {
+		t.Errorf("Expected the 2 noble tests, got %+v", filtered.Tests)
+	}
+	if len(filtered.Errors) != 1 || filtered.Errors[0].Status != "regression" {
+		t.Errorf("Expected only the regression as an error, got %+v", filtered.Errors)
+	}
+
+	// A filter matching nothing in the matrix is warned about
+	filtered = s.FilterResults(results, &Filter{Release: "focal"})
+	if len(filtered.Tests) != 0 || len(filtered.Warnings) != 1 {
+		t.Errorf("Expected no tests and a warning, got %+v", filtered)
+	}
+
+	// Without a filter the errors follow FailOn
+	filtered = NewScraper().FilterResults(results, nil)
+	if len(filtered.Errors) != 2 {
+		t.Errorf("Expected 2 errors with the default FailOn, got %+v", filtered.Errors)
+	}
+
+	if len(results.Tests) != 3 || len(results.Warnings) != 0 {
+		t.Errorf("Expected the original results to be left untouched, got %+v", results)
+	}
+}
@@ -0,0 +1,7 @@
+package scraper
+
+import "github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+
+// passingStatuses are the statuses of tests that did not fail: neutral means
+// the test had nothing to check on that release/architecture
+var passingStatuses = []autopkgtest.Status{autopkgtest.StatusPass, autopkgtest.StatusNeutral}
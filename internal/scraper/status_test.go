@@ -0,0 +1,30 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/canonical/autopkgtest-automation/internal/autopkgtest"
+)
+
+func TestIsPassingStatus(t *testing.T) {
+	for _, status := range []string{"pass", "✔ pass", "PASS", "😐neutral", "neutral"} {
+		if !isPassingStatus(status) {
+			t.Errorf("Expected %q to be passing", status)
+		}
+	}
+	for _, status := range []string{"fail", "regression", "✘ regression", "tmpfail", "bypass", "bypass-failed", "pass-failed", "passed", "unknown", string(autopkgtest.StatusNoData), ""} {
+		if isPassingStatus(status) {
+			t.Errorf("Expected %q not to be passing", status)
+		}
+	}
+}
+
+func TestIsErrorStatusUnknownStatus(t *testing.T) {
+	// Statuses that merely contain "pass" are errors like any unknown status
+	s := NewScraper()
+	for _, status := range []string{"bypass-failed", "pass-failed"} {
+		if !s.isErrorStatus(status) {
+			t.Errorf("Expected %q to be an error", status)
+		}
+	}
+}
@@ -1,20 +1,92 @@
 package triggerlinkgenerator
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 )
 
+// SupportedSuites lists the Ubuntu release codenames autopkgtest currently
+// accepts requests for
+var SupportedSuites = []string{"focal", "jammy", "noble", "questing", "resolute"}
+
+// SupportedArches lists the architectures autopkgtest runs tests on
+var SupportedArches = []string{"amd64", "arm64", "armhf", "i386", "ppc64el", "riscv64", "s390x"}
+
+// SuiteArches lists the architectures tests can be requested on for each
+// suite, since not every architecture is tested on every release
+var SuiteArches = map[string][]string{
+	"focal":    {"amd64", "arm64", "armhf", "i386", "ppc64el", "s390x"},
+	"jammy":    {"amd64", "arm64", "armhf", "i386", "ppc64el", "s390x"},
+	"noble":    {"amd64", "arm64", "armhf", "i386", "ppc64el", "riscv64", "s390x"},
+	"questing": {"amd64", "arm64", "armhf", "i386", "ppc64el", "riscv64", "s390x"},
+	"resolute": {"amd64", "arm64", "armhf", "i386", "ppc64el", "riscv64", "s390x"},
+}
+
+// ArchesForSuite returns the architectures of suite in SuiteArches, or
+// SupportedArches for a suite it does not list (e.g. one added by a
+// refreshed index). Architectures missing from SupportedArches are left
+// out, so that the result always passes validation.
+func ArchesForSuite(suite string) []string {
+	archs, ok := SuiteArches[suite]
+	if !ok {
+		return slices.Clone(SupportedArches)
+	}
+	var supported []string
+	for _, arch := range archs {
+		if slices.Contains(SupportedArches, arch) {
+			supported = append(supported, arch)
+		}
+	}
+	return supported
+}
+
+// AllArchitectures is the pseudo-architecture requesting a test without a
+// specific architecture. request.cgi has no "arch=all" target: a request
+// with no arch parameter is what makes the server pick the architectures
+// itself, so buildURL omits the parameter for it. Combined with concrete
+// architectures it still yields its own URL.
+const AllArchitectures = "all"
+
+// ppaRegex matches a Launchpad PPA reference of the form "user/ppa-name"
+var ppaRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9.+-]*/[a-z0-9][a-z0-9.+-]*$`)
+
+// launchpadUserRegex matches a Launchpad user or team name
+var launchpadUserRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9.+-]*$`)
+
+// triggerRegex matches a trigger of the form "package/version", such as
+// "systemd/259-1ubuntu3", "dhcpcd/1:10.3.0-7" or "migration-reference/0"
+var triggerRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9.+-]*/[0-9][A-Za-z0-9.+~:-]*$`)
+
+// envKeyRegex matches an environment variable name
+var envKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 // LinkRequest represents a request to generate an autopkgtest trigger link
 type LinkRequest struct {
 	Package       string   // Source package name (required)
 	Version       string   // Package version (optional, used in trigger param)
 	Triggers      []string // Custom trigger list (optional, overrides package/version; multiple triggers supported)
-	Architectures []string // List of architectures to test (optional)
-	Suite         string   // Ubuntu release codename (required, e.g., "noble", "mantic")
+	Architectures []string // List of architectures to test (optional, AllArchitectures for no specific one)
+	Suite         string   // Ubuntu release codename (required, e.g., "noble", "jammy")
 	PPA           string   // PPA name for testing (optional, format: "user/ppa-name")
+	PPAs          []string // Additional PPAs layered after PPA, in order (optional)
+	ReadableBy    []string // Launchpad users allowed to see the results of private PPA tests (optional)
 	AllProposed   bool     // Install all packages from proposed pocket (optional)
+	Env           []string // Environment variables for the test, as KEY=VALUE (optional)
+
+	// AllowAnyTrigger skips the check that every custom trigger has the
+	// form "package/version", for exotic triggers the server accepts
+	AllowAnyTrigger bool
+
+	// DiscoverArchitectures generates one URL per architecture the package
+	// is currently tested on in Suite, as reported by the generator's
+	// ArchSource, instead of using Architectures
+	DiscoverArchitectures bool
 }
 
 // LinkResponse represents the result of generating trigger URLs
@@ -23,15 +95,40 @@ type LinkResponse struct {
 	Message string   // Human-readable message
 }
 
+// PackageLinks holds the trigger URLs generated for one package of a
+// multi-package request
+type PackageLinks struct {
+	Package string
+	URLs    []string
+}
+
+// ArchitectureSource reports the architectures a package is tested on in a
+// suite. *scraper.Scraper implements it from the package's results matrix.
+type ArchitectureSource interface {
+	TestedArchitectures(pkg, suite string) ([]string, error)
+}
+
+// ArchitectureList is an ArchitectureSource returning the same architectures
+// for every package and suite
+type ArchitectureList []string
+
+// TestedArchitectures returns the list itself
+func (l ArchitectureList) TestedArchitectures(pkg, suite string) ([]string, error) {
+	return l, nil
+}
+
 // Generator handles generating autopkgtest trigger URLs
 type Generator struct {
-	BaseURL string
+	BaseURL     string
+	KnownSuites []string           // Release codenames accepted as Suite (SupportedSuites if nil)
+	ArchSource  ArchitectureSource // Used for requests with DiscoverArchitectures (optional)
 }
 
 // NewGenerator creates a new generator instance
 func NewGenerator() *Generator {
 	return &Generator{
-		BaseURL: "https://autopkgtest.ubuntu.com/request.cgi",
+		BaseURL:     "https://autopkgtest.ubuntu.com/request.cgi",
+		KnownSuites: slices.Clone(SupportedSuites),
 	}
 }
 
@@ -43,8 +140,32 @@ func (g *Generator) GenerateLinks(req *LinkRequest) (*LinkResponse, error) {
 	if req.Package == "" {
 		return nil, fmt.Errorf("package name is required")
 	}
-	if req.Suite == "" {
-		return nil, fmt.Errorf("suite (release) is required")
+	if err := g.validateSuite(req.Suite); err != nil {
+		return nil, err
+	}
+	if err := validateTriggers(req.Triggers, req.AllowAnyTrigger); err != nil {
+		return nil, err
+	}
+	if err := g.validateDiscovery(req); err != nil {
+		return nil, err
+	}
+	// Discovered architectures come from the results page and are not
+	// checked; only those given are
+	if err := validateArchitectures(normalizeArchitectures(req.Architectures)); err != nil {
+		return nil, err
+	}
+	// A malformed PPA would otherwise only be rejected by the server, with
+	// an error that does not say which parameter is wrong
+	for _, ppa := range req.ppas() {
+		if err := validatePPA(ppa); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateReadableBy(req.ReadableBy, len(req.ppas()) > 0); err != nil {
+		return nil, err
+	}
+	if err := validateEnv(req.Env); err != nil {
+		return nil, err
 	}
 
 	// Determine trigger parameter
@@ -59,20 +180,32 @@ func (g *Generator) GenerateLinks(req *LinkRequest) (*LinkResponse, error) {
 		trigger = "migration-reference/0"
 	}
 
+	archs := normalizeArchitectures(req.Architectures)
+	if req.DiscoverArchitectures {
+		discovered, err := g.ArchSource.TestedArchitectures(req.Package, req.Suite)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover architectures of %s on %s: %w", req.Package, req.Suite, err)
+		}
+		if len(discovered) == 0 {
+			return nil, fmt.Errorf("%s is not tested on any architecture on %s", req.Package, req.Suite)
+		}
+		archs = discovered
+	}
+
 	var urls []string
 	var message string
 
 	// If architectures are specified, generate one URL per arch
-	if len(req.Architectures) > 0 {
-		for _, arch := range req.Architectures {
-			generatedURL := g.buildURL(req.Package, req.Suite, arch, trigger, req.PPA, req.AllProposed)
+	if len(archs) > 0 {
+		for _, arch := range archs {
+			generatedURL := g.buildURL(req.Package, req.Suite, arch, trigger, req.ppas(), req.ReadableBy, req.Env, req.AllProposed)
 			urls = append(urls, generatedURL)
 		}
 		message = fmt.Sprintf("Generated %d trigger URL(s) for package '%s' on %s (%s)",
-			len(urls), req.Package, req.Suite, strings.Join(req.Architectures, ", "))
+			len(urls), req.Package, req.Suite, strings.Join(archs, ", "))
 	} else {
 		// Generate a single URL without architecture specification
-		generatedURL := g.buildURL(req.Package, req.Suite, "", trigger, req.PPA, req.AllProposed)
+		generatedURL := g.buildURL(req.Package, req.Suite, "", trigger, req.ppas(), req.ReadableBy, req.Env, req.AllProposed)
 		urls = append(urls, generatedURL)
 		message = fmt.Sprintf("Generated trigger URL for package '%s' on %s (all architectures)",
 			req.Package, req.Suite)
@@ -84,20 +217,200 @@ func (g *Generator) GenerateLinks(req *LinkRequest) (*LinkResponse, error) {
 	}, nil
 }
 
+// GenerateLinksForPackages generates trigger URLs for each package in
+// packages, sharing every other option of req (suite, architectures,
+// triggers, PPAs, all-proposed). req.Package is ignored. The result holds
+// one entry per package, in order, covering the full package × architecture
+// cross product. req.Version cannot be used since it is specific to a single
+// package; use req.Triggers instead.
+func (g *Generator) GenerateLinksForPackages(req *LinkRequest, packages []string) ([]PackageLinks, error) {
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("at least one package is required")
+	}
+	if req.Version != "" {
+		return nil, fmt.Errorf("version cannot be combined with multiple packages (use triggers instead)")
+	}
+
+	var links []PackageLinks
+	for _, pkg := range packages {
+		pkgReq := *req
+		pkgReq.Package = pkg
+
+		resp, err := g.GenerateLinks(&pkgReq)
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %w", pkg, err)
+		}
+		links = append(links, PackageLinks{Package: pkg, URLs: resp.URLs})
+	}
+
+	return links, nil
+}
+
+// Validate checks a link request without building any URLs. It reports every
+// problem found (missing fields, unknown suite or architectures, malformed
+// trigger or PPA)
+// as a single joined error.
+func (g *Generator) Validate(req *LinkRequest) error {
+	var errs []error
+
+	if req.Package == "" {
+		errs = append(errs, fmt.Errorf("package name is required"))
+	}
+	if err := g.validateSuite(req.Suite); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateArchitectures(normalizeArchitectures(req.Architectures)); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateTriggers(req.Triggers, req.AllowAnyTrigger); err != nil {
+		errs = append(errs, err)
+	}
+	for _, ppa := range req.ppas() {
+		if err := validatePPA(ppa); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := validateReadableBy(req.ReadableBy, len(req.ppas()) > 0); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateEnv(req.Env); err != nil {
+		errs = append(errs, err)
+	}
+	if err := g.validateDiscovery(req); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateDiscovery checks that a request with DiscoverArchitectures has no
+// explicit architectures and that the generator has an ArchSource
+func (g *Generator) validateDiscovery(req *LinkRequest) error {
+	if !req.DiscoverArchitectures {
+		return nil
+	}
+	if len(normalizeArchitectures(req.Architectures)) > 0 {
+		return fmt.Errorf("architectures cannot be combined with architecture discovery")
+	}
+	if g.ArchSource == nil {
+		return fmt.Errorf("architecture discovery requires an architecture source")
+	}
+	return nil
+}
+
+// validateSuite checks that suite is one of the generator's known suites
+func (g *Generator) validateSuite(suite string) error {
+	if suite == "" {
+		return fmt.Errorf("suite (release) is required")
+	}
+	known := g.KnownSuites
+	if known == nil {
+		known = SupportedSuites
+	}
+	if !slices.Contains(known, suite) {
+		return fmt.Errorf("unknown suite %q (valid: %s)", suite, strings.Join(known, ", "))
+	}
+	return nil
+}
+
+// validateArchitectures checks that every architecture is one of
+// SupportedArches or AllArchitectures
+func validateArchitectures(archs []string) error {
+	var unknown []string
+	for _, arch := range archs {
+		if arch != AllArchitectures && !slices.Contains(SupportedArches, arch) {
+			unknown = append(unknown, arch)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown architecture(s) %s (valid: %s)",
+			strings.Join(unknown, ", "), strings.Join(SupportedArches, ", "))
+	}
+	return nil
+}
+
+// normalizeArchitectures lowercases archs and drops empty entries and
+// duplicates, keeping the first occurrence of each, so that "amd64,AMD64,"
+// yields a single amd64 URL
+func normalizeArchitectures(archs []string) []string {
+	var normalized []string
+	for _, arch := range archs {
+		arch = strings.ToLower(strings.TrimSpace(arch))
+		if arch == "" || slices.Contains(normalized, arch) {
+			continue
+		}
+		normalized = append(normalized, arch)
+	}
+	return normalized
+}
+
+// validateTriggers checks that every trigger has the form "package/version",
+// unless allowAny is set, so that a typo such as "systemd259-1" is caught
+// before the request reaches the server
+func validateTriggers(triggers []string, allowAny bool) error {
+	if allowAny {
+		return nil
+	}
+	for _, trigger := range triggers {
+		if !triggerRegex.MatchString(trigger) {
+			return fmt.Errorf("invalid trigger %q (expected format: package/version, e.g. migration-reference/0)", trigger)
+		}
+	}
+	return nil
+}
+
+// validatePPA checks that ppa has the form "user/ppa-name", as Launchpad
+// names them (lowercase letters, digits, ".", "+" and "-")
+func validatePPA(ppa string) error {
+	if !ppaRegex.MatchString(ppa) {
+		return fmt.Errorf("invalid PPA %q (expected format: user/ppa-name)", ppa)
+	}
+	return nil
+}
+
+// validateReadableBy checks that users are Launchpad names and are only given
+// along with a PPA, since results of main archive tests are always public
+func validateReadableBy(users []string, hasPPA bool) error {
+	if len(users) == 0 {
+		return nil
+	}
+	if !hasPPA {
+		return fmt.Errorf("readable-by requires at least one PPA")
+	}
+	var invalid []string
+	for _, user := range users {
+		if !launchpadUserRegex.MatchString(user) {
+			invalid = append(invalid, fmt.Sprintf("%q", user))
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid readable-by user(s) %s (expected Launchpad user or team names)", strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
 // buildURL constructs a single autopkgtest trigger URL
-func (g *Generator) buildURL(pkg, suite, arch, trigger, ppa string, allProposed bool) string {
+func (g *Generator) buildURL(pkg, suite, arch, trigger string, ppas, readableBy, env []string, allProposed bool) string {
 	params := url.Values{}
 	params.Add("release", suite)
 	params.Add("package", pkg)
 	params.Add("trigger", trigger)
 
-	if arch != "" {
+	if arch != "" && arch != AllArchitectures {
 		params.Add("arch", arch)
 	}
 
-	if ppa != "" {
+	// request.cgi layers PPAs in the order the ppa parameters are given
+	for _, ppa := range ppas {
 		params.Add("ppa", ppa)
 	}
+	for _, user := range readableBy {
+		params.Add("readable-by", user)
+	}
+	// Each variable is its own env parameter; the = inside is escaped
+	for _, variable := range env {
+		params.Add("env", variable)
+	}
 
 	if allProposed {
 		params.Add("all-proposed", "1")
@@ -106,6 +419,36 @@ func (g *Generator) buildURL(pkg, suite, arch, trigger, ppa string, allProposed
 	return fmt.Sprintf("%s?%s", g.BaseURL, params.Encode())
 }
 
+// validateEnv checks that every variable has the form KEY=VALUE with a valid
+// shell variable name as KEY; VALUE may be empty and contain anything
+func validateEnv(env []string) error {
+	var invalid []string
+	for _, variable := range env {
+		key, _, found := strings.Cut(variable, "=")
+		if !found || !envKeyRegex.MatchString(key) {
+			invalid = append(invalid, fmt.Sprintf("%q", variable))
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid env variable(s) %s (expected KEY=VALUE)", strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// ppas returns PPA followed by PPAs, skipping empty entries
+func (req *LinkRequest) ppas() []string {
+	var ppas []string
+	if req.PPA != "" {
+		ppas = append(ppas, req.PPA)
+	}
+	for _, ppa := range req.PPAs {
+		if ppa != "" {
+			ppas = append(ppas, ppa)
+		}
+	}
+	return ppas
+}
+
 // String creates a formatted string representation of a link request
 func (req *LinkRequest) String() string {
 	var result strings.Builder
@@ -123,12 +466,18 @@ func (req *LinkRequest) String() string {
 	} else {
 		result.WriteString("Arch(s):\tall\n")
 	}
-	if req.PPA != "" {
-		result.WriteString(fmt.Sprintf("PPA:\t%s\n", req.PPA))
+	if ppas := req.ppas(); len(ppas) > 0 {
+		result.WriteString(fmt.Sprintf("PPA(s):\t%s\n", strings.Join(ppas, ", ")))
+	}
+	if len(req.ReadableBy) > 0 {
+		result.WriteString(fmt.Sprintf("Readable-By:\t%s\n", strings.Join(req.ReadableBy, ", ")))
 	}
 	if req.AllProposed {
 		result.WriteString("All-Proposed:\tyes\n")
 	}
+	if len(req.Env) > 0 {
+		result.WriteString(fmt.Sprintf("Env:\t%s\n", strings.Join(req.Env, ", ")))
+	}
 
 	return result.String()
 }
@@ -150,3 +499,23 @@ func (resp *LinkResponse) String() string {
 
 	return result.String()
 }
+
+// WriteYAML writes the link response to w as a YAML document with a
+// "message" string and a "urls" list, for consumption by other tooling.
+// Every value is emitted as a double-quoted scalar so that URLs and messages
+// never need further escaping.
+func (resp *LinkResponse) WriteYAML(w io.Writer) error {
+	var result strings.Builder
+	result.WriteString("message: " + strconv.Quote(resp.Message) + "\n")
+	if len(resp.URLs) == 0 {
+		result.WriteString("urls: []\n")
+	} else {
+		result.WriteString("urls:\n")
+		for _, u := range resp.URLs {
+			result.WriteString("  - " + strconv.Quote(u) + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, result.String())
+	return err
+}
@@ -1,6 +1,10 @@
 package triggerlinkgenerator
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -92,6 +96,107 @@ func TestGenerateLinksWithArchitectures(t *testing.T) {
 	}
 }
 
+func TestGenerateLinksNormalizesArchitectures(t *testing.T) {
+	tests := []struct {
+		name     string
+		archs    []string
+		wantArch []string
+	}{
+		{name: "duplicates", archs: []string{"amd64", "arm64", "amd64"}, wantArch: []string{"amd64", "arm64"}},
+		{name: "casing", archs: []string{"AMD64", "Arm64", "amd64"}, wantArch: []string{"amd64", "arm64"}},
+		{name: "empty items", archs: []string{"amd64", "", " ", "s390x", ""}, wantArch: []string{"amd64", "s390x"}},
+		{name: "only empty items", archs: []string{"", ""}, wantArch: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewGenerator()
+			resp, err := gen.GenerateLinks(&LinkRequest{
+				Package:       "testpkg",
+				Suite:         "noble",
+				Architectures: tt.archs,
+			})
+			if err != nil {
+				t.Fatalf("GenerateLinks failed: %v", err)
+			}
+
+			if len(tt.wantArch) == 0 {
+				if len(resp.URLs) != 1 || strings.Contains(resp.URLs[0], "arch=") {
+					t.Errorf("Expected a single URL without arch, got %v", resp.URLs)
+				}
+				return
+			}
+
+			if len(resp.URLs) != len(tt.wantArch) {
+				t.Fatalf("Expected %d URLs, got %d: %v", len(tt.wantArch), len(resp.URLs), resp.URLs)
+			}
+			for i, arch := range tt.wantArch {
+				if !strings.Contains(resp.URLs[i], "arch="+arch+"&") {
+					t.Errorf("Expected URL %d to contain arch=%s, got %s", i, arch, resp.URLs[i])
+				}
+			}
+			if !strings.Contains(resp.Message, strings.Join(tt.wantArch, ", ")) {
+				t.Errorf("Expected message to list %v, got %s", tt.wantArch, resp.Message)
+			}
+		})
+	}
+}
+
+func TestValidateNormalizesArchitectures(t *testing.T) {
+	gen := NewGenerator()
+	err := gen.Validate(&LinkRequest{
+		Package:       "testpkg",
+		Suite:         "noble",
+		Architectures: []string{"AMD64", "arm64", ""},
+	})
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestGenerateLinksAllArchitectures(t *testing.T) {
+	tests := []struct {
+		name      string
+		archs     []string
+		wantArchs []string // Expected arch of each URL, "" for none
+	}{
+		{name: "all alone", archs: []string{"all"}, wantArchs: []string{""}},
+		{name: "all with concrete arches", archs: []string{"amd64", "ALL", "arm64"}, wantArchs: []string{"amd64", "", "arm64"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewGenerator()
+			req := &LinkRequest{
+				Package:       "testpkg",
+				Suite:         "noble",
+				Architectures: tt.archs,
+			}
+			if err := gen.Validate(req); err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+
+			resp, err := gen.GenerateLinks(req)
+			if err != nil {
+				t.Fatalf("GenerateLinks failed: %v", err)
+			}
+
+			if len(resp.URLs) != len(tt.wantArchs) {
+				t.Fatalf("Expected %d URLs, got %d: %v", len(tt.wantArchs), len(resp.URLs), resp.URLs)
+			}
+			for i, arch := range tt.wantArchs {
+				if arch == "" {
+					if strings.Contains(resp.URLs[i], "arch=") {
+						t.Errorf("Expected URL %d without arch, got %s", i, resp.URLs[i])
+					}
+				} else if !strings.Contains(resp.URLs[i], "arch="+arch) {
+					t.Errorf("Expected URL %d to contain arch=%s, got %s", i, arch, resp.URLs[i])
+				}
+			}
+		})
+	}
+}
+
 func TestGenerateLinksWithPPA(t *testing.T) {
 	gen := NewGenerator()
 	req := &LinkRequest{
@@ -111,6 +216,58 @@ func TestGenerateLinksWithPPA(t *testing.T) {
 	}
 }
 
+func TestGenerateLinksWithMultiplePPAs(t *testing.T) {
+	gen := NewGenerator()
+	req := &LinkRequest{
+		Package: "testpkg",
+		Suite:   "noble",
+		PPA:     "user/base-ppa",
+		PPAs:    []string{"user/second-ppa", "other/third-ppa"},
+	}
+
+	resp, err := gen.GenerateLinks(req)
+	if err != nil {
+		t.Fatalf("GenerateLinks failed: %v", err)
+	}
+
+	u, err := url.Parse(resp.URLs[0])
+	if err != nil {
+		t.Fatalf("Failed to parse generated URL: %v", err)
+	}
+
+	got := u.Query()["ppa"]
+	want := []string{"user/base-ppa", "user/second-ppa", "other/third-ppa"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected ppa params %v in order, got %v", want, got)
+	}
+}
+
+func TestGenerateLinksWithReadableBy(t *testing.T) {
+	gen := NewGenerator()
+	req := &LinkRequest{
+		Package:    "testpkg",
+		Suite:      "noble",
+		PPA:        "user/private-ppa",
+		ReadableBy: []string{"alice", "release-team"},
+	}
+
+	resp, err := gen.GenerateLinks(req)
+	if err != nil {
+		t.Fatalf("GenerateLinks failed: %v", err)
+	}
+
+	u, err := url.Parse(resp.URLs[0])
+	if err != nil {
+		t.Fatalf("Failed to parse generated URL: %v", err)
+	}
+
+	got := u.Query()["readable-by"]
+	want := []string{"alice", "release-team"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Expected readable-by params %v, got %v", want, got)
+	}
+}
+
 func TestGenerateLinksWithAllProposed(t *testing.T) {
 	gen := NewGenerator()
 	req := &LinkRequest{
@@ -137,6 +294,8 @@ func TestGenerateLinksWithCustomTrigger(t *testing.T) {
 		Suite:    "noble",
 		Version:  "1.0.0",                    // Should be ignored
 		Triggers: []string{"custom-trigger"}, // Should override version
+		// Not of the package/version form
+		AllowAnyTrigger: true,
 	}
 
 	resp, err := gen.GenerateLinks(req)
@@ -153,6 +312,31 @@ func TestGenerateLinksWithCustomTrigger(t *testing.T) {
 	}
 }
 
+func TestGenerateLinksInvalidTrigger(t *testing.T) {
+	gen := NewGenerator()
+
+	valid := []string{"migration-reference/0", "systemd/259-1ubuntu3", "dhcpcd/1:10.3.0-7", "linux/6.8.0-50.51", "gcc-14/14.2.0-4ubuntu2~24.04"}
+	if _, err := gen.GenerateLinks(&LinkRequest{Package: "testpkg", Suite: "noble", Triggers: valid}); err != nil {
+		t.Errorf("Expected valid triggers to be accepted, got: %v", err)
+	}
+
+	for _, trigger := range []string{"systemd259-1", "systemd/", "/259-1", "systemd/259 1", "Systemd/259-1", "systemd/v259"} {
+		req := &LinkRequest{Package: "testpkg", Suite: "noble", Triggers: []string{"migration-reference/0", trigger}}
+		_, err := gen.GenerateLinks(req)
+		if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("invalid trigger %q", trigger)) {
+			t.Errorf("Expected invalid trigger error naming %q, got: %v", trigger, err)
+		}
+		if err := gen.Validate(req); err == nil {
+			t.Errorf("Expected Validate to reject trigger %q", trigger)
+		}
+
+		req.AllowAnyTrigger = true
+		if _, err := gen.GenerateLinks(req); err != nil {
+			t.Errorf("Expected AllowAnyTrigger to accept %q, got: %v", trigger, err)
+		}
+	}
+}
+
 func TestGenerateLinksMissingPackage(t *testing.T) {
 	gen := NewGenerator()
 	req := &LinkRequest{
@@ -183,6 +367,69 @@ func TestGenerateLinksMissingSuite(t *testing.T) {
 	}
 }
 
+func TestGenerateLinksUnknownSuite(t *testing.T) {
+	gen := NewGenerator()
+	req := &LinkRequest{
+		Package: "testpkg",
+		Suite:   "nobel",
+	}
+
+	_, err := gen.GenerateLinks(req)
+	if err == nil {
+		t.Fatal("Expected error for unknown suite, got nil")
+	}
+	if !strings.Contains(err.Error(), `unknown suite "nobel"`) || !strings.Contains(err.Error(), "noble") {
+		t.Errorf("Expected unknown suite error listing valid suites, got: %v", err)
+	}
+}
+
+func TestGenerateLinksInvalidPPA(t *testing.T) {
+	gen := NewGenerator()
+	tests := []struct {
+		name string
+		req  *LinkRequest
+		want string
+	}{
+		{"missing user", &LinkRequest{Package: "testpkg", Suite: "noble", PPA: "just-a-name"}, `invalid PPA "just-a-name"`},
+		{"uppercase", &LinkRequest{Package: "testpkg", Suite: "noble", PPA: "User/PPA"}, `invalid PPA "User/PPA"`},
+		{"extra segment", &LinkRequest{Package: "testpkg", Suite: "noble", PPA: "user/ppa/extra"}, `invalid PPA "user/ppa/extra"`},
+		{"in list", &LinkRequest{Package: "testpkg", Suite: "noble", PPA: "user/ok", PPAs: []string{"ppa:user/other"}}, `invalid PPA "ppa:user/other"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := gen.GenerateLinks(tt.req)
+			if err == nil {
+				t.Fatal("Expected error for malformed PPA, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), "user/ppa-name") {
+				t.Errorf("Expected %s error with the expected format, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestGenerateLinksKnownSuites(t *testing.T) {
+	gen := NewGenerator()
+	gen.KnownSuites = append(gen.KnownSuites, "stonking")
+
+	if _, err := gen.GenerateLinks(&LinkRequest{Package: "testpkg", Suite: "stonking"}); err != nil {
+		t.Errorf("Expected added suite to be accepted, got: %v", err)
+	}
+	if !slices.Contains(SupportedSuites, "noble") || slices.Contains(SupportedSuites, "stonking") {
+		t.Errorf("Extending KnownSuites should not modify SupportedSuites, got %v", SupportedSuites)
+	}
+
+	// A zero Generator falls back to SupportedSuites
+	zero := &Generator{BaseURL: "https://example.com/request.cgi"}
+	if _, err := zero.GenerateLinks(&LinkRequest{Package: "testpkg", Suite: "noble"}); err != nil {
+		t.Errorf("Expected supported suite to be accepted, got: %v", err)
+	}
+	if _, err := zero.GenerateLinks(&LinkRequest{Package: "testpkg", Suite: "stonking"}); err == nil {
+		t.Error("Expected unknown suite to be rejected")
+	}
+}
+
 func TestLinkRequestString(t *testing.T) {
 	req := &LinkRequest{
 		Package:       "testpkg",
@@ -235,6 +482,41 @@ func TestLinkResponseString(t *testing.T) {
 	}
 }
 
+func TestLinkResponseWriteYAML(t *testing.T) {
+	resp := &LinkResponse{
+		URLs:    []string{"https://example.com/request.cgi?package=ovn&arch=amd64", "https://example.com/request.cgi?package=ovn&arch=arm64"},
+		Message: `Generated 2 trigger URL(s) for package 'ovn' on noble (amd64, arm64)`,
+	}
+
+	var buf strings.Builder
+	if err := resp.WriteYAML(&buf); err != nil {
+		t.Fatalf("WriteYAML failed: %v", err)
+	}
+
+	expected := `message: "Generated 2 trigger URL(s) for package 'ovn' on noble (amd64, arm64)"
+urls:
+  - "https://example.com/request.cgi?package=ovn&arch=amd64"
+  - "https://example.com/request.cgi?package=ovn&arch=arm64"
+`
+	if buf.String() != expected {
+		t.Errorf("Expected YAML:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestLinkResponseWriteYAML_Empty(t *testing.T) {
+	resp := &LinkResponse{Message: "say \"hi\""}
+
+	var buf strings.Builder
+	if err := resp.WriteYAML(&buf); err != nil {
+		t.Fatalf("WriteYAML failed: %v", err)
+	}
+
+	expected := "message: \"say \\\"hi\\\"\"\nurls: []\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
 func TestBuildURL(t *testing.T) {
 	gen := NewGenerator()
 
@@ -244,7 +526,8 @@ func TestBuildURL(t *testing.T) {
 		suite       string
 		arch        string
 		trigger     string
-		ppa         string
+		ppas        []string
+		readableBy  []string
 		allProposed bool
 		wantSubstr  []string
 	}{
@@ -254,7 +537,7 @@ func TestBuildURL(t *testing.T) {
 			suite:       "noble",
 			arch:        "",
 			trigger:     "pkg/1.0",
-			ppa:         "",
+			ppas:        nil,
 			allProposed: false,
 			wantSubstr:  []string{"package=pkg", "release=noble", "trigger=pkg%2F1.0"},
 		},
@@ -264,27 +547,59 @@ func TestBuildURL(t *testing.T) {
 			suite:       "noble",
 			arch:        "amd64",
 			trigger:     "pkg/1.0",
-			ppa:         "",
+			ppas:        nil,
 			allProposed: false,
 			wantSubstr:  []string{"arch=amd64"},
 		},
+		{
+			name:        "all architectures",
+			pkg:         "pkg",
+			suite:       "noble",
+			arch:        AllArchitectures,
+			trigger:     "pkg/1.0",
+			ppas:        nil,
+			allProposed: false,
+			wantSubstr:  []string{"package=pkg"},
+		},
 		{
 			name:        "with ppa",
 			pkg:         "pkg",
 			suite:       "noble",
 			arch:        "",
 			trigger:     "pkg/1.0",
-			ppa:         "user/ppa",
+			ppas:        []string{"user/ppa"},
 			allProposed: false,
 			wantSubstr:  []string{"ppa=user%2Fppa"},
 		},
+		{
+			name:        "with readable-by",
+			pkg:         "pkg",
+			suite:       "noble",
+			arch:        "",
+			trigger:     "pkg/1.0",
+			ppas:        []string{"user/private-ppa"},
+			readableBy:  []string{"alice", "bob"},
+			allProposed: false,
+			wantSubstr:  []string{"readable-by=alice", "readable-by=bob"},
+		},
+		{
+			name:        "with readable-by needing encoding",
+			pkg:         "pkg",
+			suite:       "noble",
+			arch:        "",
+			trigger:     "pkg/1.0",
+			ppas:        []string{"user/private-ppa"},
+			readableBy:  []string{"team+ops"},
+			allProposed: false,
+			wantSubstr:  []string{"readable-by=team%2Bops"},
+		},
 		{
 			name:        "with all-proposed",
 			pkg:         "pkg",
 			suite:       "noble",
 			arch:        "",
 			trigger:     "pkg/1.0",
-			ppa:         "",
+			ppas:        nil,
 			allProposed: true,
 			wantSubstr:  []string{"all-proposed=1"},
 		},
@@ -292,13 +607,280 @@ func TestBuildURL(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			url := gen.buildURL(tt.pkg, tt.suite, tt.arch, tt.trigger, tt.ppa, tt.allProposed)
+			url := gen.buildURL(tt.pkg, tt.suite, tt.arch, tt.trigger, tt.ppas, tt.readableBy, nil, tt.allProposed)
 
 			for _, substr := range tt.wantSubstr {
 				if !strings.Contains(url, substr) {
 					t.Errorf("URL should contain '%s', got: %s", substr, url)
 				}
 			}
+			if tt.arch == AllArchitectures && strings.Contains(url, "arch=") {
+				t.Errorf("URL should not contain an arch for %s, got: %s", AllArchitectures, url)
+			}
+		})
+	}
+}
+
+func TestGenerateLinksEnv(t *testing.T) {
+	gen := NewGenerator()
+	req := &LinkRequest{
+		Package:       "ovn",
+		Suite:         "noble",
+		Architectures: []string{"amd64"},
+		Env:           []string{"DEB_BUILD_OPTIONS=nocheck parallel=4", "EMPTY="},
+	}
+
+	resp, err := gen.GenerateLinks(req)
+	if err != nil {
+		t.Fatalf("GenerateLinks failed: %v", err)
+	}
+	if len(resp.URLs) != 1 {
+		t.Fatalf("Expected 1 URL, got %d", len(resp.URLs))
+	}
+
+	if !strings.Contains(resp.URLs[0], "env=DEB_BUILD_OPTIONS%3Dnocheck+parallel%3D4") {
+		t.Errorf("Expected an encoded env parameter, got: %s", resp.URLs[0])
+	}
+
+	parsed, err := url.Parse(resp.URLs[0])
+	if err != nil {
+		t.Fatalf("Failed to parse URL: %v", err)
+	}
+	if got := parsed.Query()["env"]; !slices.Equal(got, req.Env) {
+		t.Errorf("Expected env parameters %q, got %q", req.Env, got)
+	}
+}
+
+func TestGenerateLinksRejectsInvalidOptions(t *testing.T) {
+	gen := NewGenerator()
+	tests := []struct {
+		name string
+		req  *LinkRequest
+		want string
+	}{
+		{"env without value", &LinkRequest{Package: "ovn", Suite: "noble", Env: []string{"DEB_BUILD_OPTIONS"}}, `invalid env variable(s) "DEB_BUILD_OPTIONS"`},
+		{"env with invalid key", &LinkRequest{Package: "ovn", Suite: "noble", Env: []string{"1X=y"}}, `invalid env variable(s) "1X=y"`},
+		{"readable-by without PPA", &LinkRequest{Package: "ovn", Suite: "noble", ReadableBy: []string{"alice"}}, "readable-by requires at least one PPA"},
+		{"invalid readable-by user", &LinkRequest{Package: "ovn", Suite: "noble", PPA: "user/ppa", ReadableBy: []string{"Alice Smith"}}, `invalid readable-by user(s) "Alice Smith"`},
+		{"unknown architecture", &LinkRequest{Package: "ovn", Suite: "noble", Architectures: []string{"amd64", "amd46"}}, "unknown architecture(s) amd46"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GenerateLinks checks what Validate checks
+			if _, err := gen.GenerateLinks(tt.req); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected %s error, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	gen := NewGenerator()
+
+	tests := []struct {
+		name    string
+		req     *LinkRequest
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			req: &LinkRequest{
+				Package:       "ovn",
+				Suite:         "noble",
+				Architectures: []string{"amd64", "arm64"},
+				PPA:           "user/ppa-name",
+			},
+		},
+		{
+			name:    "unknown suite",
+			req:     &LinkRequest{Package: "ovn", Suite: "nobel"},
+			wantErr: []string{`unknown suite "nobel"`},
+		},
+		{
+			name:    "unknown architecture",
+			req:     &LinkRequest{Package: "ovn", Suite: "noble", Architectures: []string{"amd64", "x86"}},
+			wantErr: []string{"unknown architecture(s) x86"},
+		},
+		{
+			name:    "malformed PPA",
+			req:     &LinkRequest{Package: "ovn", Suite: "noble", PPA: "just-a-name"},
+			wantErr: []string{`invalid PPA "just-a-name"`},
+		},
+		{
+			name:    "malformed PPA in list",
+			req:     &LinkRequest{Package: "ovn", Suite: "noble", PPAs: []string{"user/ok", "just-a-name"}},
+			wantErr: []string{`invalid PPA "just-a-name"`},
+		},
+		{
+			name:    "readable-by without PPA",
+			req:     &LinkRequest{Package: "ovn", Suite: "noble", ReadableBy: []string{"alice"}},
+			wantErr: []string{"readable-by requires at least one PPA"},
+		},
+		{
+			name:    "malformed readable-by user",
+			req:     &LinkRequest{Package: "ovn", Suite: "noble", PPA: "user/ppa", ReadableBy: []string{"alice", "Bob Smith"}},
+			wantErr: []string{`invalid readable-by user(s) "Bob Smith"`},
+		},
+		{
+			name:    "malformed env variables",
+			req:     &LinkRequest{Package: "ovn", Suite: "noble", Env: []string{"DEB_BUILD_OPTIONS=nocheck", "NOVALUE", "1BAD=x"}},
+			wantErr: []string{`invalid env variable(s) "NOVALUE", "1BAD=x"`},
+		},
+		{
+			name:    "multiple problems",
+			req:     &LinkRequest{Suite: "nobel", PPA: "just-a-name"},
+			wantErr: []string{"package name is required", `unknown suite "nobel"`, `invalid PPA "just-a-name"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := gen.Validate(tt.req)
+
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("Expected no error, got: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("Expected validation error, got nil")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Expected error to contain %q, got: %v", want, err)
+				}
+			}
 		})
 	}
 }
+
+func TestGenerateLinksForPackages(t *testing.T) {
+	gen := NewGenerator()
+	req := &LinkRequest{
+		Suite:         "noble",
+		Architectures: []string{"amd64", "arm64"},
+		Triggers:      []string{"systemd/259-1ubuntu3"},
+		AllProposed:   true,
+	}
+
+	links, err := gen.GenerateLinksForPackages(req, []string{"ovn", "openvswitch", "dhcpcd"})
+	if err != nil {
+		t.Fatalf("GenerateLinksForPackages failed: %v", err)
+	}
+
+	if len(links) != 3 {
+		t.Fatalf("Expected 3 packages, got %d", len(links))
+	}
+
+	total := 0
+	for i, want := range []string{"ovn", "openvswitch", "dhcpcd"} {
+		if links[i].Package != want {
+			t.Errorf("Expected package %d to be %s, got %s", i, want, links[i].Package)
+		}
+		if len(links[i].URLs) != 2 {
+			t.Errorf("Expected 2 URLs for %s, got %d", want, len(links[i].URLs))
+		}
+		for _, url := range links[i].URLs {
+			if !strings.Contains(url, "package="+want+"&") {
+				t.Errorf("URL for %s should contain package=%s, got %s", want, want, url)
+			}
+			if !strings.Contains(url, "trigger=systemd%2F259-1ubuntu3") || !strings.Contains(url, "all-proposed=1") {
+				t.Errorf("URL for %s should carry the shared options, got %s", want, url)
+			}
+		}
+		total += len(links[i].URLs)
+	}
+
+	// 3 packages × 2 architectures
+	if total != 6 {
+		t.Errorf("Expected 6 URLs in total, got %d", total)
+	}
+}
+
+func TestGenerateLinksForPackagesWithVersion(t *testing.T) {
+	gen := NewGenerator()
+	req := &LinkRequest{Suite: "noble", Version: "1.0-1"}
+
+	if _, err := gen.GenerateLinksForPackages(req, []string{"ovn", "openvswitch"}); err == nil {
+		t.Error("Expected error when combining version with multiple packages")
+	}
+}
+
+// failingArchSource is an ArchitectureSource that cannot reach the server
+type failingArchSource struct{}
+
+func (failingArchSource) TestedArchitectures(pkg, suite string) ([]string, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestGenerateLinksDiscoverArchitectures(t *testing.T) {
+	gen := NewGenerator()
+	// Discovered architectures are tested on the server, so even one
+	// missing from SupportedArches is used
+	gen.ArchSource = ArchitectureList{"amd64", "s390x", "loong64"}
+
+	resp, err := gen.GenerateLinks(&LinkRequest{Package: "ovn", Suite: "noble", DiscoverArchitectures: true})
+	if err != nil {
+		t.Fatalf("GenerateLinks failed: %v", err)
+	}
+	if len(resp.URLs) != 3 {
+		t.Fatalf("Expected one URL per discovered architecture, got %v", resp.URLs)
+	}
+	for i, arch := range []string{"amd64", "s390x", "loong64"} {
+		if !strings.Contains(resp.URLs[i], "arch="+arch) {
+			t.Errorf("Expected URL %d to be for %s, got %s", i, arch, resp.URLs[i])
+		}
+	}
+}
+
+func TestGenerateLinksDiscoverArchitecturesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source ArchitectureSource
+		archs  []string
+		want   string
+	}{
+		{"no source", nil, nil, "requires an architecture source"},
+		{"with architectures", ArchitectureList{"amd64"}, []string{"arm64"}, "cannot be combined"},
+		{"source error", failingArchSource{}, nil, "connection refused"},
+		{"not tested", ArchitectureList{}, nil, "not tested on any architecture"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewGenerator()
+			gen.ArchSource = tt.source
+
+			_, err := gen.GenerateLinks(&LinkRequest{Package: "ovn", Suite: "noble", Architectures: tt.archs, DiscoverArchitectures: true})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestArchesForSuite(t *testing.T) {
+	origArches := SupportedArches
+	t.Cleanup(func() { SupportedArches = origArches })
+
+	if got := ArchesForSuite("noble"); !slices.Equal(got, SuiteArches["noble"]) {
+		t.Errorf("Expected %v for noble, got %v", SuiteArches["noble"], got)
+	}
+	if got := ArchesForSuite("focal"); slices.Contains(got, "riscv64") {
+		t.Errorf("Expected no riscv64 on focal, got %v", got)
+	}
+
+	// A suite only known from the index gets every supported architecture
+	if got := ArchesForSuite("future"); !slices.Equal(got, SupportedArches) {
+		t.Errorf("Expected %v for an unlisted suite, got %v", SupportedArches, got)
+	}
+
+	// Architectures dropped by the index are left out
+	SupportedArches = []string{"amd64", "arm64"}
+	if got := ArchesForSuite("noble"); !slices.Equal(got, []string{"amd64", "arm64"}) {
+		t.Errorf("Expected only the supported architectures, got %v", got)
+	}
+}
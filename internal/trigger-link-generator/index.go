@@ -0,0 +1,68 @@
+package triggerlinkgenerator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Index is a cached copy of the suites and architectures autopkgtest
+// currently runs tests for, used in place of the built-in lists when present
+type Index struct {
+	Suites  []string  `json:"suites"`
+	Arches  []string  `json:"arches"`
+	Updated time.Time `json:"updated"`
+}
+
+// DefaultIndexPath returns the location of the index cache in the user's
+// cache directory
+func DefaultIndexPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return filepath.Join(dir, "autopkgtest-cli", "index.json"), nil
+}
+
+// LoadIndex reads an index cache written by Save
+func LoadIndex(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("failed to parse index cache %s: %w", path, err)
+	}
+	if len(idx.Suites) == 0 || len(idx.Arches) == 0 {
+		return nil, fmt.Errorf("index cache %s has no suites or architectures", path)
+	}
+	return &idx, nil
+}
+
+// Save writes the index to path, creating its directory if needed
+func (idx *Index) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write index cache: %w", err)
+	}
+	return nil
+}
+
+// Apply replaces SupportedSuites and SupportedArches with the indexed values
+// so that validation uses them
+func (idx *Index) Apply() {
+	SupportedSuites = slices.Clone(idx.Suites)
+	SupportedArches = slices.Clone(idx.Arches)
+}
@@ -0,0 +1,47 @@
+package triggerlinkgenerator
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestIndexSaveLoadApply(t *testing.T) {
+	origSuites, origArches := SupportedSuites, SupportedArches
+	defer func() {
+		SupportedSuites, SupportedArches = origSuites, origArches
+	}()
+
+	path := filepath.Join(t.TempDir(), "cache", "index.json")
+	idx := &Index{
+		Suites: []string{"noble", "stonking"},
+		Arches: []string{"amd64", "riscv64"},
+	}
+	if err := idx.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := LoadIndex(path)
+	if err != nil {
+		t.Fatalf("LoadIndex failed: %v", err)
+	}
+	if !slices.Equal(loaded.Suites, idx.Suites) || !slices.Equal(loaded.Arches, idx.Arches) {
+		t.Errorf("Expected %v/%v, got %v/%v", idx.Suites, idx.Arches, loaded.Suites, loaded.Arches)
+	}
+
+	loaded.Apply()
+
+	g := NewGenerator()
+	if err := g.Validate(&LinkRequest{Package: "ovn", Suite: "stonking", Architectures: []string{"riscv64"}}); err != nil {
+		t.Errorf("Expected cached suite to validate, got: %v", err)
+	}
+	if err := g.Validate(&LinkRequest{Package: "ovn", Suite: "jammy"}); err == nil {
+		t.Error("Expected suite missing from the cache to fail validation")
+	}
+}
+
+func TestLoadIndex_Missing(t *testing.T) {
+	if _, err := LoadIndex(filepath.Join(t.TempDir(), "index.json")); err == nil {
+		t.Error("Expected error for missing index cache")
+	}
+}